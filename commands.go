@@ -0,0 +1,85 @@
+package main
+
+import (
+	"backend/config"
+	"backend/currency"
+	"backend/jobs"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// runCommand executes a CLI subcommand instead of starting the HTTP server
+// and returns the process exit code
+func runCommand(args []string) int {
+	switch args[0] {
+	case "gc-uploads":
+		return gcUploadsCommand(args[1:])
+	case "load-exchange-rates":
+		return loadExchangeRatesCommand(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\nAvailable commands:\n"+
+			"  gc-uploads            delete orphaned upload files and report dangling references\n"+
+			"  load-exchange-rates   load exchange rates from a CSV file\n", args[0])
+		return 2
+	}
+}
+
+func gcUploadsCommand(args []string) int {
+	flags := flag.NewFlagSet("gc-uploads", flag.ExitOnError)
+	grace := flags.Duration("grace", jobs.DefaultOrphanGracePeriod, "only delete orphans older than this")
+	dryRun := flags.Bool("dry-run", false, "report orphans without deleting them")
+	flags.Parse(args)
+
+	report, err := jobs.SweepOrphanedUploads(context.Background(), jobs.OrphanSweepOptions{
+		GracePeriod: *grace,
+		DryRun:      *dryRun,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gc-uploads:", err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+
+	if len(report.Failed) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func loadExchangeRatesCommand(args []string) int {
+	flags := flag.NewFlagSet("load-exchange-rates", flag.ExitOnError)
+	path := flags.String("file", "", "CSV file with the columns base,quote,rate[,effective_from]")
+	flags.Parse(args)
+
+	if *path == "" {
+		fmt.Fprintln(os.Stderr, "load-exchange-rates: -file is required")
+		return 2
+	}
+	file, err := os.Open(*path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "load-exchange-rates:", err)
+		return 1
+	}
+	defer file.Close()
+
+	loaded, rowErrors, err := currency.LoadCSV(config.DB, file, 0)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "load-exchange-rates:", err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(map[string]any{"loaded": loaded, "errors": rowErrors})
+
+	if len(rowErrors) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// AppBaseURL is the public base URL used when building absolute links
+// (uploaded images, signed file URLs). Override with APP_BASE_URL.
+var AppBaseURL = GetEnv("APP_BASE_URL", "http://localhost:8081")
+
+// GetEnv returns the value of the environment variable key, or fallback
+// when it is unset or empty.
+func GetEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// UploadSigningKey signs private file URLs and direct upload requests.
+// Override with UPLOAD_SIGNING_KEY.
+var UploadSigningKey = []byte(GetEnv("UPLOAD_SIGNING_KEY", "@Rute2023-files"))
+
+// GetDuration parses the environment variable key as a time.Duration (e.g.
+// "6h"), returning fallback when it is unset or invalid
+func GetDuration(key string, fallback time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
@@ -4,11 +4,13 @@ import (
 	"backend/config"
 	"backend/models"
 	"backend/utils"
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 func Register(c *gin.Context) {
@@ -69,6 +71,16 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	// Gabungkan keranjang tamu ke keranjang user; login tetap berhasil
+	// walaupun penggabungan gagal
+	if cartToken := c.GetHeader(cartTokenHeader); cartToken != "" {
+		if err := config.DB.Transaction(func(tx *gorm.DB) error {
+			return mergeGuestCart(tx, user.ID, cartToken)
+		}); err != nil {
+			log.Printf("failed to merge guest cart into cart of user %d: %v", user.ID, err)
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"token": token,
 		"name":  user.Name,
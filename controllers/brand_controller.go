@@ -0,0 +1,406 @@
+package controllers
+
+import (
+	"backend/config"
+	"backend/models"
+	"backend/search"
+	"backend/utils"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var errBrandNotFound = errors.New("brand not found")
+
+// GetBrands retrieves all brands. Pass active=true to hide inactive brands.
+func GetBrands(c *gin.Context) {
+	var brands []models.Brand
+
+	query := config.DB.Order("name ASC")
+	if active := c.Query("active"); active != "" {
+		query = query.Where("active = ?", active == "true")
+	}
+
+	if err := query.Find(&brands).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve brands",
+		})
+		return
+	}
+
+	counts := brandProductCounts()
+	responses := make([]models.BrandResponse, 0, len(brands))
+	for _, brand := range brands {
+		responses = append(responses, toBrandResponse(brand, counts[brand.ID]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Brands retrieved successfully",
+		"data":    responses,
+	})
+}
+
+// GetBrand retrieves a single brand by slug or ID
+func GetBrand(c *gin.Context) {
+	brand, ok := findBrandParam(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Brand retrieved successfully",
+		"data":    toBrandResponse(*brand, brandProductCounts()[brand.ID]),
+	})
+}
+
+// GetBrandProducts lists the products of a brand with pagination
+func GetBrandProducts(c *gin.Context) {
+	brand, ok := findBrandParam(c)
+	if !ok {
+		return
+	}
+
+	var products []models.Product
+	var total int64
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit := pageSize(c, "limit")
+	if page < 1 {
+		page = 1
+	}
+	status := c.DefaultQuery("status", models.ProductStatusActive)
+	condition, args, err := productStatusCondition(status, currentUserUUID(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	query := config.DB.Model(&models.Product{}).Where("brand_id = ?", brand.ID).Where(condition, args...)
+
+	query.Count(&total)
+
+	if err := query.Preload("CategoryRef").Preload("BrandRef").Preload("TaxClassRef").
+		Offset((page - 1) * limit).Limit(limit).
+		Order("created_at DESC").
+		Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve products",
+		})
+		return
+	}
+
+	variantSummaries := loadVariantSummaries(products)
+	responses := make([]models.ProductResponse, 0, len(products))
+	for _, product := range products {
+		response := toProductResponse(product)
+		response.Variants = variantSummaries[product.ID]
+		responses = append(responses, response)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Products retrieved successfully",
+		"brand":   toBrandResponse(*brand, total),
+		"data":    responses,
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// CreateBrand creates a new brand
+func CreateBrand(c *gin.Context) {
+	var request models.BrandCreateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	slug, err := uniqueSlug(&models.Brand{}, firstNonEmpty(request.Slug, request.Name), 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	brand := models.Brand{
+		Uuid:        uuid.New(),
+		Name:        strings.TrimSpace(request.Name),
+		Slug:        slug,
+		Description: request.Description,
+		Active:      true,
+	}
+	if request.Active != nil {
+		brand.Active = *request.Active
+	}
+
+	// Select Active explicitly so false is not replaced by the column default
+	if err := config.DB.Select("*").Create(&brand).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to create brand",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Brand created successfully",
+		"data":    toBrandResponse(brand, 0),
+	})
+}
+
+// UpdateBrand updates a brand; all fields are optional
+func UpdateBrand(c *gin.Context) {
+	brand, ok := findBrandParam(c)
+	if !ok {
+		return
+	}
+
+	var request models.BrandUpdateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	renamed := false
+	if request.Name != "" && strings.TrimSpace(request.Name) != brand.Name {
+		brand.Name = strings.TrimSpace(request.Name)
+		renamed = true
+	}
+	if request.Slug != "" {
+		slug, err := uniqueSlug(&models.Brand{}, request.Slug, brand.ID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		brand.Slug = slug
+	}
+	if request.Description != nil {
+		brand.Description = *request.Description
+	}
+	if request.Active != nil {
+		brand.Active = *request.Active
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(brand).Error; err != nil {
+			return err
+		}
+
+		// Keep the legacy brand name on products in sync
+		if renamed {
+			return tx.Unscoped().Model(&models.Product{}).
+				Where("brand_id = ?", brand.ID).
+				Update("brand", brand.Name).Error
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update brand",
+			"details": err.Error(),
+		})
+		return
+	}
+	if renamed {
+		search.SyncWhere("brand_id = ?", brand.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Brand updated successfully",
+		"data":    toBrandResponse(*brand, brandProductCounts()[brand.ID]),
+	})
+}
+
+// DeleteBrand deletes a brand that has no products. Deactivate brands that
+// are still in use instead.
+func DeleteBrand(c *gin.Context) {
+	brand, ok := findBrandParam(c)
+	if !ok {
+		return
+	}
+
+	var products int64
+	config.DB.Model(&models.Product{}).Where("brand_id = ?", brand.ID).Count(&products)
+	if products > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Brand still has products, deactivate it instead",
+			"details": gin.H{"products": products},
+		})
+		return
+	}
+
+	// Free the slug so it can be reused
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(brand).Update("slug", fmt.Sprintf("%s-deleted-%d", brand.Slug, brand.ID)).Error; err != nil {
+			return err
+		}
+		return tx.Delete(brand).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete brand",
+		})
+		return
+	}
+
+	if brand.LogoPath != "" {
+		if err := utils.ReleaseImage(brand.LogoPath); err != nil {
+			log.Printf("failed to release logo of brand %s: %v", brand.Uuid, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Brand deleted successfully",
+	})
+}
+
+// UploadBrandLogo uploads the logo of a brand through the regular image
+// pipeline (validation, metadata stripping, deduplication)
+func UploadBrandLogo(c *gin.Context) {
+	brand, ok := findBrandParam(c)
+	if !ok {
+		return
+	}
+
+	// Get uploaded file
+	fileHeader, err := c.FormFile("logo")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "No logo file provided",
+		})
+		return
+	}
+
+	imageResponse, err := utils.SaveUploadedBrandLogo(c, fileHeader)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	oldLogoPath := brand.LogoPath
+	brand.LogoPath = imageResponse.ImagePath
+	brand.LogoURL = imageResponse.ImageURL
+	brand.LogoHash = imageResponse.ImageHash
+
+	if err := config.DB.Save(brand).Error; err != nil {
+		if releaseErr := utils.ReleaseImage(imageResponse.ImagePath); releaseErr != nil {
+			log.Printf("failed to release unattached logo %s: %v", imageResponse.ImagePath, releaseErr)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update brand with logo info",
+		})
+		return
+	}
+
+	if oldLogoPath != "" {
+		if err := utils.ReleaseImage(oldLogoPath); err != nil {
+			log.Printf("failed to release replaced logo %s: %v", oldLogoPath, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Logo uploaded successfully",
+		"logo_url": brand.LogoURL,
+	})
+}
+
+// resolveProductBrand finds the brand for a product create or update request.
+// brandID wins; the legacy brand field may name an existing brand by name or
+// slug.
+func resolveProductBrand(brandID, brandName string) (*models.Brand, error) {
+	if brandID != "" {
+		return findBrand(brandID)
+	}
+
+	var brand models.Brand
+	if err := config.DB.
+		Where("slug = ? OR LOWER(name) = LOWER(?)", utils.Slugify(brandName), strings.TrimSpace(brandName)).
+		First(&brand).Error; err != nil {
+		return nil, errBrandNotFound
+	}
+	return &brand, nil
+}
+
+// findBrand looks a brand up by UUID or slug
+func findBrand(identifier string) (*models.Brand, error) {
+	var brand models.Brand
+	query := config.DB.Where("slug = ?", identifier)
+	if id, err := uuid.Parse(identifier); err == nil {
+		query = config.DB.Where("uuid = ?", id)
+	}
+
+	if err := query.First(&brand).Error; err != nil {
+		return nil, errBrandNotFound
+	}
+	return &brand, nil
+}
+
+// findBrandParam loads the brand named by the :slug URL parameter and writes
+// the error response itself when it cannot
+func findBrandParam(c *gin.Context) (*models.Brand, bool) {
+	brand, err := findBrand(c.Param("slug"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Brand not found",
+		})
+		return nil, false
+	}
+	return brand, true
+}
+
+// brandProductCounts returns the number of products per brand ID
+func brandProductCounts() map[uint]int64 {
+	var rows []struct {
+		BrandID uint
+		Total   int64
+	}
+	config.DB.Model(&models.Product{}).
+		Select("brand_id, COUNT(*) AS total").
+		Where("brand_id IS NOT NULL").
+		Group("brand_id").
+		Scan(&rows)
+
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.BrandID] = row.Total
+	}
+	return counts
+}
+
+func toBrandResponse(brand models.Brand, productCount int64) models.BrandResponse {
+	return models.BrandResponse{
+		ID:           brand.Uuid,
+		Name:         brand.Name,
+		Slug:         brand.Slug,
+		Description:  brand.Description,
+		LogoURL:      brand.LogoURL,
+		Active:       brand.Active,
+		ProductCount: productCount,
+		CreatedAt:    brand.CreatedAt,
+		UpdatedAt:    brand.UpdatedAt,
+	}
+}
@@ -0,0 +1,645 @@
+package controllers
+
+import (
+	"backend/config"
+	"backend/inventory"
+	"backend/models"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// cartTokenHeader carries the token of a guest cart
+const cartTokenHeader = "X-Cart-Token"
+
+var errCartItemNotFound = errors.New("cart item not found")
+
+// stockKey identifies the stock of a product, or of one of its variants
+type stockKey struct {
+	productID uint
+	variantID uint
+}
+
+// GetCart returns the cart of the logged-in user, or the guest cart of the
+// X-Cart-Token header. Without a cart the response is an empty cart.
+func GetCart(c *gin.Context) {
+	cart, err := findCart(c)
+	if err != nil {
+		handleCartError(c, err, "Failed to retrieve cart")
+		return
+	}
+
+	response, err := loadCartResponse(cart, currentUserID(c))
+	if err != nil {
+		handleCartError(c, err, "Failed to retrieve cart")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Cart retrieved successfully",
+		"data":    response,
+	})
+}
+
+// AddCartItem adds a product or variant to the cart, creating the cart on
+// first use. Adding an item that is already in the cart raises its quantity
+// and keeps its price. Guests get the token of a new cart in the response
+// and in the X-Cart-Token header.
+func AddCartItem(c *gin.Context) {
+	var request models.CartItemRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var product models.Product
+	if err := config.DB.Where("uuid = ?", request.ProductID).First(&product).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Product not found",
+		})
+		return
+	}
+	var variant *models.ProductVariant
+	if request.VariantID != "" {
+		variant = &models.ProductVariant{}
+		if err := config.DB.Where("uuid = ? AND product_id = ?", request.VariantID, product.ID).First(variant).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Variant not found",
+			})
+			return
+		}
+	} else {
+		var variants int64
+		config.DB.Model(&models.ProductVariant{}).Where("product_id = ?", product.ID).Count(&variants)
+		if variants > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request data",
+				"details": "variant_id is required for products with variants",
+			})
+			return
+		}
+	}
+	if product.Status != models.ProductStatusActive || (variant != nil && variant.Status != "active") {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Product is not available",
+		})
+		return
+	}
+
+	userID := currentUserID(c)
+	var cart *models.Cart
+	var token string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		cart, token, err = openCart(tx, c, true)
+		if err != nil {
+			return err
+		}
+
+		item := models.CartItem{CartID: cart.ID, ProductID: product.ID}
+		query := tx.Where("cart_id = ? AND product_id = ?", cart.ID, product.ID)
+		if variant != nil {
+			item.VariantID = &variant.ID
+			query = query.Where("variant_id = ?", variant.ID)
+		} else {
+			query = query.Where("variant_id IS NULL")
+		}
+		err = query.First(&item).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err := checkCartStock(tx, item, item.Quantity+request.Quantity); err != nil {
+			return err
+		}
+
+		if item.ID != 0 {
+			err = tx.Model(&item).Update("quantity", item.Quantity+request.Quantity).Error
+		} else {
+			now := time.Now()
+			item.Uuid = uuid.New()
+			item.Quantity = request.Quantity
+			item.UnitPrice = cartUnitPrice(product, variant, customerPrices(userID, []models.Product{product}, now)[product.ID], now)
+			err = tx.Create(&item).Error
+		}
+		if err != nil {
+			return err
+		}
+		return touchCart(tx, cart)
+	})
+	if err != nil {
+		handleCartError(c, err, "Failed to add item to cart")
+		return
+	}
+
+	response, err := loadCartResponse(cart, userID)
+	if err != nil {
+		handleCartError(c, err, "Failed to retrieve cart")
+		return
+	}
+	if token != "" {
+		response.Token = token
+		c.Header(cartTokenHeader, token)
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Item added to cart successfully",
+		"data":    response,
+	})
+}
+
+// UpdateCartItem sets the quantity of a cart item. The price of the item is
+// kept.
+func UpdateCartItem(c *gin.Context) {
+	itemUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid cart item ID",
+		})
+		return
+	}
+
+	var request models.CartItemUpdateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var cart *models.Cart
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		cart, _, err = openCart(tx, c, false)
+		if err != nil {
+			return err
+		}
+		item, err := findCartItem(tx, cart, itemUUID)
+		if err != nil {
+			return err
+		}
+		// Lowering the quantity is always allowed, even when stock has run
+		// short since the item was added
+		if request.Quantity > item.Quantity {
+			if err := checkCartStock(tx, *item, request.Quantity); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(item).Update("quantity", request.Quantity).Error; err != nil {
+			return err
+		}
+		return touchCart(tx, cart)
+	})
+	if err != nil {
+		handleCartError(c, err, "Failed to update cart item")
+		return
+	}
+
+	response, err := loadCartResponse(cart, currentUserID(c))
+	if err != nil {
+		handleCartError(c, err, "Failed to retrieve cart")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Cart item updated successfully",
+		"data":    response,
+	})
+}
+
+// RemoveCartItem removes an item from the cart
+func RemoveCartItem(c *gin.Context) {
+	itemUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid cart item ID",
+		})
+		return
+	}
+
+	var cart *models.Cart
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		cart, _, err = openCart(tx, c, false)
+		if err != nil {
+			return err
+		}
+		item, err := findCartItem(tx, cart, itemUUID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Delete(item).Error; err != nil {
+			return err
+		}
+		return touchCart(tx, cart)
+	})
+	if err != nil {
+		handleCartError(c, err, "Failed to remove cart item")
+		return
+	}
+
+	response, err := loadCartResponse(cart, currentUserID(c))
+	if err != nil {
+		handleCartError(c, err, "Failed to retrieve cart")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Cart item removed successfully",
+		"data":    response,
+	})
+}
+
+// mergeGuestCart moves the items of the guest cart of token into the cart
+// of a user within tx, creating the user's cart if needed, and deletes the
+// guest cart. Items already in the user's cart get the quantities added up
+// and moved items keep theirs, limited to the available stock; items out of
+// stock are dropped. Moved items are priced again for the user, whose
+// customer group may have a price list. Unknown tokens are ignored.
+func mergeGuestCart(tx *gorm.DB, userID uint, token string) error {
+	if token == "" {
+		return nil
+	}
+	guest, err := lockGuestCart(tx, token)
+	if guest == nil || err != nil {
+		return err
+	}
+	cart, err := lockUserCart(tx, userID, true)
+	if err != nil {
+		return err
+	}
+
+	var guestItems, userItems []models.CartItem
+	if err := tx.Preload("Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).Preload("Variant").
+		Where("cart_id = ?", guest.ID).Order("id ASC").Find(&guestItems).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("cart_id = ?", cart.ID).Find(&userItems).Error; err != nil {
+		return err
+	}
+	existing := make(map[stockKey]models.CartItem, len(userItems))
+	for _, item := range userItems {
+		existing[cartItemKey(item)] = item
+	}
+
+	products := make([]models.Product, 0, len(guestItems))
+	productIDs := make([]uint, 0, len(guestItems))
+	for _, item := range guestItems {
+		if item.Product != nil {
+			products = append(products, *item.Product)
+		}
+		productIDs = append(productIDs, item.ProductID)
+	}
+	now := time.Now()
+	prices := customerPrices(userID, products, now)
+	available, err := loadCartAvailability(tx, productIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range guestItems {
+		key := cartItemKey(item)
+		if current, ok := existing[key]; ok {
+			quantity := current.Quantity + item.Quantity
+			if quantity > available[key] {
+				quantity = max(available[key], current.Quantity)
+			}
+			if err := tx.Model(&current).Update("quantity", quantity).Error; err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Moved items are limited to the available stock like added ones;
+		// items without any stay behind and are deleted with the guest cart
+		if available[key] <= 0 {
+			continue
+		}
+		updates := map[string]interface{}{"cart_id": cart.ID, "quantity": min(item.Quantity, available[key])}
+		if item.Product != nil {
+			price := cartUnitPrice(*item.Product, item.Variant, prices[item.ProductID], now)
+			updates["unit_price_amount"] = price.Amount
+			updates["unit_price_currency"] = price.Currency
+		}
+		if err := tx.Model(&item).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Where("cart_id = ?", guest.ID).Delete(&models.CartItem{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Delete(guest).Error; err != nil {
+		return err
+	}
+	return touchCart(tx, cart)
+}
+
+// findCart returns the cart of the request like openCart, without locking
+// it, or nil when there is none
+func findCart(c *gin.Context) (*models.Cart, error) {
+	query := config.DB.Where("user_id = ?", currentUserID(c))
+	if currentUserID(c) == 0 {
+		token := c.GetHeader(cartTokenHeader)
+		if token == "" {
+			return nil, nil
+		}
+		query = config.DB.Where("token_hash = ?", hashCartToken(token))
+	}
+
+	var cart models.Cart
+	err := query.First(&cart).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+// openCart locks the cart of the request within tx: the cart of the
+// logged-in user, or the guest cart of the X-Cart-Token header. With create
+// a missing cart is created; the token of a new guest cart is returned so
+// it can be handed to the client. Without create a missing cart is nil.
+func openCart(tx *gorm.DB, c *gin.Context, create bool) (*models.Cart, string, error) {
+	if userID := currentUserID(c); userID != 0 {
+		cart, err := lockUserCart(tx, userID, create)
+		return cart, "", err
+	}
+
+	if token := c.GetHeader(cartTokenHeader); token != "" {
+		cart, err := lockGuestCart(tx, token)
+		if cart != nil || err != nil || !create {
+			return cart, "", err
+		}
+	} else if !create {
+		return nil, "", nil
+	}
+
+	// New guest cart, also for unknown tokens, e.g. of a cart that has been
+	// merged into a user's cart
+	token, err := newCartToken()
+	if err != nil {
+		return nil, "", err
+	}
+	hash := hashCartToken(token)
+	cart := models.Cart{Uuid: uuid.New(), TokenHash: &hash}
+	if err := tx.Create(&cart).Error; err != nil {
+		return nil, "", err
+	}
+	return &cart, token, nil
+}
+
+// lockUserCart locks the cart of a user, creating it with create
+func lockUserCart(tx *gorm.DB, userID uint, create bool) (*models.Cart, error) {
+	var cart models.Cart
+	lookup := func() error {
+		return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&cart).Error
+	}
+	err := lookup()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if !create {
+			return nil, nil
+		}
+		cart = models.Cart{Uuid: uuid.New(), UserID: &userID}
+		if err = tx.Create(&cart).Error; err != nil {
+			// A concurrent request may have created the cart since the
+			// lookup, so the insert failed on the unique user_id; lock
+			// that cart instead
+			cart = models.Cart{}
+			if lookup() == nil {
+				return &cart, nil
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+// lockGuestCart locks the guest cart of a token, or returns nil when there
+// is none
+func lockGuestCart(tx *gorm.DB, token string) (*models.Cart, error) {
+	var cart models.Cart
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("token_hash = ?", hashCartToken(token)).First(&cart).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+// touchCart records that the items of a cart changed
+func touchCart(tx *gorm.DB, cart *models.Cart) error {
+	return tx.Model(cart).Update("updated_at", time.Now()).Error
+}
+
+// findCartItem loads an item of cart
+func findCartItem(tx *gorm.DB, cart *models.Cart, itemUUID uuid.UUID) (*models.CartItem, error) {
+	if cart == nil {
+		return nil, errCartItemNotFound
+	}
+	var item models.CartItem
+	err := tx.Where("uuid = ? AND cart_id = ?", itemUUID, cart.ID).First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errCartItemNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// checkCartStock checks that quantity of the product or variant of item is
+// available. Carts don't reserve stock, so this only keeps customers from
+// adding more than could be sold right now.
+func checkCartStock(tx *gorm.DB, item models.CartItem, quantity int) error {
+	available, err := loadCartAvailability(tx, []uint{item.ProductID})
+	if err != nil {
+		return err
+	}
+	if quantity > available[cartItemKey(item)] {
+		return fmt.Errorf("%w: %d available, %d requested", inventory.ErrInsufficientStock, available[cartItemKey(item)], quantity)
+	}
+	return nil
+}
+
+// loadCartAvailability returns the stock available in active warehouses of
+// the given products and their variants
+func loadCartAvailability(tx *gorm.DB, productIDs []uint) (map[stockKey]int, error) {
+	available := make(map[stockKey]int)
+	if len(productIDs) == 0 {
+		return available, nil
+	}
+
+	var rows []struct {
+		ProductID uint
+		VariantID uint
+		Available int
+	}
+	if err := tx.Table("stock_levels AS l").
+		Select("l.product_id, l.variant_id, SUM(GREATEST(l.quantity - l.reserved, 0)) AS available").
+		Joins("JOIN warehouses w ON w.id = l.warehouse_id").
+		Where("l.product_id IN ? AND w.active = ?", productIDs, true).
+		Group("l.product_id, l.variant_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		available[stockKey{row.ProductID, row.VariantID}] = row.Available
+	}
+	return available, nil
+}
+
+// cartItemKey returns the stock key of the product or variant of an item
+func cartItemKey(item models.CartItem) stockKey {
+	key := stockKey{productID: item.ProductID}
+	if item.VariantID != nil {
+		key.variantID = *item.VariantID
+	}
+	return key
+}
+
+// cartUnitPrice returns the price of a product or variant for a cart: the
+// price override of the variant, or else the current price of the product
+// or the customer's price list price, whichever is lower
+func cartUnitPrice(product models.Product, variant *models.ProductVariant, customer *models.CustomerPrice, at time.Time) models.Money {
+	if variant != nil && variant.PriceAmount != nil {
+		return models.Money{Amount: *variant.PriceAmount, Currency: product.Price.Currency}
+	}
+	price := product.CurrentPrice(at)
+	if customer != nil && customer.Price.Amount < price.Amount {
+		return customer.Price
+	}
+	return price
+}
+
+// loadCartResponse builds the response of a cart with the current prices
+// and availability of its items. A nil cart is an empty cart.
+func loadCartResponse(cart *models.Cart, userID uint) (models.CartResponse, error) {
+	response := models.CartResponse{
+		Items:     []models.CartItemResponse{},
+		Subtotals: []models.Money{},
+	}
+	if cart == nil {
+		return response, nil
+	}
+	if err := config.DB.First(cart, cart.ID).Error; err != nil {
+		return response, err
+	}
+	response.ID = &cart.Uuid
+	response.UpdatedAt = &cart.UpdatedAt
+
+	var items []models.CartItem
+	if err := config.DB.Preload("Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).Preload("Variant").
+		Where("cart_id = ?", cart.ID).Order("id ASC").Find(&items).Error; err != nil {
+		return response, err
+	}
+
+	products := make([]models.Product, 0, len(items))
+	productIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		if item.Product != nil {
+			products = append(products, *item.Product)
+		}
+		productIDs = append(productIDs, item.ProductID)
+	}
+	now := time.Now()
+	prices := customerPrices(userID, products, now)
+	available, err := loadCartAvailability(config.DB, productIDs)
+	if err != nil {
+		return response, err
+	}
+
+	subtotals := make(map[string]int)
+	for _, item := range items {
+		itemResponse := models.CartItemResponse{
+			ID:           item.Uuid,
+			Quantity:     item.Quantity,
+			UnitPrice:    item.UnitPrice,
+			CurrentPrice: item.UnitPrice,
+			LineTotal:    models.Money{Amount: item.UnitPrice.Amount * int64(item.Quantity), Currency: item.UnitPrice.Currency},
+			Available:    available[cartItemKey(item)],
+			CreatedAt:    item.CreatedAt,
+			UpdatedAt:    item.UpdatedAt,
+		}
+		purchasable := itemResponse.Available >= item.Quantity
+		if product := item.Product; product != nil {
+			itemResponse.ProductID = product.Uuid
+			itemResponse.Name = product.Name
+			itemResponse.SKU = product.SKU
+			itemResponse.ImageURL = product.ImageURL
+			itemResponse.CurrentPrice = cartUnitPrice(*product, item.Variant, prices[item.ProductID], now)
+			purchasable = purchasable && !product.DeletedAt.Valid && product.Status == models.ProductStatusActive
+		}
+		if variant := item.Variant; variant != nil {
+			itemResponse.VariantID = &variant.Uuid
+			itemResponse.SKU = variant.SKU
+			if variant.ImageURL != "" {
+				itemResponse.ImageURL = variant.ImageURL
+			}
+			purchasable = purchasable && variant.Status == "active"
+		}
+		itemResponse.PriceChanged = itemResponse.CurrentPrice != item.UnitPrice
+		itemResponse.Purchasable = purchasable
+		response.Items = append(response.Items, itemResponse)
+		response.Quantity += item.Quantity
+
+		// Subtotals in the order their currencies first appear
+		index, ok := subtotals[item.UnitPrice.Currency]
+		if !ok {
+			index = len(response.Subtotals)
+			subtotals[item.UnitPrice.Currency] = index
+			response.Subtotals = append(response.Subtotals, models.Money{Currency: item.UnitPrice.Currency})
+		}
+		response.Subtotals[index].Amount += itemResponse.LineTotal.Amount
+	}
+	return response, nil
+}
+
+// handleCartError writes the error response of a failed cart operation
+func handleCartError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, errCartItemNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Cart item not found",
+		})
+	case errors.Is(err, inventory.ErrInsufficientStock):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Insufficient stock",
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
+
+// newCartToken returns a random guest cart token
+func newCartToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// hashCartToken returns the stored form of a guest cart token
+func hashCartToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
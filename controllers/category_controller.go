@@ -0,0 +1,484 @@
+package controllers
+
+import (
+	"backend/config"
+	"backend/models"
+	"backend/search"
+	"backend/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var errCategoryNotFound = errors.New("category not found")
+
+// GetCategories retrieves all categories as a flat list ordered by position
+func GetCategories(c *gin.Context) {
+	categories, counts, err := loadCategories()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve categories",
+		})
+		return
+	}
+
+	byID := indexCategories(categories)
+	responses := make([]models.CategoryResponse, 0, len(categories))
+	for _, category := range categories {
+		responses = append(responses, toCategoryResponse(category, byID, counts[category.ID]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Categories retrieved successfully",
+		"data":    responses,
+	})
+}
+
+// GetCategoryTree retrieves the categories nested under their parents.
+// product_count includes the products of all descendants.
+func GetCategoryTree(c *gin.Context) {
+	categories, counts, err := loadCategories()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve categories",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Category tree retrieved successfully",
+		"data":    buildCategoryTree(categories, counts),
+	})
+}
+
+// GetCategoryByID retrieves a single category by ID or slug
+func GetCategoryByID(c *gin.Context) {
+	category, err := findCategory(config.DB, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Category not found",
+		})
+		return
+	}
+
+	var count int64
+	config.DB.Model(&models.Product{}).Where("category_id = ?", category.ID).Count(&count)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Category retrieved successfully",
+		"data":    categoryResponseWithParent(*category, count),
+	})
+}
+
+// CreateCategory creates a new category
+func CreateCategory(c *gin.Context) {
+	var request models.CategoryCreateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	category := models.Category{
+		Uuid:        uuid.New(),
+		Name:        strings.TrimSpace(request.Name),
+		Description: request.Description,
+		Position:    request.Position,
+	}
+
+	if request.ParentID != "" {
+		parent, err := findCategory(config.DB, request.ParentID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Parent category not found",
+			})
+			return
+		}
+		category.ParentID = &parent.ID
+	}
+
+	slug, err := uniqueSlug(&models.Category{}, firstNonEmpty(request.Slug, request.Name), 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	category.Slug = slug
+
+	if err := config.DB.Create(&category).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to create category",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Category created successfully",
+		"data":    categoryResponseWithParent(category, 0),
+	})
+}
+
+// UpdateCategory updates a category. Moving it under one of its own
+// descendants is rejected.
+func UpdateCategory(c *gin.Context) {
+	category, err := findCategory(config.DB, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Category not found",
+		})
+		return
+	}
+
+	var request models.CategoryUpdateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	renamed := false
+	if request.Name != "" && strings.TrimSpace(request.Name) != category.Name {
+		category.Name = strings.TrimSpace(request.Name)
+		renamed = true
+	}
+	if request.Slug != "" {
+		slug, err := uniqueSlug(&models.Category{}, request.Slug, category.ID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		category.Slug = slug
+	}
+	if request.Description != nil {
+		category.Description = *request.Description
+	}
+	if request.Position != nil {
+		category.Position = *request.Position
+	}
+	if request.ParentID != nil {
+		if *request.ParentID == "" {
+			category.ParentID = nil
+		} else {
+			parent, err := findCategory(config.DB, *request.ParentID)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "Parent category not found",
+				})
+				return
+			}
+			if err := checkCategoryParent(category, parent); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+			category.ParentID = &parent.ID
+		}
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Parent", "Children").Save(category).Error; err != nil {
+			return err
+		}
+
+		// Keep the legacy category name on products in sync
+		if renamed {
+			return tx.Unscoped().Model(&models.Product{}).
+				Where("category_id = ?", category.ID).
+				Update("category", category.Name).Error
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update category",
+			"details": err.Error(),
+		})
+		return
+	}
+	if renamed {
+		search.SyncWhere("category_id = ?", category.ID)
+	}
+
+	var productCount int64
+	config.DB.Model(&models.Product{}).Where("category_id = ?", category.ID).Count(&productCount)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Category updated successfully",
+		"data":    categoryResponseWithParent(*category, productCount),
+	})
+}
+
+// DeleteCategory deletes a category that has no subcategories and no
+// products
+func DeleteCategory(c *gin.Context) {
+	category, err := findCategory(config.DB, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Category not found",
+		})
+		return
+	}
+
+	var children, products int64
+	config.DB.Model(&models.Category{}).Where("parent_id = ?", category.ID).Count(&children)
+	config.DB.Model(&models.Product{}).Where("category_id = ?", category.ID).Count(&products)
+	if children > 0 || products > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Category still has subcategories or products",
+			"details": gin.H{
+				"subcategories": children,
+				"products":      products,
+			},
+		})
+		return
+	}
+
+	// Free the slug so it can be reused
+	category.Slug = fmt.Sprintf("%s-deleted-%d", category.Slug, category.ID)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(category).Update("slug", category.Slug).Error; err != nil {
+			return err
+		}
+		return tx.Delete(category).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete category",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Category deleted successfully",
+	})
+}
+
+// resolveProductCategory finds the category for a product create or update
+// request. categoryID wins; the legacy category field may name an existing
+// category by name or slug.
+func resolveProductCategory(categoryID, categoryName string) (*models.Category, error) {
+	if categoryID != "" {
+		return findCategory(config.DB, categoryID)
+	}
+	if strings.TrimSpace(categoryName) == "" {
+		return nil, errCategoryNotFound
+	}
+
+	var category models.Category
+	err := config.DB.
+		Where("slug = ? OR LOWER(name) = LOWER(?)", utils.Slugify(categoryName), strings.TrimSpace(categoryName)).
+		First(&category).Error
+	if err != nil {
+		return nil, errCategoryNotFound
+	}
+	return &category, nil
+}
+
+// categorySubtreeIDs returns the IDs of the category named by identifier (ID,
+// slug or name) and all of its descendants
+func categorySubtreeIDs(identifier string) ([]uint, error) {
+	var categories []models.Category
+	if err := config.DB.Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	slug := utils.Slugify(identifier)
+	var root *models.Category
+	for i := range categories {
+		category := &categories[i]
+		if category.Uuid.String() == identifier || category.Slug == slug || strings.EqualFold(category.Name, identifier) {
+			root = category
+			break
+		}
+	}
+	if root == nil {
+		return nil, errCategoryNotFound
+	}
+
+	children := make(map[uint][]uint)
+	for _, category := range categories {
+		if category.ParentID != nil {
+			children[*category.ParentID] = append(children[*category.ParentID], category.ID)
+		}
+	}
+
+	ids := []uint{root.ID}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, children[ids[i]]...)
+	}
+	return ids, nil
+}
+
+// findCategory looks a category up by UUID or slug
+func findCategory(db *gorm.DB, identifier string) (*models.Category, error) {
+	var category models.Category
+	query := db.Where("slug = ?", identifier)
+	if id, err := uuid.Parse(identifier); err == nil {
+		query = db.Where("uuid = ?", id)
+	}
+
+	if err := query.First(&category).Error; err != nil {
+		return nil, errCategoryNotFound
+	}
+	return &category, nil
+}
+
+// checkCategoryParent rejects moving a category below itself
+func checkCategoryParent(category, parent *models.Category) error {
+	for current := parent; current != nil; {
+		if current.ID == category.ID {
+			return errors.New("a category cannot be moved below itself or one of its subcategories")
+		}
+		if current.ParentID == nil {
+			return nil
+		}
+
+		next := &models.Category{}
+		if err := config.DB.First(next, *current.ParentID).Error; err != nil {
+			return nil
+		}
+		current = next
+	}
+	return nil
+}
+
+// uniqueSlug slugifies value and appends a counter if the slug is taken by
+// another row of model (a category or brand)
+func uniqueSlug(model interface{}, value string, excludeID uint) (string, error) {
+	base := utils.Slugify(value)
+	if base == "" {
+		return "", errors.New("slug must contain letters or digits")
+	}
+
+	slug := base
+	for i := 2; ; i++ {
+		var count int64
+		config.DB.Unscoped().Model(model).Where("slug = ? AND id <> ?", slug, excludeID).Count(&count)
+		if count == 0 {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// loadCategories returns all categories in display order together with the
+// number of products directly assigned to each
+func loadCategories() ([]models.Category, map[uint]int64, error) {
+	var categories []models.Category
+	if err := config.DB.Order("position ASC, name ASC").Find(&categories).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var rows []struct {
+		CategoryID uint
+		Total      int64
+	}
+	if err := config.DB.Model(&models.Product{}).
+		Select("category_id, COUNT(*) AS total").
+		Where("category_id IS NOT NULL").
+		Group("category_id").
+		Scan(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.CategoryID] = row.Total
+	}
+	return categories, counts, nil
+}
+
+func indexCategories(categories []models.Category) map[uint]*models.Category {
+	byID := make(map[uint]*models.Category, len(categories))
+	for i := range categories {
+		byID[categories[i].ID] = &categories[i]
+	}
+	return byID
+}
+
+// buildCategoryTree nests categories under their parents, summing product
+// counts up the tree
+func buildCategoryTree(categories []models.Category, counts map[uint]int64) []models.CategoryResponse {
+	byID := indexCategories(categories)
+	children := make(map[uint][]models.Category)
+	var roots []models.Category
+	for _, category := range categories {
+		if category.ParentID == nil || byID[*category.ParentID] == nil {
+			roots = append(roots, category)
+		} else {
+			children[*category.ParentID] = append(children[*category.ParentID], category)
+		}
+	}
+
+	var build func(category models.Category) models.CategoryResponse
+	build = func(category models.Category) models.CategoryResponse {
+		response := toCategoryResponse(category, byID, counts[category.ID])
+		for _, child := range children[category.ID] {
+			childResponse := build(child)
+			response.ProductCount += childResponse.ProductCount
+			response.Children = append(response.Children, childResponse)
+		}
+		return response
+	}
+
+	tree := make([]models.CategoryResponse, 0, len(roots))
+	for _, root := range roots {
+		tree = append(tree, build(root))
+	}
+	return tree
+}
+
+// toCategoryResponse converts a category; byID resolves the parent UUID when
+// the parent is loaded
+func toCategoryResponse(category models.Category, byID map[uint]*models.Category, productCount int64) models.CategoryResponse {
+	response := models.CategoryResponse{
+		ID:           category.Uuid,
+		Name:         category.Name,
+		Slug:         category.Slug,
+		Description:  category.Description,
+		Position:     category.Position,
+		ProductCount: productCount,
+	}
+	if category.ParentID != nil {
+		if parent := byID[*category.ParentID]; parent != nil {
+			response.ParentID = &parent.Uuid
+		}
+	}
+	return response
+}
+
+// categoryResponseWithParent converts a single category, loading its parent
+// to fill in parent_id
+func categoryResponseWithParent(category models.Category, productCount int64) models.CategoryResponse {
+	byID := map[uint]*models.Category{}
+	if category.ParentID != nil {
+		var parent models.Category
+		if err := config.DB.First(&parent, *category.ParentID).Error; err == nil {
+			byID[parent.ID] = &parent
+		}
+	}
+	return toCategoryResponse(category, byID, productCount)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
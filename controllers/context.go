@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"backend/config"
+	"backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// currentUserID returns the authenticated user's ID set by the JWT
+// middleware, or 0 for anonymous requests
+func currentUserID(c *gin.Context) uint {
+	if userID, exists := c.Get("user_id"); exists {
+		if uid, ok := userID.(uint); ok {
+			return uid
+		}
+	}
+	return 0
+}
+
+// currentUserUUID returns the authenticated user's UUID, or uuid.Nil for
+// anonymous requests
+func currentUserUUID(c *gin.Context) uuid.UUID {
+	return userUUID(currentUserID(c))
+}
+
+// userUUID returns the UUID of a user, or uuid.Nil when there is none
+func userUUID(userID uint) uuid.UUID {
+	var id uuid.UUID
+	if userID != 0 {
+		config.DB.Model(&models.User{}).Where("id = ?", userID).Select("uuid").Scan(&id)
+	}
+	return id
+}
@@ -0,0 +1,243 @@
+package controllers
+
+import (
+	"backend/config"
+	"backend/currency"
+	"backend/models"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetExchangeRates lists the rates in effect, one per currency pair. Filter
+// with base and quote, pass at (RFC 3339) to see the rates of another time
+// and history=true to list every stored rate instead.
+func GetExchangeRates(c *gin.Context) {
+	at := time.Now()
+	if value := c.Query("at"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid query parameters",
+				"details": "at must be an RFC 3339 timestamp",
+			})
+			return
+		}
+		at = parsed
+	}
+
+	query := config.DB.Model(&models.ExchangeRate{})
+	if base := strings.ToUpper(c.Query("base")); base != "" {
+		query = query.Where("base_currency = ?", base)
+	}
+	if quote := strings.ToUpper(c.Query("quote")); quote != "" {
+		query = query.Where("quote_currency = ?", quote)
+	}
+	if c.Query("history") != "true" {
+		// Latest rate of each pair that has taken effect at that time
+		query = query.Where("effective_from = (SELECT MAX(r.effective_from) FROM exchange_rates r WHERE r.base_currency = exchange_rates.base_currency AND r.quote_currency = exchange_rates.quote_currency AND r.effective_from <= ?)", at)
+	}
+
+	var rates []models.ExchangeRate
+	if err := query.Order("base_currency, quote_currency, effective_from DESC").Find(&rates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve exchange rates",
+		})
+		return
+	}
+
+	responses := make([]models.ExchangeRate, 0, len(rates))
+	for _, rate := range rates {
+		responses = append(responses, toExchangeRateResponse(rate))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Exchange rates retrieved successfully",
+		"data":    responses,
+	})
+}
+
+// CreateExchangeRate stores a rate for a currency pair. A rate with the same
+// pair and effective time replaces the stored one.
+func CreateExchangeRate(c *gin.Context) {
+	var req models.ExchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rate, err := currency.NewRate(req.Base, req.Quote, req.Rate.String(), req.EffectiveFrom, models.ExchangeRateManual, currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid exchange rate",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := currency.SaveRate(config.DB, rate); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to save exchange rate",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Exchange rate saved successfully",
+		"data":    toExchangeRateResponse(*rate),
+	})
+}
+
+// ImportExchangeRates loads the rates of an uploaded CSV file. The file is
+// loaded completely or not at all.
+func ImportExchangeRates(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "No file provided",
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read file",
+		})
+		return
+	}
+	defer file.Close()
+
+	loaded, rowErrors, err := currency.LoadCSV(config.DB, file, currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to import exchange rates",
+			"details": err.Error(),
+		})
+		return
+	}
+	if len(rowErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid rows, no rates were imported",
+			"details": rowErrors,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Exchange rates imported successfully",
+		"data":    gin.H{"loaded": loaded},
+	})
+}
+
+// DeleteExchangeRate removes a stored rate. The previous rate of the pair,
+// if any, is in effect again.
+func DeleteExchangeRate(c *gin.Context) {
+	rateUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid exchange rate ID",
+		})
+		return
+	}
+
+	var rate models.ExchangeRate
+	if err := config.DB.Where("uuid = ?", rateUUID).First(&rate).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Exchange rate not found",
+		})
+		return
+	}
+
+	if err := config.DB.Delete(&rate).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete exchange rate",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Exchange rate deleted successfully",
+	})
+}
+
+// toExchangeRateResponse formats the stored decimal without trailing zeros
+func toExchangeRateResponse(rate models.ExchangeRate) models.ExchangeRate {
+	if value, err := currency.ParseRate(rate.Rate); err == nil {
+		rate.Rate = currency.FormatRate(value)
+	}
+	return rate
+}
+
+// priceConverter reads the currency and rounding query parameters. It
+// returns a nil converter when no currency was requested and writes the
+// error response itself when the parameters are invalid.
+func priceConverter(c *gin.Context) (*currency.Converter, string, bool) {
+	target := strings.ToUpper(strings.TrimSpace(c.Query("currency")))
+	if target == "" {
+		return nil, "", true
+	}
+	if !models.ValidCurrency(target) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"details": "unknown currency " + target,
+		})
+		return nil, "", false
+	}
+
+	converter, err := currency.NewConverter(config.DB, time.Now(), c.DefaultQuery("rounding", currency.DefaultRounding()))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"details": err.Error(),
+		})
+		return nil, "", false
+	}
+	return converter, target, true
+}
+
+// convertPrice sets the converted prices of a product response: the price,
+// the current price and the sale and compare-at prices when set. It writes
+// the error response itself when no rate is available.
+func convertPrice(c *gin.Context, converter *currency.Converter, target string, response *models.ProductResponse) bool {
+	conversion, err := converter.Convert(response.Price, target)
+	convert := func(price *models.Money) *models.Money {
+		if price == nil || err != nil {
+			return nil
+		}
+		var converted *models.PriceConversion
+		if converted, err = converter.Convert(*price, target); err != nil {
+			return nil
+		}
+		return &converted.Price
+	}
+	if err == nil {
+		conversion.CurrentPrice = convert(&response.CurrentPrice)
+		conversion.SalePrice = convert(response.SalePrice)
+		conversion.CompareAtPrice = convert(response.CompareAtPrice)
+	}
+	if errors.Is(err, currency.ErrNoRate) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "Price cannot be converted",
+			"details": err.Error(),
+		})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to convert price",
+			"details": err.Error(),
+		})
+		return false
+	}
+	response.Converted = conversion
+	return true
+}
@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"backend/storage"
+	"backend/utils"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServePrivateFile streams a private upload after checking its signed URL.
+// URLs bound to a user (uid query parameter) also require that user's token.
+func ServePrivateFile(c *gin.Context) {
+	filePath := strings.TrimPrefix(path.Clean("/"+c.Param("filepath")), "/")
+	if !storage.ValidKey(filePath) || !strings.HasPrefix(filePath, "private/") {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "File not found",
+		})
+		return
+	}
+
+	expiresAt, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Missing or invalid expires parameter",
+		})
+		return
+	}
+
+	var boundUserID uint
+	if uid := c.Query("uid"); uid != "" {
+		parsed, err := strconv.ParseUint(uid, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Invalid uid parameter",
+			})
+			return
+		}
+		boundUserID = uint(parsed)
+	}
+
+	if err := utils.VerifyFileSignature(filePath, expiresAt, boundUserID, c.Query("signature")); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// Signed URLs bound to a user only work for that user
+	if boundUserID != 0 {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Authentication required for this file",
+			})
+			return
+		}
+		if uid, ok := userID.(uint); !ok || uid != boundUserID {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "You do not have access to this file",
+			})
+			return
+		}
+	}
+
+	info, err := storage.Default.Stat(c.Request.Context(), filePath)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "File not found",
+		})
+		return
+	}
+
+	reader, err := storage.Default.Open(c.Request.Context(), filePath)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "File not found",
+		})
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Cache-Control", "private, no-store")
+	c.DataFromReader(http.StatusOK, info.Size, info.ContentType, reader, nil)
+}
+
+// ReceiveDirectUpload accepts presigned PUT uploads for the local storage
+// backend. With an object store configured clients upload to the bucket
+// instead and this handler is never used.
+func ReceiveDirectUpload(c *gin.Context) {
+	local, ok := storage.Default.(*storage.LocalStorage)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Direct uploads are handled by the storage provider",
+		})
+		return
+	}
+
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if !storage.ValidKey(key) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid upload key",
+		})
+		return
+	}
+
+	expiresAt, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Missing or invalid expires parameter",
+		})
+		return
+	}
+
+	contentType := c.GetHeader("Content-Type")
+	if err := storage.VerifyLocalUpload(key, contentType, expiresAt, c.Query("signature")); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	// A URL uploads once, so a leaked one cannot replace the file before
+	// it is completed. The mark is taken before writing so concurrent
+	// requests cannot both write, and dropped again if the write fails.
+	if err := local.ConsumeUpload(c.Query("signature"), expiresAt); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// Keep one byte past the limit so the completion step rejects oversized
+	// uploads instead of attaching a truncated file
+	body := io.LimitReader(c.Request.Body, utils.MaxDirectUploadSize+1)
+	if err := local.Put(c.Request.Context(), key, body, c.Request.ContentLength, contentType); err != nil {
+		local.ReleaseUpload(c.Query("signature"))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to store upload",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
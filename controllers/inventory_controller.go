@@ -0,0 +1,471 @@
+package controllers
+
+import (
+	"backend/config"
+	"backend/inventory"
+	"backend/models"
+	"backend/search"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// errStockWrite rejects direct stock writes of product and variant updates
+const errStockWrite = "stock is changed with POST /products/{id}/stock/adjust"
+
+// AdjustProductStock records a stock movement of a product or one of its
+// variants in a warehouse and updates the stock atomically
+func AdjustProductStock(c *gin.Context) {
+	product, ok := findProduct(c)
+	if !ok {
+		return
+	}
+
+	var request models.StockAdjustRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	movement := inventory.Movement{
+		ProductID: product.ID,
+		Type:      request.Type,
+		Quantity:  request.Quantity,
+		SetStock:  request.Stock,
+		Reason:    request.Reason,
+		Reference: request.Reference,
+		ActorID:   currentUserID(c),
+	}
+	var variantUUID *uuid.UUID
+	if request.VariantID != "" {
+		var variant models.ProductVariant
+		if err := config.DB.Where("uuid = ? AND product_id = ?", request.VariantID, product.ID).First(&variant).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Variant not found",
+			})
+			return
+		}
+		movement.VariantID = &variant.ID
+		variantUUID = &variant.Uuid
+	}
+	warehouse, ok := resolveWarehouse(c, request.Warehouse)
+	if !ok {
+		return
+	}
+	movement.WarehouseID = warehouse.ID
+
+	var entry *models.InventoryMovement
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		entry, err = inventory.Move(tx, movement)
+		return err
+	})
+	if !handleStockMoveError(c, err) {
+		return
+	}
+	search.Sync(product.ID)
+
+	entry.Warehouse = warehouse
+	response := toMovementResponse(*entry)
+	response.VariantID = variantUUID
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Stock adjusted successfully",
+		"data":    response,
+	})
+}
+
+// GetStockMovements lists the stock ledger of a product, newest first, with
+// cursor pagination. Filter with variant_id, warehouse and type.
+func GetStockMovements(c *gin.Context) {
+	product, ok := findProduct(c)
+	if !ok {
+		return
+	}
+
+	query := config.DB.Preload("Variant").Preload("Warehouse").Preload("Transfer").Preload("Actor").Where("product_id = ?", product.ID)
+	if variantID := c.Query("variant_id"); variantID != "" {
+		query = query.Where("variant_id IN (?)", config.DB.Model(&models.ProductVariant{}).Select("id").Where("uuid = ?", variantID))
+	}
+	if ref := c.Query("warehouse"); ref != "" {
+		warehouse, err := lookupWarehouse(ref)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Warehouse not found",
+			})
+			return
+		}
+		query = query.Where("warehouse_id = ?", warehouse.ID)
+	}
+	if movementType := c.Query("type"); movementType != "" {
+		query = query.Where("type = ?", movementType)
+	}
+
+	limit := pageSize(c, "limit")
+	cursor, err := parseCursor(c, "newest")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid cursor",
+		})
+		return
+	}
+	query, err = keysetOrder{idColumn: "id", desc: true}.apply(query, cursor, limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid cursor",
+		})
+		return
+	}
+
+	var movements []models.InventoryMovement
+	if err := query.Find(&movements).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve stock movements",
+		})
+		return
+	}
+	movements, next, prev := keysetPage(movements, limit, cursor, "newest", func(movement models.InventoryMovement) (string, uint) {
+		return "", movement.ID
+	})
+
+	responses := []models.InventoryMovementResponse{}
+	for _, movement := range movements {
+		responses = append(responses, toMovementResponse(movement))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Stock movements retrieved successfully",
+		"data":        responses,
+		"stock":       product.Stock,
+		"limit":       limit,
+		"next_cursor": next,
+		"prev_cursor": prev,
+		"has_next":    next != "",
+		"has_prev":    prev != "",
+	})
+}
+
+// handleStockMoveError writes the error response for a failed stock
+// movement and reports whether the movement succeeded
+func handleStockMoveError(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, inventory.ErrInvalidQuantity):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid quantity",
+			"details": err.Error(),
+		})
+	case errors.Is(err, inventory.ErrInvalidTransfer):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid transfer",
+			"details": err.Error(),
+		})
+	case errors.Is(err, inventory.ErrNoWarehouse):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "No default warehouse",
+			"details": "create a warehouse with is_default first",
+		})
+	case errors.Is(err, inventory.ErrInsufficientStock):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Insufficient stock",
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to adjust stock",
+			"details": err.Error(),
+		})
+	}
+	return false
+}
+
+// toMovementResponse converts a movement with its preloaded variant and
+// actor
+func toMovementResponse(movement models.InventoryMovement) models.InventoryMovementResponse {
+	response := models.InventoryMovementResponse{
+		ID:                  movement.Uuid,
+		Type:                movement.Type,
+		Quantity:            movement.Quantity,
+		StockAfter:          movement.StockAfter,
+		WarehouseStockAfter: movement.WarehouseStockAfter,
+		Reason:              movement.Reason,
+		Reference:           movement.Reference,
+		VariantSKU:          movement.VariantSKU,
+		CreatedAt:           movement.CreatedAt,
+	}
+	if movement.Variant != nil {
+		response.VariantID = &movement.Variant.Uuid
+	}
+	if movement.Warehouse != nil {
+		info := movement.Warehouse.Info()
+		response.Warehouse = &info
+	}
+	if movement.Transfer != nil {
+		response.TransferID = &movement.Transfer.Uuid
+	}
+	if movement.Actor != nil {
+		response.Actor = &models.RevisionActor{ID: movement.Actor.Uuid, Name: movement.Actor.Name}
+	}
+	return response
+}
+
+// GetProductStock lists the stock levels of a product and its variants per
+// warehouse
+func GetProductStock(c *gin.Context) {
+	product, ok := findProduct(c)
+	if !ok {
+		return
+	}
+
+	var levels []models.StockLevel
+	if err := config.DB.Preload("Warehouse").Where("product_id = ?", product.ID).Order("warehouse_id ASC, variant_id ASC").Find(&levels).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve stock levels",
+		})
+		return
+	}
+	var variants []models.ProductVariant
+	config.DB.Where("product_id = ?", product.ID).Find(&variants)
+	variantsByID := make(map[uint]models.ProductVariant, len(variants))
+	for _, variant := range variants {
+		variantsByID[variant.ID] = variant
+	}
+
+	responses := []models.StockLevelResponse{}
+	for _, level := range levels {
+		if level.Warehouse == nil {
+			continue
+		}
+		response := models.StockLevelResponse{
+			Warehouse: level.Warehouse.Info(),
+			Active:    level.Warehouse.Active,
+			SKU:       product.SKU,
+			Quantity:  level.Quantity,
+			Reserved:  level.Reserved,
+			Available: level.Available(),
+		}
+		if level.VariantID != 0 {
+			variant, ok := variantsByID[level.VariantID]
+			if !ok {
+				continue
+			}
+			response.VariantID = &variant.Uuid
+			response.SKU = variant.SKU
+		}
+		responses = append(responses, response)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Stock levels retrieved successfully",
+		"data":         responses,
+		"stock":        product.Stock,
+		"availability": loadStockAvailability([]models.Product{*product})[product.ID],
+	})
+}
+
+// CreateStockTransfer moves stock of a product or variant between two
+// warehouses atomically
+func CreateStockTransfer(c *gin.Context) {
+	var request models.StockTransferRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var product models.Product
+	if err := config.DB.Where("uuid = ?", request.ProductID).First(&product).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Product not found",
+		})
+		return
+	}
+	transfer := inventory.Transfer{
+		ProductID: product.ID,
+		Quantity:  request.Quantity,
+		Reason:    request.Reason,
+		Reference: request.Reference,
+		ActorID:   currentUserID(c),
+	}
+	var variant *models.ProductVariant
+	if request.VariantID != "" {
+		variant = &models.ProductVariant{}
+		if err := config.DB.Where("uuid = ? AND product_id = ?", request.VariantID, product.ID).First(variant).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Variant not found",
+			})
+			return
+		}
+		transfer.VariantID = &variant.ID
+	}
+	from, ok := resolveWarehouse(c, request.From)
+	if !ok {
+		return
+	}
+	to, ok := resolveWarehouse(c, request.To)
+	if !ok {
+		return
+	}
+	transfer.FromWarehouseID, transfer.ToWarehouseID = from.ID, to.ID
+
+	var record *models.StockTransfer
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		record, err = inventory.MoveTransfer(tx, transfer)
+		return err
+	})
+	if !handleStockMoveError(c, err) {
+		return
+	}
+
+	record.Product, record.Variant = &product, variant
+	record.FromWarehouse, record.ToWarehouse = from, to
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Stock transferred successfully",
+		"data":    toTransferResponse(*record),
+	})
+}
+
+// GetStockTransfers lists stock transfers, newest first, with cursor
+// pagination. Filter with product_id and warehouse, which matches either
+// side.
+func GetStockTransfers(c *gin.Context) {
+	query := config.DB.Preload("Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Variant").Preload("FromWarehouse").Preload("ToWarehouse").Preload("Actor")
+	if productID := c.Query("product_id"); productID != "" {
+		query = query.Where("product_id IN (?)", config.DB.Unscoped().Model(&models.Product{}).Select("id").Where("uuid = ?", productID))
+	}
+	if ref := c.Query("warehouse"); ref != "" {
+		warehouse, err := lookupWarehouse(ref)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Warehouse not found",
+			})
+			return
+		}
+		query = query.Where("from_warehouse_id = ? OR to_warehouse_id = ?", warehouse.ID, warehouse.ID)
+	}
+
+	limit := pageSize(c, "limit")
+	cursor, err := parseCursor(c, "newest")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid cursor",
+		})
+		return
+	}
+	query, err = keysetOrder{idColumn: "id", desc: true}.apply(query, cursor, limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid cursor",
+		})
+		return
+	}
+
+	var transfers []models.StockTransfer
+	if err := query.Find(&transfers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve stock transfers",
+		})
+		return
+	}
+	transfers, next, prev := keysetPage(transfers, limit, cursor, "newest", func(transfer models.StockTransfer) (string, uint) {
+		return "", transfer.ID
+	})
+
+	responses := []models.StockTransferResponse{}
+	for _, transfer := range transfers {
+		responses = append(responses, toTransferResponse(transfer))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Stock transfers retrieved successfully",
+		"data":        responses,
+		"limit":       limit,
+		"next_cursor": next,
+		"prev_cursor": prev,
+		"has_next":    next != "",
+		"has_prev":    prev != "",
+	})
+}
+
+// toTransferResponse converts a transfer with its preloaded product,
+// variant, warehouses and actor
+func toTransferResponse(transfer models.StockTransfer) models.StockTransferResponse {
+	response := models.StockTransferResponse{
+		ID:        transfer.Uuid,
+		Quantity:  transfer.Quantity,
+		Reason:    transfer.Reason,
+		Reference: transfer.Reference,
+		CreatedAt: transfer.CreatedAt,
+	}
+	if transfer.Product != nil {
+		response.ProductID = transfer.Product.Uuid
+	}
+	if transfer.Variant != nil {
+		response.VariantID = &transfer.Variant.Uuid
+	}
+	if transfer.FromWarehouse != nil {
+		response.From = transfer.FromWarehouse.Info()
+	}
+	if transfer.ToWarehouse != nil {
+		response.To = transfer.ToWarehouse.Info()
+	}
+	if transfer.Actor != nil {
+		response.Actor = &models.RevisionActor{ID: transfer.Actor.Uuid, Name: transfer.Actor.Name}
+	}
+	return response
+}
+
+// loadStockAvailability sums the unreserved stock of products and their
+// variants per active warehouse
+func loadStockAvailability(products []models.Product) map[uint]*models.StockAvailability {
+	availability := make(map[uint]*models.StockAvailability)
+	if len(products) == 0 {
+		return availability
+	}
+
+	ids := make([]uint, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+		availability[product.ID] = &models.StockAvailability{Warehouses: []models.WarehouseAvailability{}}
+	}
+
+	var rows []struct {
+		ProductID uint
+		Uuid      uuid.UUID
+		Code      string
+		Name      string
+		Available int
+	}
+	if err := config.DB.Table("stock_levels AS l").
+		Select("l.product_id, w.uuid, w.code, w.name, SUM(GREATEST(l.quantity - l.reserved, 0)) AS available").
+		Joins("JOIN warehouses w ON w.id = l.warehouse_id").
+		Where("l.product_id IN ? AND w.active = ?", ids, true).
+		Group("l.product_id, w.id, w.uuid, w.code, w.name").
+		Having("SUM(GREATEST(l.quantity - l.reserved, 0)) > 0").
+		Order("w.is_default DESC, w.code ASC").
+		Scan(&rows).Error; err != nil {
+		log.Printf("failed to aggregate stock levels: %v", err)
+		return availability
+	}
+
+	for _, row := range rows {
+		entry := availability[row.ProductID]
+		entry.Available += row.Available
+		entry.Warehouses = append(entry.Warehouses, models.WarehouseAvailability{
+			WarehouseInfo: models.WarehouseInfo{ID: row.Uuid, Code: row.Code, Name: row.Name},
+			Available:     row.Available,
+		})
+	}
+	return availability
+}
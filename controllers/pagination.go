@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Page size limits shared by all listings
+const (
+	DefaultPageSize = 10
+	MaxPageSize     = 100
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// pageCursor is the decoded form of the opaque cursor query parameter. It
+// holds the sort key of the first or last row of the page it was issued for.
+type pageCursor struct {
+	Sort     string `json:"s"`
+	Value    string `json:"v,omitempty"`
+	ID       uint   `json:"id"`
+	Backward bool   `json:"b,omitempty"` // fetch the rows before the position
+}
+
+func (cursor pageCursor) encode() string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// parseCursor decodes the cursor query parameter, if any. The cursor must
+// have been issued for the same sort order.
+func parseCursor(c *gin.Context, sort string) (*pageCursor, error) {
+	value := c.Query("cursor")
+	if value == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	var cursor pageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Sort != sort {
+		return nil, errInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// pageSize reads a page size query parameter, falling back to
+// DefaultPageSize and capping at MaxPageSize
+func pageSize(c *gin.Context, keys ...string) int {
+	for _, key := range keys {
+		if size, err := strconv.Atoi(c.Query(key)); err == nil && size > 0 {
+			return min(size, MaxPageSize)
+		}
+	}
+	return DefaultPageSize
+}
+
+// keysetOrder is a sort order with the primary key as tie-breaker, so that
+// every row has a unique position to continue from
+type keysetOrder struct {
+	column   string // sort column; empty to sort by idColumn only
+	idColumn string
+	desc     bool
+	parse    func(value string) (interface{}, error) // decodes pageCursor.Value
+}
+
+// apply restricts query to the rows after (or before) cursor and fetches one
+// row more than limit to tell whether another page follows
+func (order keysetOrder) apply(query *gorm.DB, cursor *pageCursor, limit int) (*gorm.DB, error) {
+	desc := order.desc
+	if cursor != nil && cursor.Backward {
+		desc = !desc
+	}
+	op, direction := ">", "ASC"
+	if desc {
+		op, direction = "<", "DESC"
+	}
+
+	if cursor != nil {
+		if order.column == "" {
+			query = query.Where(fmt.Sprintf("%s %s ?", order.idColumn, op), cursor.ID)
+		} else {
+			value, err := order.parse(cursor.Value)
+			if err != nil {
+				return nil, errInvalidCursor
+			}
+			query = query.Where(fmt.Sprintf("%[1]s %[2]s ? OR (%[1]s = ? AND %[3]s %[2]s ?)", order.column, op, order.idColumn),
+				value, value, cursor.ID)
+		}
+	}
+
+	if order.column != "" {
+		query = query.Order(order.column + " " + direction)
+	}
+	return query.Order(order.idColumn + " " + direction).Limit(limit + 1), nil
+}
+
+// keysetPage trims rows fetched with keysetOrder.apply to the page, restores
+// their order when paging backwards and returns the cursors of the
+// neighbouring pages ("" when there is none). key returns the cursor value
+// and ID of a row.
+func keysetPage[T any](rows []T, limit int, cursor *pageCursor, sort string, key func(row T) (string, uint)) ([]T, string, string) {
+	hasMore := len(rows) > limit
+	if hasMore {
+		rows = rows[:limit]
+	}
+	backward := cursor != nil && cursor.Backward
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	if len(rows) == 0 {
+		return rows, "", ""
+	}
+
+	cursorAt := func(row T, backward bool) string {
+		value, id := key(row)
+		return pageCursor{Sort: sort, Value: value, ID: id, Backward: backward}.encode()
+	}
+
+	var next, prev string
+	if hasMore || backward {
+		next = cursorAt(rows[len(rows)-1], false)
+	}
+	if (backward && hasMore) || (!backward && cursor != nil) {
+		prev = cursorAt(rows[0], true)
+	}
+	return rows, next, prev
+}
+
+func parseTimeCursor(value string) (interface{}, error) {
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+func parseIntCursor(value string) (interface{}, error) {
+	return strconv.ParseInt(value, 10, 64)
+}
+
+func parseStringCursor(value string) (interface{}, error) {
+	return value, nil
+}
@@ -0,0 +1,339 @@
+package controllers
+
+import (
+	"backend/config"
+	"backend/models"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GetPriceLists retrieves all price lists. Pass customer_group to list the
+// lists of one group.
+func GetPriceLists(c *gin.Context) {
+	query := config.DB.Order("customer_group ASC, name ASC")
+	if group := c.Query("customer_group"); group != "" {
+		query = query.Where("customer_group = ?", normalizeCustomerGroup(group))
+	}
+
+	var lists []models.PriceList
+	if err := query.Find(&lists).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve price lists",
+		})
+		return
+	}
+
+	counts := priceListProductCounts()
+	responses := make([]models.PriceListResponse, 0, len(lists))
+	for _, list := range lists {
+		responses = append(responses, toPriceListResponse(list, counts[list.ID]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Price lists retrieved successfully",
+		"data":    responses,
+	})
+}
+
+// GetPriceList retrieves a price list with its prices
+func GetPriceList(c *gin.Context) {
+	list, ok := findPriceList(c)
+	if !ok {
+		return
+	}
+
+	var prices []models.PriceListPrice
+	if err := config.DB.Preload("Product").Where("price_list_id = ?", list.ID).Order("id ASC").Find(&prices).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve price list",
+		})
+		return
+	}
+
+	response := toPriceListResponse(*list, int64(len(prices)))
+	response.Prices = []models.PriceListPriceEntry{}
+	for _, price := range prices {
+		if price.Product == nil {
+			continue
+		}
+		response.Prices = append(response.Prices, models.PriceListPriceEntry{
+			ProductID: price.Product.Uuid,
+			Name:      price.Product.Name,
+			SKU:       price.Product.SKU,
+			Price:     price.Price,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Price list retrieved successfully",
+		"data":    response,
+	})
+}
+
+// CreatePriceList creates a price list for a customer group
+func CreatePriceList(c *gin.Context) {
+	var request models.PriceListRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	list := models.PriceList{Uuid: uuid.New(), Active: true}
+	if err := applyPriceListRequest(&list, request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := config.DB.Create(&list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to create price list",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Price list created successfully",
+		"data":    toPriceListResponse(list, 0),
+	})
+}
+
+// UpdatePriceList replaces the settings of a price list. Its prices are
+// kept.
+func UpdatePriceList(c *gin.Context) {
+	list, ok := findPriceList(c)
+	if !ok {
+		return
+	}
+
+	var request models.PriceListRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err := applyPriceListRequest(list, request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := config.DB.Save(list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update price list",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Price list updated successfully",
+		"data":    toPriceListResponse(*list, priceListProductCounts()[list.ID]),
+	})
+}
+
+// DeletePriceList deletes a price list with its prices
+func DeletePriceList(c *gin.Context) {
+	list, ok := findPriceList(c)
+	if !ok {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("price_list_id = ?", list.ID).Delete(&models.PriceListPrice{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(list).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete price list",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Price list deleted successfully",
+	})
+}
+
+// SetPriceListPrices adds or replaces product prices of a price list. Every
+// price must be in the currency of its product; if one is invalid nothing is
+// saved.
+func SetPriceListPrices(c *gin.Context) {
+	list, ok := findPriceList(c)
+	if !ok {
+		return
+	}
+
+	var request models.PriceListPricesRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		for i, item := range request.Prices {
+			var product models.Product
+			if err := tx.Where("uuid = ?", item.ProductID).First(&product).Error; err != nil {
+				return fmt.Errorf("prices[%d]: product not found", i)
+			}
+			if item.Price.Currency != product.Price.Currency {
+				return fmt.Errorf("prices[%d]: price must be in the product currency %s", i, product.Price.Currency)
+			}
+
+			price := models.PriceListPrice{PriceListID: list.ID, ProductID: product.ID}
+			if err := tx.Where(price).FirstOrInit(&price).Error; err != nil {
+				return err
+			}
+			price.Price = item.Price
+			if err := tx.Save(&price).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to set prices",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Prices set successfully",
+		"data":    toPriceListResponse(*list, priceListProductCounts()[list.ID]),
+	})
+}
+
+// DeletePriceListPrice removes the price of a product from a price list
+func DeletePriceListPrice(c *gin.Context) {
+	list, ok := findPriceList(c)
+	if !ok {
+		return
+	}
+
+	productUUID, err := uuid.Parse(c.Param("product_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID",
+		})
+		return
+	}
+
+	result := config.DB.Where("price_list_id = ? AND product_id IN (?)", list.ID,
+		config.DB.Unscoped().Model(&models.Product{}).Select("id").Where("uuid = ?", productUUID)).
+		Delete(&models.PriceListPrice{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete price",
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Price not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Price deleted successfully",
+	})
+}
+
+// findPriceList loads the price list named by the :id URL parameter and
+// writes the error response itself when it cannot
+func findPriceList(c *gin.Context) (*models.PriceList, bool) {
+	listUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid price list ID",
+		})
+		return nil, false
+	}
+
+	var list models.PriceList
+	if err := config.DB.Where("uuid = ?", listUUID).First(&list).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Price list not found",
+		})
+		return nil, false
+	}
+	return &list, true
+}
+
+// applyPriceListRequest sets the fields of a create or update request
+func applyPriceListRequest(list *models.PriceList, request models.PriceListRequest) error {
+	if request.StartsAt != nil && request.EndsAt != nil && !request.EndsAt.After(*request.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+	group := normalizeCustomerGroup(request.CustomerGroup)
+	if group == "" {
+		return errors.New("customer_group is required")
+	}
+
+	list.Name = strings.TrimSpace(request.Name)
+	list.CustomerGroup = group
+	if request.Active != nil {
+		list.Active = *request.Active
+	}
+	list.StartsAt = request.StartsAt
+	list.EndsAt = request.EndsAt
+	return nil
+}
+
+// normalizeCustomerGroup makes customer group names case-insensitive
+func normalizeCustomerGroup(group string) string {
+	return strings.ToLower(strings.TrimSpace(group))
+}
+
+// priceListProductCounts returns the number of priced products per list
+func priceListProductCounts() map[uint]int64 {
+	var rows []struct {
+		PriceListID uint
+		Count       int64
+	}
+	config.DB.Model(&models.PriceListPrice{}).Select("price_list_id, COUNT(*) AS count").Group("price_list_id").Scan(&rows)
+
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.PriceListID] = row.Count
+	}
+	return counts
+}
+
+func toPriceListResponse(list models.PriceList, productCount int64) models.PriceListResponse {
+	return models.PriceListResponse{
+		ID:            list.Uuid,
+		Name:          list.Name,
+		CustomerGroup: list.CustomerGroup,
+		Active:        list.Active,
+		StartsAt:      list.StartsAt,
+		EndsAt:        list.EndsAt,
+		ProductCount:  productCount,
+		CreatedAt:     list.CreatedAt,
+		UpdatedAt:     list.UpdatedAt,
+	}
+}
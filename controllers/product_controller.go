@@ -2,13 +2,21 @@ package controllers
 
 import (
 	"backend/config"
+	"backend/inventory"
+	"backend/jobs"
 	"backend/models"
+	"backend/search"
+	"backend/storage"
 	"backend/utils"
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // CreateProduct creates a new product with optional image upload
@@ -24,54 +32,120 @@ func CreateProduct(c *gin.Context) {
 		return
 	}
 
-	// Create product instance
-	product := models.Product{
-		Uuid:        uuid.New(),
-		Name:        request.Name,
-		Description: request.Description,
-		Price:       request.Price,
-		Stock:       request.Stock,
-		Category:    request.Category,
-		Brand:       request.Brand,
-		SKU:         request.SKU,
-		Status:      "active",
+	category, err := resolveProductCategory(request.CategoryID, request.Category)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Category not found",
+		})
+		return
 	}
 
-	if request.Status != "" {
-		product.Status = request.Status
+	var brand *models.Brand
+	if request.BrandID != "" || request.Brand != "" {
+		brand, err = resolveProductBrand(request.BrandID, request.Brand)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Brand not found",
+			})
+			return
+		}
+	}
+
+	var taxClass *models.TaxClass
+	if request.TaxClass != "" {
+		if taxClass, err = resolveTaxClass(request.TaxClass); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Tax class not found",
+			})
+			return
+		}
+	}
+
+	// Create product instance
+	product := models.Product{
+		Uuid:             uuid.New(),
+		Name:             request.Name,
+		Description:      request.Description,
+		Price:            request.Price,
+		CategoryID:       &category.ID,
+		Category:         category.Name,
+		CategoryRef:      category,
+		SKU:              request.SKU,
+		Status:           initialProductStatus(request.Status, request.PublishAt),
+		PublishAt:        request.PublishAt,
+		UnpublishAt:      request.UnpublishAt,
+		ReorderThreshold: request.ReorderThreshold,
+	}
+	if brand != nil {
+		product.BrandID = &brand.ID
+		product.Brand = brand.Name
+		product.BrandRef = brand
+	}
+	if taxClass != nil {
+		product.TaxClassID = &taxClass.ID
+		product.TaxClassRef = taxClass
+	}
+
+	if err := checkProductSchedule(&product); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid schedule",
+			"details": err.Error(),
+		})
+		return
 	}
 
-	// Get user ID from context (set by JWT middleware)
-	if userID, exists := c.Get("user_id"); exists {
-		if uid, ok := userID.(uuid.UUID); ok {
-			product.CreatedBy = uid
+	// Compare-at and sale prices are stored in the product currency
+	product.SaleStartsAt, product.SaleEndsAt = request.SaleStartsAt, request.SaleEndsAt
+	if product.CompareAtAmount, err = pricingAmount(product, "compare_at_price", request.CompareAtPrice); err == nil {
+		if product.SaleAmount, err = pricingAmount(product, "sale_price", request.SalePrice); err == nil {
+			err = checkProductPricing(&product)
 		}
 	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid pricing",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// The creator may see the product before it is active
+	product.CreatedBy = currentUserUUID(c)
 
 	// Save product to database
-	if err := config.DB.Create(&product).Error; err != nil {
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+		if request.Stock > 0 {
+			entry, err := inventory.Move(tx, inventory.Movement{
+				ProductID: product.ID,
+				Type:      models.MovementReceive,
+				Quantity:  request.Stock,
+				Reason:    "initial stock",
+				ActorID:   currentUserID(c),
+			})
+			if err != nil {
+				return err
+			}
+			product.Stock = entry.StockAfter
+		}
+		if err := models.RecordPriceChange(tx, nil, product, currentUserID(c)); err != nil {
+			return err
+		}
+		return models.RecordProductRevision(tx, models.RevisionCreate, nil, product, currentUserID(c))
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to create product",
 			"details": err.Error(),
 		})
 		return
 	}
+	search.Sync(product.ID)
 
 	// Convert to response format
-	response := models.ProductResponse{
-		ID:          product.Uuid,
-		Name:        product.Name,
-		Description: product.Description,
-		Price:       product.Price,
-		Stock:       product.Stock,
-		Category:    product.Category,
-		Brand:       product.Brand,
-		SKU:         product.SKU,
-		ImageURL:    product.ImageURL,
-		Status:      product.Status,
-		CreatedAt:   product.CreatedAt,
-		UpdatedAt:   product.UpdatedAt,
-	}
+	response := toProductResponse(product)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Product created successfully",
@@ -110,8 +184,17 @@ func UploadProductImage(c *gin.Context) {
 		return
 	}
 
+	// Private images (drafts, documents) are kept out of the public upload
+	// directory and only served through signed URLs
+	private := c.PostForm("visibility") == "private"
+
 	// Save uploaded image
-	imageResponse, err := utils.SaveUploadedImage(c, fileHeader)
+	var imageResponse *utils.ImageUploadResponse
+	if private {
+		imageResponse, err = utils.SavePrivateUploadedImage(c, fileHeader)
+	} else {
+		imageResponse, err = utils.SaveUploadedImage(c, fileHeader)
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
@@ -119,18 +202,150 @@ func UploadProductImage(c *gin.Context) {
 		return
 	}
 
-	// Delete old image if exists
-	if product.ImagePath != "" {
-		utils.DeleteImage(product.ImagePath)
+	if err := attachProductImage(&product, imageResponse, private, currentUserID(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update product with image info",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Image uploaded successfully",
+		"image_url":     imageResponse.ImageURL,
+		"image_hash":    imageResponse.ImageHash,
+		"image_private": private,
+	})
+}
+
+// RequestProductImageUploadURL issues a presigned upload target so large
+// images go straight to the storage backend instead of through the API
+func RequestProductImageUploadURL(c *gin.Context) {
+	productID := c.Param("id")
+
+	// Parse UUID
+	productUUID, err := uuid.Parse(productID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID",
+		})
+		return
 	}
 
-	// Update product with new image info
-	product.ImagePath = imageResponse.ImagePath
-	product.ImageURL = imageResponse.ImageURL
+	var product models.Product
+	if err := config.DB.Where("uuid = ?", productUUID).First(&product).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Product not found",
+		})
+		return
+	}
+
+	var request models.ImageUploadURLRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := utils.ValidateDirectUpload(request.Filename, request.ContentType, request.Size); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	private := request.Visibility == "private"
+	key := utils.NewIncomingKey(request.Filename)
 
-	if err := config.DB.Save(&product).Error; err != nil {
-		// If database update fails, delete the uploaded file
-		utils.DeleteImage(imageResponse.ImagePath)
+	upload, err := storage.Default.PresignPut(key, request.ContentType, utils.DirectUploadTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to create upload URL",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	token, err := utils.SignUploadToken(utils.UploadToken{
+		Key:       key,
+		ProductID: product.Uuid,
+		Private:   private,
+		ExpiresAt: time.Now().Add(utils.UploadTokenTTL).Unix(),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create upload token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Upload URL created successfully",
+		"upload":       upload,
+		"upload_token": token,
+		"max_size":     utils.MaxDirectUploadSize,
+	})
+}
+
+// CompleteProductImageUpload verifies an object uploaded through a presigned
+// URL and attaches it to the product
+func CompleteProductImageUpload(c *gin.Context) {
+	productID := c.Param("id")
+
+	// Parse UUID
+	productUUID, err := uuid.Parse(productID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID",
+		})
+		return
+	}
+
+	var product models.Product
+	if err := config.DB.Where("uuid = ?", productUUID).First(&product).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Product not found",
+		})
+		return
+	}
+
+	var request models.ImageUploadCompleteRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	token, err := utils.ParseUploadToken(request.UploadToken)
+	if err != nil || token.ProductID != product.Uuid {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid or expired upload token",
+		})
+		return
+	}
+
+	// Check what actually landed in storage; the client controls the bytes
+	if _, err := utils.VerifyStoredImage(c.Request.Context(), token.Key, utils.MaxDirectUploadSize); err != nil {
+		utils.DeleteImage(token.Key)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// Strip metadata and move the file into content-addressed storage
+	imageResponse, err := utils.ImportIncomingUpload(c.Request.Context(), token.Key, token.Private)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := attachProductImage(&product, imageResponse, token.Private, currentUserID(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to update product with image info",
 		})
@@ -138,83 +353,241 @@ func UploadProductImage(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":   "Image uploaded successfully",
-		"image_url": imageResponse.ImageURL,
+		"message":       "Image uploaded successfully",
+		"image_url":     imageResponse.ImageURL,
+		"image_hash":    imageResponse.ImageHash,
+		"image_private": token.Private,
+	})
+}
+
+// attachProductImage points the product at a newly stored image and releases
+// the previous one. On failure the new image reference is released again.
+func attachProductImage(product *models.Product, image *utils.ImageUploadResponse, private bool, actorID uint) error {
+	before := *product
+	oldImagePath := product.ImagePath
+
+	// Update product with new image info
+	product.ImagePath = image.ImagePath
+	product.ImageURL = image.ImageURL
+	product.ImageHash = image.ImageHash
+	product.ImagePrivate = private
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("stock").Save(product).Error; err != nil {
+			return err
+		}
+		return models.RecordProductRevision(tx, models.RevisionImage, &before, *product, actorID)
+	})
+	if err != nil {
+		// If database update fails, release the uploaded file. Anything left
+		// behind is picked up by the orphan sweeper.
+		if releaseErr := utils.ReleaseImage(image.ImagePath); releaseErr != nil {
+			log.Printf("failed to release unattached image %s: %v", image.ImagePath, releaseErr)
+		}
+		return err
+	}
+
+	// Release old image if exists. Re-uploading the same bytes yields the
+	// same key, which is fine: the new reference was counted separately.
+	if oldImagePath != "" {
+		if err := utils.ReleaseImage(oldImagePath); err != nil {
+			log.Printf("failed to release replaced image %s: %v", oldImagePath, err)
+		}
+	}
+
+	return nil
+}
+
+// GetProductImageSignedURL returns a temporary URL for a product's private
+// image, bound to the requesting user
+func GetProductImageSignedURL(c *gin.Context) {
+	productID := c.Param("id")
+
+	// Parse UUID
+	productUUID, err := uuid.Parse(productID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID",
+		})
+		return
+	}
+
+	var product models.Product
+	viewer := currentUserUUID(c)
+	if err := config.DB.Where("uuid = ?", productUUID).First(&product).Error; err != nil || !productVisibleTo(product, viewer) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Product not found",
+		})
+		return
+	}
+
+	if product.ImagePath == "" {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Product has no image",
+		})
+		return
+	}
+
+	// Public images don't need signing
+	if !product.ImagePrivate {
+		c.JSON(http.StatusOK, gin.H{
+			"message":   "Image URL retrieved successfully",
+			"image_url": product.ImageURL,
+		})
+		return
+	}
+
+	// Private images are only for the user who created the product
+	if product.CreatedBy != viewer {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "You do not have access to this image",
+		})
+		return
+	}
+
+	ttl := utils.DefaultSignedURLTTL
+	if seconds, err := strconv.Atoi(c.Query("ttl")); err == nil && seconds > 0 {
+		ttl = time.Duration(seconds) * time.Second
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Image URL retrieved successfully",
+		"image_url": utils.SignedFileURL(storage.KeyFromPath(product.ImagePath), ttl, currentUserID(c)),
 	})
 }
 
-// GetAllProducts retrieves all products with pagination and filtering
+// GetAllProducts retrieves all products with pagination, filtering, sorting
+// and facet counts
 func GetAllProducts(c *gin.Context) {
 	var products []models.Product
 	var total int64
 
 	// Get query parameters
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	category := c.Query("category")
-	status := c.DefaultQuery("status", "active")
-	search := c.Query("search")
-
-	// Calculate offset
-	offset := (page - 1) * limit
-
-	// Build query
-	query := config.DB.Model(&models.Product{})
-
-	// Apply filters
-	if category != "" {
-		query = query.Where("category = ?", category)
+	limit := pageSize(c, "limit")
+	converter, target, ok := priceConverter(c)
+	if !ok {
+		return
 	}
-	if status != "" {
-		query = query.Where("status = ?", status)
+	calculator, ok := taxCalculator(c)
+	if !ok {
+		return
 	}
-	if search != "" {
-		query = query.Where("name LIKE ? OR description LIKE ?", "%"+search+"%", "%"+search+"%")
+
+	listing, err := parseProductListing(c.Request.Context(), c.Request.URL.Query(), currentUserUUID(c))
+	if errors.Is(err, errInvalidListing) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"details": err.Error(),
+		})
+		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve products",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// Offset pagination is kept for clients that still send page
+	page, _ := strconv.Atoi(c.Query("page"))
+	offsetMode := page > 0 && c.Query("cursor") == ""
 
-	// Get total count
-	query.Count(&total)
+	cursor, err := parseCursor(c, listing.sort)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"details": err.Error(),
+		})
+		return
+	}
 
 	// Get products with pagination
-	if err := query.Offset(offset).Limit(limit).Order("created_at DESC").Find(&products).Error; err != nil {
+	query := listing.query("").Preload("CategoryRef").Preload("BrandRef").Preload("TaxClassRef")
+	if offsetMode {
+		query = listing.ordered(query).Offset((page - 1) * limit).Limit(limit)
+	} else if query, err = listing.page(query, cursor, limit); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err := query.Find(&products).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to retrieve products",
 		})
 		return
 	}
 
+	pagination := gin.H{"limit": limit}
+	if offsetMode {
+		listing.query("").Count(&total)
+		pagination["page"] = page
+		pagination["total"] = total
+		pagination["total_pages"] = (total + int64(limit) - 1) / int64(limit)
+	} else {
+		var next, prev string
+		products, next, prev = keysetPage(products, limit, cursor, listing.sort, listing.cursorKey)
+		pagination["next_cursor"] = next
+		pagination["prev_cursor"] = prev
+		pagination["has_next"] = next != ""
+		pagination["has_prev"] = prev != ""
+
+		// Counting is optional as it scans every matching row
+		if c.Query("include_total") == "true" {
+			listing.query("").Count(&total)
+			pagination["total"] = total
+		}
+	}
+
 	// Convert to response format
+	variantSummaries := loadVariantSummaries(products)
+	availability := loadStockAvailability(products)
+
 	var responses []models.ProductResponse
 	for _, product := range products {
-		responses = append(responses, models.ProductResponse{
-			ID:          product.Uuid,
-			Name:        product.Name,
-			Description: product.Description,
-			Price:       product.Price,
-			Stock:       product.Stock,
-			Category:    product.Category,
-			Brand:       product.Brand,
-			SKU:         product.SKU,
-			ImageURL:    product.ImageURL,
-			Status:      product.Status,
-			CreatedAt:   product.CreatedAt,
-			UpdatedAt:   product.UpdatedAt,
-		})
+		response := toProductResponse(product)
+		response.Variants = variantSummaries[product.ID]
+		response.Availability = availability[product.ID]
+		if hit, ok := listing.hits[product.ID]; ok {
+			response.Search = &models.SearchMatch{Score: hit.Score, Highlights: hit.Highlights}
+		}
+		if converter != nil && !convertPrice(c, converter, target, &response) {
+			return
+		}
+		if !addProductTax(c, calculator, product, &response) {
+			return
+		}
+		responses = append(responses, response)
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Products retrieved successfully",
-		"data":    responses,
-		"pagination": gin.H{
-			"page":        page,
-			"limit":       limit,
-			"total":       total,
-			"total_pages": (total + int64(limit) - 1) / int64(limit),
-		},
-	})
+	result := gin.H{
+		"message":    "Products retrieved successfully",
+		"data":       responses,
+		"pagination": pagination,
+	}
+	if converter != nil {
+		result["currency"] = target
+	}
+
+	if c.DefaultQuery("facets", "true") != "false" {
+		facets, err := listing.facets()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to count facets",
+				"details": err.Error(),
+			})
+			return
+		}
+		result["facets"] = facets
+	}
+
+	c.JSON(http.StatusOK, result)
 }
 
-// GetProductByID retrieves a single product by ID
+// GetProductByID retrieves a single product by ID. Products that are not
+// active are only found by the user who created them.
 func GetProductByID(c *gin.Context) {
 	productID := c.Param("id")
 
@@ -227,28 +600,38 @@ func GetProductByID(c *gin.Context) {
 		return
 	}
 
+	converter, target, ok := priceConverter(c)
+	if !ok {
+		return
+	}
+	calculator, ok := taxCalculator(c)
+	if !ok {
+		return
+	}
+
 	var product models.Product
-	if err := config.DB.Where("uuid = ?", productUUID).First(&product).Error; err != nil {
+	err = config.DB.Preload("CategoryRef").Preload("BrandRef").Preload("TaxClassRef").Where("uuid = ?", productUUID).First(&product).Error
+	// Products that are not active yet or anymore are only shown to their
+	// creator
+	if err != nil || !productVisibleTo(product, currentUserUUID(c)) {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "Product not found",
 		})
 		return
 	}
 
+	// Count the view for popularity sorting without touching updated_at
+	config.DB.Model(&product).UpdateColumn("view_count", gorm.Expr("view_count + 1"))
+
 	// Convert to response format
-	response := models.ProductResponse{
-		ID:          product.Uuid,
-		Name:        product.Name,
-		Description: product.Description,
-		Price:       product.Price,
-		Stock:       product.Stock,
-		Category:    product.Category,
-		Brand:       product.Brand,
-		SKU:         product.SKU,
-		ImageURL:    product.ImageURL,
-		Status:      product.Status,
-		CreatedAt:   product.CreatedAt,
-		UpdatedAt:   product.UpdatedAt,
+	response := toProductResponse(product)
+	response.Variants = loadVariantSummaries([]models.Product{product})[product.ID]
+	response.Availability = loadStockAvailability([]models.Product{product})[product.ID]
+	if converter != nil && !convertPrice(c, converter, target, &response) {
+		return
+	}
+	if !addProductTax(c, calculator, product, &response) {
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -272,7 +655,7 @@ func UpdateProduct(c *gin.Context) {
 
 	// Find existing product
 	var product models.Product
-	if err := config.DB.Where("uuid = ?", productUUID).First(&product).Error; err != nil {
+	if err := config.DB.Preload("CategoryRef").Preload("BrandRef").Preload("TaxClassRef").Where("uuid = ?", productUUID).First(&product).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "Product not found",
 		})
@@ -287,6 +670,7 @@ func UpdateProduct(c *gin.Context) {
 		})
 		return
 	}
+	before := product
 
 	// Update fields if provided
 	if request.Name != "" {
@@ -296,55 +680,140 @@ func UpdateProduct(c *gin.Context) {
 		product.Description = request.Description
 	}
 	if request.Price != nil {
+		if err := checkPriceCurrencyChange(product, request.Price.Currency); err != nil {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
 		product.Price = *request.Price
 	}
-	if request.Stock != nil {
-		product.Stock = *request.Stock
+	if err := updateProductPricing(&product, product.Price.Currency != before.Price.Currency, request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid pricing",
+			"details": err.Error(),
+		})
+		return
 	}
-	if request.Category != "" {
-		product.Category = request.Category
+	if request.Stock != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": errStockWrite,
+		})
+		return
 	}
-	if request.Brand != "" {
-		product.Brand = request.Brand
+	if request.CategoryID != "" || request.Category != "" {
+		category, err := resolveProductCategory(request.CategoryID, request.Category)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Category not found",
+			})
+			return
+		}
+		product.CategoryID = &category.ID
+		product.Category = category.Name
+		product.CategoryRef = category
+	}
+	if request.BrandID != "" || request.Brand != "" {
+		brand, err := resolveProductBrand(request.BrandID, request.Brand)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Brand not found",
+			})
+			return
+		}
+		product.BrandID = &brand.ID
+		product.Brand = brand.Name
+		product.BrandRef = brand
+	}
+	if request.TaxClass != nil {
+		product.TaxClassID, product.TaxClassRef = nil, nil
+		if *request.TaxClass != "" {
+			taxClass, err := resolveTaxClass(*request.TaxClass)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "Tax class not found",
+				})
+				return
+			}
+			product.TaxClassID = &taxClass.ID
+			product.TaxClassRef = taxClass
+		}
 	}
 	if request.SKU != "" {
 		product.SKU = request.SKU
 	}
+	if request.ReorderThreshold.Set {
+		if request.ReorderThreshold.Value != nil && *request.ReorderThreshold.Value < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request data",
+				"details": "reorder_threshold must not be negative",
+			})
+			return
+		}
+		product.ReorderThreshold = request.ReorderThreshold.Value
+	}
+	if request.PublishAt.Set {
+		product.PublishAt = request.PublishAt.Time
+	}
+	if request.UnpublishAt.Set {
+		product.UnpublishAt = request.UnpublishAt.Time
+	}
+	// Activating clears publish_at, which would drop the one sent along
+	if request.Status == models.ProductStatusActive && request.PublishAt.Time != nil && request.PublishAt.Time.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid schedule",
+			"details": "status active conflicts with a future publish_at, use status draft to schedule the product",
+		})
+		return
+	}
 	if request.Status != "" {
-		product.Status = request.Status
+		if err := changeProductStatus(&product, request.Status); err != nil {
+			var transitionErr errProductStatusTransition
+			if errors.As(err, &transitionErr) {
+				respondStatusTransitionError(c, transitionErr)
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to update product",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+	if err := checkProductSchedule(&product); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid schedule",
+			"details": err.Error(),
+		})
+		return
 	}
 
 	// Set updated by user
-	if userID, exists := c.Get("user_id"); exists {
-		if uid, ok := userID.(uuid.UUID); ok {
-			product.UpdatedBy = uid
-		}
-	}
+	product.UpdatedBy = currentUserUUID(c)
 
 	// Save changes
-	if err := config.DB.Save(&product).Error; err != nil {
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// Stock only changes through inventory movements
+		if err := tx.Omit("stock").Save(&product).Error; err != nil {
+			return err
+		}
+		if err := models.RecordPriceChange(tx, &before, product, currentUserID(c)); err != nil {
+			return err
+		}
+		return models.RecordProductRevision(tx, models.RevisionUpdate, &before, product, currentUserID(c))
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to update product",
 			"details": err.Error(),
 		})
 		return
 	}
+	search.Sync(product.ID)
 
 	// Convert to response format
-	response := models.ProductResponse{
-		ID:          product.Uuid,
-		Name:        product.Name,
-		Description: product.Description,
-		Price:       product.Price,
-		Stock:       product.Stock,
-		Category:    product.Category,
-		Brand:       product.Brand,
-		SKU:         product.SKU,
-		ImageURL:    product.ImageURL,
-		Status:      product.Status,
-		CreatedAt:   product.CreatedAt,
-		UpdatedAt:   product.UpdatedAt,
-	}
+	response := toProductResponse(product)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Product updated successfully",
@@ -352,7 +821,8 @@ func UpdateProduct(c *gin.Context) {
 	})
 }
 
-// DeleteProduct soft deletes a product
+// DeleteProduct soft deletes a product, moving it to the trash. Its image is
+// kept until the product is purged.
 func DeleteProduct(c *gin.Context) {
 	productID := c.Param("id")
 
@@ -374,31 +844,34 @@ func DeleteProduct(c *gin.Context) {
 	}
 
 	// Soft delete the product
-	if err := config.DB.Delete(&product).Error; err != nil {
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&product).Error; err != nil {
+			return err
+		}
+		return models.RecordProductRevision(tx, models.RevisionDelete, &product, product, currentUserID(c))
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to delete product",
 		})
 		return
 	}
-
-	// Optionally delete the image file
-	if product.ImagePath != "" {
-		utils.DeleteImage(product.ImagePath)
-	}
+	search.Sync(product.ID)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Product deleted successfully",
+		"message":  "Product moved to trash",
+		"purge_at": time.Now().Add(jobs.TrashRetention()),
 	})
 }
 
-// GetProductCategories retrieves all unique product categories
+// GetProductCategories retrieves the names of all categories. Use
+// GET /categories or /categories/tree for the full category objects.
 func GetProductCategories(c *gin.Context) {
 	var categories []string
 
-	if err := config.DB.Model(&models.Product{}).
-		Distinct("category").
-		Where("category != ''").
-		Pluck("category", &categories).Error; err != nil {
+	if err := config.DB.Model(&models.Category{}).
+		Order("position ASC, name ASC").
+		Pluck("name", &categories).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to retrieve categories",
 		})
@@ -410,3 +883,77 @@ func GetProductCategories(c *gin.Context) {
 		"data":    categories,
 	})
 }
+
+// toProductResponse converts a product into its API representation. Private
+// image URLs are never exposed here; clients request a signed URL instead.
+func toProductResponse(product models.Product) models.ProductResponse {
+	imageURL := product.ImageURL
+	if product.ImagePrivate {
+		imageURL = ""
+	}
+
+	var categoryUUID *uuid.UUID
+	if product.CategoryRef != nil {
+		categoryUUID = &product.CategoryRef.Uuid
+	}
+
+	var brandUUID *uuid.UUID
+	if product.BrandRef != nil {
+		brandUUID = &product.BrandRef.Uuid
+	}
+
+	var taxClass string
+	if product.TaxClassRef != nil {
+		taxClass = product.TaxClassRef.Code
+	}
+
+	return models.ProductResponse{
+		ID:               product.Uuid,
+		Name:             product.Name,
+		Description:      product.Description,
+		Price:            product.Price,
+		CompareAtPrice:   product.CompareAtPrice(),
+		SalePrice:        product.SalePrice(),
+		SaleStartsAt:     product.SaleStartsAt,
+		SaleEndsAt:       product.SaleEndsAt,
+		CurrentPrice:     product.CurrentPrice(time.Now()),
+		Stock:            product.Stock,
+		ReorderThreshold: product.ReorderThreshold,
+		CategoryID:       categoryUUID,
+		Category:         product.Category,
+		BrandID:          brandUUID,
+		Brand:            product.Brand,
+		TaxClass:         taxClass,
+		SKU:              product.SKU,
+		ImageURL:         imageURL,
+		ImageHash:        product.ImageHash,
+		ImagePrivate:     product.ImagePrivate,
+		Status:           product.Status,
+		PublishAt:        product.PublishAt,
+		UnpublishAt:      product.UnpublishAt,
+		CreatedAt:        product.CreatedAt,
+		UpdatedAt:        product.UpdatedAt,
+	}
+}
+
+// findProduct loads the product named by the :id URL parameter and writes
+// the error response itself when it cannot
+func findProduct(c *gin.Context) (*models.Product, bool) {
+	productUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID",
+		})
+		return nil, false
+	}
+
+	var product models.Product
+	if err := config.DB.Where("uuid = ?", productUUID).First(&product).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Product not found",
+		})
+		return nil, false
+	}
+
+	return &product, true
+}
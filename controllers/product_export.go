@@ -0,0 +1,385 @@
+package controllers
+
+import (
+	"backend/config"
+	"backend/jobs"
+	"backend/models"
+	"backend/spreadsheet"
+	"backend/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Export settings and storage location
+const (
+	FormatNDJSON      = "ndjson"
+	ExportFilePrefix  = "exports"
+	exportBatchSize   = 500
+	exportTimeLayout  = "20060102-150405"
+	exportFilenameFmt = "products-%s.%s"
+)
+
+// exportContentTypes lists the supported export formats
+var exportContentTypes = map[string]string{
+	spreadsheet.FormatCSV:  "text/csv; charset=utf-8",
+	spreadsheet.FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	FormatNDJSON:           "application/x-ndjson",
+}
+
+// exportColumns is the header of CSV and XLSX exports. Products with
+// variants get one row per variant, repeating the product columns.
+var exportColumns = []interface{}{
+	"id", "sku", "name", "description", "price", "currency", "stock", "category", "brand", "tax_class", "status", "image_url",
+	"variant_id", "variant_sku", "variant_options", "variant_price", "variant_stock", "variant_image_url",
+	"created_at", "updated_at",
+}
+
+// productExportParams are the Params of a product export job
+type productExportParams struct {
+	Format string `json:"format"`
+	Query  string `json:"query"` // listing filters as a URL query string
+}
+
+// productExportResult is the Result of a product export job
+type productExportResult struct {
+	Format   string `json:"format"`
+	Products int    `json:"products"`
+	Variants int    `json:"variants"`
+	Size     int64  `json:"size"`
+}
+
+// exportedProduct is a line of an NDJSON export
+type exportedProduct struct {
+	models.ProductResponse
+	Variants []models.ProductVariantResponse `json:"variants"`
+}
+
+// ExportProducts streams the products matching the GetAllProducts filters
+// as CSV, XLSX or NDJSON. With async=true the export runs as a background
+// job and the file is downloaded once it is ready.
+func ExportProducts(c *gin.Context) {
+	format := c.DefaultQuery("format", spreadsheet.FormatCSV)
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "format must be csv, xlsx or ndjson",
+		})
+		return
+	}
+
+	params := c.Request.URL.Query()
+	params.Del("format")
+	params.Del("async")
+
+	listing, err := parseProductListing(c.Request.Context(), params, currentUserUUID(c))
+	if errors.Is(err, errInvalidListing) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid query parameters",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to export products",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if c.Query("async") == "true" {
+		jobParams, _ := json.Marshal(productExportParams{Format: format, Query: params.Encode()})
+		job := models.Job{
+			Uuid:      uuid.New(),
+			Kind:      models.JobProductExport,
+			Params:    string(jobParams),
+			CreatedBy: currentUserID(c),
+		}
+		if err := jobs.Enqueue(&job); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to queue export",
+			})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"message": "Export queued",
+			"data":    toJobResponse(job),
+		})
+		return
+	}
+
+	filename := fmt.Sprintf(exportFilenameFmt, time.Now().Format(exportTimeLayout), format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	// The status is already sent, so a failure can only cut the file short
+	if _, err := exportProducts(c.Request.Context(), listing, c.Writer, format, nil); err != nil {
+		log.Printf("product export failed: %v", err)
+	}
+}
+
+// GetProductExport returns the status of an export job
+func GetProductExport(c *gin.Context) {
+	job, ok := findJob(c, models.JobProductExport)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Export retrieved successfully",
+		"data":    toJobResponse(*job),
+	})
+}
+
+// DownloadProductExport downloads the file of a completed export job
+func DownloadProductExport(c *gin.Context) {
+	job, ok := findJob(c, models.JobProductExport)
+	if !ok {
+		return
+	}
+	if job.Status != models.JobCompleted || job.OutputKey == "" {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":  "Export is not ready",
+			"status": job.Status,
+		})
+		return
+	}
+
+	var params productExportParams
+	json.Unmarshal([]byte(job.Params), &params)
+
+	file, err := storage.Default.Open(c.Request.Context(), job.OutputKey)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Export file not found",
+		})
+		return
+	}
+	defer file.Close()
+
+	filename := fmt.Sprintf(exportFilenameFmt, job.CreatedAt.Format(exportTimeLayout), params.Format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.DataFromReader(http.StatusOK, -1, exportContentTypes[params.Format], file, nil)
+}
+
+// RunProductExport is the job handler for product exports. The file is
+// written to a temporary file first as storage needs to know its size.
+func RunProductExport(ctx context.Context, job *models.Job) error {
+	var params productExportParams
+	if err := json.Unmarshal([]byte(job.Params), &params); err != nil {
+		return err
+	}
+	if _, ok := exportContentTypes[params.Format]; !ok {
+		return fmt.Errorf("unsupported export format %q", params.Format)
+	}
+
+	query, err := url.ParseQuery(params.Query)
+	if err != nil {
+		return err
+	}
+	// Exports see the products their user would see in the listing
+	listing, err := parseProductListing(ctx, query, userUUID(job.CreatedBy))
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	listing.query("").Count(&total)
+	job.Total = int(total)
+	jobs.ReportProgress(job)
+
+	file, err := os.CreateTemp("", "product-export-*."+params.Format)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	result, err := exportProducts(ctx, listing, file, params.Format, func(products int) {
+		job.Processed = products
+		jobs.ReportProgress(job)
+	})
+	if err != nil {
+		return err
+	}
+
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("%s/%s.%s", ExportFilePrefix, job.Uuid, params.Format)
+	if err := storage.Default.Put(ctx, key, file, size, exportContentTypes[params.Format]); err != nil {
+		return fmt.Errorf("failed to store export: %v", err)
+	}
+
+	result.Size = size
+	resultJSON, _ := json.Marshal(result)
+	job.OutputKey = key
+	job.Result = string(resultJSON)
+	job.Processed = result.Products
+	return nil
+}
+
+// exportProducts writes the listing to w in batches, following the listing
+// sort order. progress, if set, is called with the number of products
+// written after each batch.
+func exportProducts(ctx context.Context, listing *productListing, w io.Writer, format string, progress func(products int)) (*productExportResult, error) {
+	result := &productExportResult{Format: format}
+
+	var write func(product models.Product, variants []models.ProductVariant) error
+	var finish func() error
+
+	switch format {
+	case FormatNDJSON:
+		encoder := json.NewEncoder(w)
+		write = func(product models.Product, variants []models.ProductVariant) error {
+			line := exportedProduct{ProductResponse: toProductResponse(product), Variants: []models.ProductVariantResponse{}}
+			for _, variant := range variants {
+				line.Variants = append(line.Variants, toVariantResponse(product, variant))
+			}
+			return encoder.Encode(line)
+		}
+		finish = func() error { return nil }
+	default:
+		rows, err := spreadsheet.NewWriter(w, format)
+		if err != nil {
+			return nil, err
+		}
+		if err := rows.WriteRow(exportColumns); err != nil {
+			return nil, err
+		}
+		write = func(product models.Product, variants []models.ProductVariant) error {
+			return writeProductRows(rows, product, variants)
+		}
+		finish = rows.Close
+	}
+
+	var cursor *pageCursor
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		query, err := listing.page(listing.query("").WithContext(ctx).Preload("CategoryRef").Preload("BrandRef").Preload("TaxClassRef"), cursor, exportBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		var products []models.Product
+		if err := query.Find(&products).Error; err != nil {
+			return nil, err
+		}
+		hasMore := len(products) > exportBatchSize
+		if hasMore {
+			products = products[:exportBatchSize]
+		}
+
+		variants, err := loadExportVariants(ctx, products)
+		if err != nil {
+			return nil, err
+		}
+		for _, product := range products {
+			if err := write(product, variants[product.ID]); err != nil {
+				return nil, err
+			}
+			result.Products++
+			result.Variants += len(variants[product.ID])
+		}
+		if progress != nil {
+			progress(result.Products)
+		}
+
+		if !hasMore {
+			break
+		}
+		value, id := listing.cursorKey(products[len(products)-1])
+		cursor = &pageCursor{Sort: listing.sort, Value: value, ID: id}
+	}
+
+	return result, finish()
+}
+
+// writeProductRows writes a product as one row, or one row per variant
+func writeProductRows(rows spreadsheet.RowWriter, product models.Product, variants []models.ProductVariant) error {
+	response := toProductResponse(product)
+	productCells := []interface{}{
+		response.ID.String(), response.SKU, response.Name, response.Description, response.Price.Decimal(), response.Price.Currency, response.Stock,
+		response.Category, response.Brand, response.TaxClass, response.Status, response.ImageURL,
+	}
+	timestamps := []interface{}{response.CreatedAt, response.UpdatedAt}
+
+	if len(variants) == 0 {
+		cells := append(append(productCells, nil, nil, nil, nil, nil, nil), timestamps...)
+		return rows.WriteRow(cells)
+	}
+
+	for _, variant := range variants {
+		variantResponse := toVariantResponse(product, variant)
+		cells := append(append([]interface{}{}, productCells...),
+			variantResponse.ID.String(), variantResponse.SKU, formatVariantOptions(variantResponse.Options),
+			variantResponse.Price.Decimal(), variantResponse.Stock, variantResponse.ImageURL)
+		if err := rows.WriteRow(append(cells, timestamps...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatVariantOptions renders options as "Colour=Red; Size=XL"
+func formatVariantOptions(options map[string]string) string {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + "=" + options[name]
+	}
+	return strings.Join(parts, "; ")
+}
+
+// loadExportVariants loads the variants of a batch of products with their
+// option values, grouped by product ID
+func loadExportVariants(ctx context.Context, products []models.Product) (map[uint][]models.ProductVariant, error) {
+	grouped := make(map[uint][]models.ProductVariant)
+	if len(products) == 0 {
+		return grouped, nil
+	}
+
+	ids := make([]uint, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+
+	var variants []models.ProductVariant
+	if err := config.DB.WithContext(ctx).Preload("Options.ProductOption").
+		Where("product_id IN ?", ids).
+		Order("product_id ASC, position ASC, id ASC").
+		Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	for _, variant := range variants {
+		grouped[variant.ProductID] = append(grouped[variant.ProductID], variant)
+	}
+	return grouped, nil
+}
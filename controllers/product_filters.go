@@ -0,0 +1,469 @@
+package controllers
+
+import (
+	"backend/config"
+	"backend/models"
+	"backend/search"
+	"backend/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Facet names, used to leave a facet's own filter out when counting it
+const (
+	facetBrand        = "brand"
+	facetCategory     = "category"
+	facetPrice        = "price"
+	facetAvailability = "availability"
+)
+
+// errInvalidListing wraps errors caused by bad listing query parameters
+var errInvalidListing = errors.New("invalid query parameter")
+
+// targetPriceBuckets is the number of price buckets aimed for
+const targetPriceBuckets = 5
+
+// inStockCondition matches products with stock of their own or on a variant
+const inStockCondition = "(products.stock > 0 OR EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id AND v.stock > 0))"
+
+// productSort is a listing sort order; value returns a product's position
+// in it for pagination cursors
+type productSort struct {
+	keysetOrder
+	value func(product models.Product) string
+}
+
+func productKeyset(column string, desc bool, parse func(string) (interface{}, error)) keysetOrder {
+	return keysetOrder{column: column, idColumn: "products.id", desc: desc, parse: parse}
+}
+
+// productSorts maps the sort query parameter to its order. "relevance" is
+// handled separately as it follows the search hits.
+var productSorts = map[string]productSort{
+	"newest":     {productKeyset("products.created_at", true, parseTimeCursor), productCreatedAt},
+	"oldest":     {productKeyset("products.created_at", false, parseTimeCursor), productCreatedAt},
+	"price_asc":  {productKeyset("products.price_amount", false, parseIntCursor), productPrice},
+	"price_desc": {productKeyset("products.price_amount", true, parseIntCursor), productPrice},
+	"name_asc":   {productKeyset("products.name", false, parseStringCursor), productName},
+	"name_desc":  {productKeyset("products.name", true, parseStringCursor), productName},
+	"popularity": {productKeyset("products.view_count", true, parseIntCursor), productViewCount},
+}
+
+func productCreatedAt(product models.Product) string {
+	return product.CreatedAt.Format(time.RFC3339Nano)
+}
+
+func productPrice(product models.Product) string {
+	return strconv.FormatInt(product.Price.Amount, 10)
+}
+
+func productName(product models.Product) string { return product.Name }
+
+func productViewCount(product models.Product) string {
+	return strconv.FormatInt(product.ViewCount, 10)
+}
+
+// productCondition is a single listing filter. facet names the facet the
+// condition belongs to, if any.
+type productCondition struct {
+	facet string
+	apply func(db *gorm.DB) *gorm.DB
+}
+
+// productListing is a parsed GetAllProducts request
+type productListing struct {
+	conditions    []productCondition
+	sort          string
+	priceCurrency string              // of price filters, price sorts and price buckets
+	hits          map[uint]search.Hit // nil unless searching
+	hitIDs        []uint              // hit IDs by descending relevance
+}
+
+// where adds a condition belonging to facet ("" for none)
+func (l *productListing) where(facet string, query interface{}, args ...interface{}) {
+	l.conditions = append(l.conditions, productCondition{
+		facet: facet,
+		apply: func(db *gorm.DB) *gorm.DB { return db.Where(query, args...) },
+	})
+}
+
+// query returns the products matching every condition except those of the
+// excluded facet
+func (l *productListing) query(exclude string) *gorm.DB {
+	query := config.DB.Model(&models.Product{})
+	for _, condition := range l.conditions {
+		if exclude != "" && condition.facet == exclude {
+			continue
+		}
+		query = condition.apply(query)
+	}
+	return query
+}
+
+// ordered applies the requested sort order to query
+func (l *productListing) ordered(query *gorm.DB) *gorm.DB {
+	if l.sort == "relevance" {
+		return query.Clauses(relevanceOrder(l.hitIDs, false))
+	}
+	return query.Order(productSorts[l.sort].column + " " + direction(productSorts[l.sort].desc)).
+		Order("products.id " + direction(productSorts[l.sort].desc))
+}
+
+// page fetches the page of products after (or before) cursor, see
+// keysetOrder.apply. Relevance pages continue from the rank of the cursor
+// product in the search hits.
+func (l *productListing) page(query *gorm.DB, cursor *pageCursor, limit int) (*gorm.DB, error) {
+	if l.sort != "relevance" {
+		return productSorts[l.sort].apply(query, cursor, limit)
+	}
+
+	ids, backward := l.hitIDs, cursor != nil && cursor.Backward
+	if cursor != nil {
+		rank, err := strconv.Atoi(cursor.Value)
+		if err != nil || rank < 0 || rank >= len(ids) {
+			return nil, errInvalidCursor
+		}
+		if backward {
+			ids = ids[:rank]
+		} else {
+			ids = ids[rank+1:]
+		}
+		if len(ids) == 0 {
+			ids = []uint{0}
+		}
+		query = query.Where("products.id IN ?", ids)
+	}
+	return query.Clauses(relevanceOrder(l.hitIDs, backward)).Limit(limit + 1), nil
+}
+
+// cursorKey returns the position of product in the listing order
+func (l *productListing) cursorKey(product models.Product) (string, uint) {
+	if l.sort != "relevance" {
+		return productSorts[l.sort].value(product), product.ID
+	}
+	for rank, id := range l.hitIDs {
+		if id == product.ID {
+			return strconv.Itoa(rank), product.ID
+		}
+	}
+	return "", product.ID
+}
+
+// relevanceOrder orders products by their position in the search hits
+func relevanceOrder(hitIDs []uint, reverse bool) clause.OrderBy {
+	sql := "FIELD(products.id, ?)"
+	if reverse {
+		sql += " DESC"
+	}
+	return clause.OrderBy{
+		Expression: clause.Expr{SQL: sql, Vars: []interface{}{hitIDs}, WithoutParentheses: true},
+	}
+}
+
+func direction(desc bool) string {
+	if desc {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+// parseProductListing reads the filter and sort query parameters of
+// GetAllProducts for viewer, uuid.Nil for anonymous requests. Parameters
+// naming several values accept a comma-separated list or a repeated
+// parameter.
+func parseProductListing(ctx context.Context, params url.Values, viewer uuid.UUID) (*productListing, error) {
+	listing := &productListing{}
+
+	status := models.ProductStatusActive
+	if values, ok := params["status"]; ok {
+		status = values[0]
+	}
+	condition, args, err := productStatusCondition(status, viewer)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidListing, err)
+	}
+	listing.where("", condition, args...)
+
+	// Categories include all of their subcategories
+	if categories := queryList(params, "category"); len(categories) > 0 {
+		var categoryIDs []uint
+		for _, category := range categories {
+			ids, err := categorySubtreeIDs(category)
+			if err != nil && !errors.Is(err, errCategoryNotFound) {
+				return nil, err
+			}
+			categoryIDs = append(categoryIDs, ids...)
+		}
+		if len(categoryIDs) == 0 {
+			categoryIDs = []uint{0}
+		}
+		listing.where(facetCategory, "products.category_id IN ?", categoryIDs)
+	}
+
+	if brands := queryList(params, "brand"); len(brands) > 0 {
+		brandIDs, err := brandIDsFor(brands)
+		if err != nil {
+			return nil, err
+		}
+		listing.where(facetBrand, "products.brand_id IN ?", brandIDs)
+	}
+
+	// Price bounds are decimal amounts in price_currency. Stored amounts are
+	// only comparable within a currency, so a price range only matches
+	// products priced in it.
+	listing.priceCurrency = models.DefaultCurrency
+	if value := strings.ToUpper(strings.TrimSpace(params.Get("price_currency"))); value != "" {
+		if !models.ValidCurrency(value) {
+			return nil, fmt.Errorf("%w: unknown price_currency %q", errInvalidListing, value)
+		}
+		listing.priceCurrency = value
+	}
+	priceFiltered := false
+	for _, bound := range []struct{ param, condition string }{
+		{"min_price", "products.price_amount >= ?"},
+		{"max_price", "products.price_amount <= ?"},
+	} {
+		value := params.Get(bound.param)
+		if value == "" {
+			continue
+		}
+		price, err := models.ParseAmount(value, listing.priceCurrency)
+		if err != nil || price < 0 {
+			return nil, fmt.Errorf("%w: %s must be a non-negative amount in %s", errInvalidListing, bound.param, listing.priceCurrency)
+		}
+		listing.where(facetPrice, bound.condition, price)
+		priceFiltered = true
+	}
+	if priceFiltered {
+		listing.where(facetPrice, "products.price_currency = ?", listing.priceCurrency)
+	}
+
+	switch availability := params.Get("availability"); availability {
+	case "":
+	case "in_stock":
+		listing.where(facetAvailability, inStockCondition)
+	case "out_of_stock":
+		listing.where(facetAvailability, "NOT "+inStockCondition)
+	default:
+		return nil, fmt.Errorf("%w: availability must be in_stock or out_of_stock", errInvalidListing)
+	}
+
+	if sku := params.Get("sku"); sku != "" {
+		listing.where("", "products.sku = ? OR EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id AND v.sku = ?)", sku, sku)
+	}
+
+	// Variant option filters, e.g. option[Size]=XL&option[Colour]=Red
+	for name, value := range queryMap(params, "option") {
+		listing.where("", `EXISTS (
+			SELECT 1 FROM product_variants v
+			JOIN product_variant_options pvo ON pvo.product_variant_id = v.id
+			JOIN product_option_values ov ON ov.id = pvo.product_option_value_id
+			JOIN product_options o ON o.id = ov.product_option_id
+			WHERE v.product_id = products.id AND LOWER(o.name) = LOWER(?) AND LOWER(ov.value) = LOWER(?))`, name, value)
+	}
+
+	// Full-text search narrows the listing to the ranked hits
+	if searchText := strings.TrimSpace(params.Get("search")); searchText != "" {
+		results, err := search.Default.Search(ctx, search.Query{
+			Text:  searchText,
+			Fuzzy: params.Get("fuzzy") != "false",
+			Limit: search.MaxCandidates,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		listing.hits = make(map[uint]search.Hit, len(results))
+		listing.hitIDs = make([]uint, 0, len(results))
+		for _, hit := range results {
+			listing.hits[hit.ID] = hit
+			listing.hitIDs = append(listing.hitIDs, hit.ID)
+		}
+		if len(listing.hitIDs) == 0 {
+			listing.hitIDs = []uint{0}
+		}
+		listing.where("", "products.id IN ?", listing.hitIDs)
+	}
+
+	// Sort by relevance when searching, newest first otherwise
+	listing.sort = params.Get("sort")
+	if listing.sort == "" {
+		listing.sort = "newest"
+		if listing.hits != nil {
+			listing.sort = "relevance"
+		}
+	}
+	if listing.sort == "relevance" && listing.hits == nil {
+		return nil, fmt.Errorf("%w: sort=relevance requires a search", errInvalidListing)
+	}
+	if _, ok := productSorts[listing.sort]; !ok && listing.sort != "relevance" {
+		return nil, fmt.Errorf("%w: sort must be one of relevance, newest, oldest, price_asc, price_desc, name_asc, name_desc, popularity", errInvalidListing)
+	}
+	// Sorting by price only orders products of one currency
+	if listing.sort == "price_asc" || listing.sort == "price_desc" {
+		listing.where("", "products.price_currency = ?", listing.priceCurrency)
+	}
+
+	return listing, nil
+}
+
+// facets counts the listing per brand, category, price bucket and stock
+// availability
+func (l *productListing) facets() (*models.ProductFacets, error) {
+	facets := &models.ProductFacets{
+		Brands:     []models.FacetValue{},
+		Categories: []models.FacetValue{},
+		Price:      []models.PriceBucket{},
+	}
+
+	if err := l.query(facetBrand).
+		Joins("JOIN brands ON brands.id = products.brand_id").
+		Select("brands.uuid AS id, brands.name, brands.slug, COUNT(*) AS count").
+		Group("brands.id, brands.uuid, brands.name, brands.slug").
+		Order("count DESC, brands.name ASC").
+		Scan(&facets.Brands).Error; err != nil {
+		return nil, err
+	}
+
+	if err := l.query(facetCategory).
+		Joins("JOIN categories ON categories.id = products.category_id").
+		Select("categories.uuid AS id, categories.name, categories.slug, COUNT(*) AS count").
+		Group("categories.id, categories.uuid, categories.name, categories.slug").
+		Order("count DESC, categories.name ASC").
+		Scan(&facets.Categories).Error; err != nil {
+		return nil, err
+	}
+
+	var bounds struct {
+		Low  *int64
+		High *int64
+	}
+	if err := l.query(facetPrice).
+		Where("products.price_currency = ?", l.priceCurrency).
+		Select("MIN(products.price_amount) AS low, MAX(products.price_amount) AS high").
+		Scan(&bounds).Error; err != nil {
+		return nil, err
+	}
+	if bounds.Low != nil && bounds.High != nil {
+		step := priceBucketStep(*bounds.Low, *bounds.High)
+		var buckets []struct {
+			Bucket int64
+			Count  int64
+		}
+		if err := l.query(facetPrice).
+			Where("products.price_currency = ?", l.priceCurrency).
+			Select("FLOOR(products.price_amount / ?) AS bucket, COUNT(*) AS count", step).
+			Group("bucket").
+			Order("bucket ASC").
+			Scan(&buckets).Error; err != nil {
+			return nil, err
+		}
+		for _, bucket := range buckets {
+			facets.Price = append(facets.Price, models.PriceBucket{
+				Min:   models.Money{Amount: bucket.Bucket * step, Currency: l.priceCurrency},
+				Max:   models.Money{Amount: (bucket.Bucket + 1) * step, Currency: l.priceCurrency},
+				Count: bucket.Count,
+			})
+		}
+	}
+
+	var availability struct {
+		Total   int64
+		InStock int64
+	}
+	if err := l.query(facetAvailability).
+		Select("COUNT(*) AS total, COALESCE(SUM(CASE WHEN " + inStockCondition + " THEN 1 ELSE 0 END), 0) AS in_stock").
+		Scan(&availability).Error; err != nil {
+		return nil, err
+	}
+	facets.Availability = models.AvailabilityFacet{
+		InStock:    availability.InStock,
+		OutOfStock: availability.Total - availability.InStock,
+	}
+
+	return facets, nil
+}
+
+// priceBucketStep picks a round bucket width in minor units (1, 2 or 5
+// times a power of ten) splitting low..high into about targetPriceBuckets
+// buckets
+func priceBucketStep(low, high int64) int64 {
+	span := high - low
+	if span <= 0 {
+		span = high
+	}
+	raw := span / targetPriceBuckets
+	if raw <= 1 {
+		return 1
+	}
+
+	magnitude := int64(1)
+	for magnitude*10 <= raw {
+		magnitude *= 10
+	}
+	for _, factor := range []int64{1, 2, 5} {
+		if raw <= factor*magnitude {
+			return factor * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+// brandIDsFor resolves brand IDs, slugs or names; unknown brands are ignored
+func brandIDsFor(identifiers []string) ([]uint, error) {
+	var uuids, slugs, names []string
+	for _, identifier := range identifiers {
+		if id, err := uuid.Parse(identifier); err == nil {
+			uuids = append(uuids, id.String())
+			continue
+		}
+		slugs = append(slugs, utils.Slugify(identifier))
+		names = append(names, strings.ToLower(identifier))
+	}
+
+	var ids []uint
+	if err := config.DB.Model(&models.Brand{}).
+		Where("uuid IN ? OR slug IN ? OR LOWER(name) IN ?", uuids, slugs, names).
+		Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		ids = []uint{0}
+	}
+	return ids, nil
+}
+
+// queryList returns the values of a query parameter given as a
+// comma-separated list, repeated, or both
+func queryList(params url.Values, key string) []string {
+	var values []string
+	for _, param := range params[key] {
+		for _, value := range strings.Split(param, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// queryMap returns the parameters written as key[name]=value, like
+// gin.Context.QueryMap
+func queryMap(params url.Values, key string) map[string]string {
+	result := make(map[string]string)
+	for param, values := range params {
+		if strings.HasPrefix(param, key+"[") && strings.HasSuffix(param, "]") && len(values) > 0 {
+			result[param[len(key)+1:len(param)-1]] = values[0]
+		}
+	}
+	return result
+}
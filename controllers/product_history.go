@@ -0,0 +1,259 @@
+package controllers
+
+import (
+	"backend/config"
+	"backend/models"
+	"backend/search"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GetProductHistory lists the revisions of a product, newest first, with
+// cursor pagination
+func GetProductHistory(c *gin.Context) {
+	productUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID",
+		})
+		return
+	}
+
+	// Deleted products keep their history
+	var product models.Product
+	if err := config.DB.Unscoped().Where("uuid = ?", productUUID).First(&product).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Product not found",
+		})
+		return
+	}
+
+	limit := pageSize(c, "limit")
+	cursor, err := parseCursor(c, "newest")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid cursor",
+		})
+		return
+	}
+	query, err := keysetOrder{idColumn: "id", desc: true}.apply(
+		config.DB.Preload("Actor").Where("product_id = ?", product.ID), cursor, limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid cursor",
+		})
+		return
+	}
+
+	var revisions []models.ProductRevision
+	if err := query.Find(&revisions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve product history",
+		})
+		return
+	}
+	revisions, next, prev := keysetPage(revisions, limit, cursor, "newest", func(revision models.ProductRevision) (string, uint) {
+		return "", revision.ID
+	})
+
+	responses := []models.ProductRevisionResponse{}
+	for _, revision := range revisions {
+		responses = append(responses, toRevisionResponse(revision))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Product history retrieved successfully",
+		"data":        responses,
+		"limit":       limit,
+		"next_cursor": next,
+		"prev_cursor": prev,
+		"has_next":    next != "",
+		"has_prev":    prev != "",
+	})
+}
+
+// RestoreProductRevision puts a product back into the state recorded by one
+// of its revisions. Images are not restored as replaced files may already be
+// deleted, and the status change must be allowed by the lifecycle.
+func RestoreProductRevision(c *gin.Context) {
+	productUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID",
+		})
+		return
+	}
+	revisionUUID, err := uuid.Parse(c.Param("revision_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid revision ID",
+		})
+		return
+	}
+
+	var product models.Product
+	if err := config.DB.Preload("CategoryRef").Preload("BrandRef").Preload("TaxClassRef").Where("uuid = ?", productUUID).First(&product).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Product not found",
+		})
+		return
+	}
+
+	var revision models.ProductRevision
+	if err := config.DB.Where("uuid = ? AND product_id = ?", revisionUUID, product.ID).First(&revision).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Revision not found",
+		})
+		return
+	}
+	snapshot, err := revision.ProductSnapshot()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to read revision",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	before := product
+	if err := applyProductSnapshot(&product, snapshot); err != nil {
+		var transitionErr errProductStatusTransition
+		if errors.As(err, &transitionErr) {
+			respondStatusTransitionError(c, transitionErr)
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Revision cannot be restored",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("stock").Save(&product).Error; err != nil {
+			return err
+		}
+		if err := models.RecordPriceChange(tx, &before, product, currentUserID(c)); err != nil {
+			return err
+		}
+		restore, _ := models.NewProductRevision(models.RevisionRestore, &before, product, currentUserID(c))
+		restore.RestoredFrom = &revision.Uuid
+		return tx.Create(&restore).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to restore revision",
+			"details": err.Error(),
+		})
+		return
+	}
+	search.Sync(product.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Revision restored successfully",
+		"data":    toProductResponse(product),
+	})
+}
+
+// applyProductSnapshot sets the fields of a revision snapshot on product,
+// except the image and stock, which only changes through inventory
+// movements. The category, brand and tax class must still exist and
+// the SKU must not have been taken by another product since.
+func applyProductSnapshot(product *models.Product, snapshot models.ProductSnapshot) error {
+	if snapshot.SKU != product.SKU && snapshot.SKU != "" {
+		var count int64
+		config.DB.Unscoped().Model(&models.Product{}).Where("sku = ? AND id <> ?", snapshot.SKU, product.ID).Count(&count)
+		if count > 0 {
+			return errors.New("the SKU of this revision is used by another product")
+		}
+	}
+
+	if err := checkPriceCurrencyChange(*product, snapshot.Price.Currency); err != nil {
+		return err
+	}
+
+	var category *models.Category
+	if snapshot.CategoryID != nil {
+		category = &models.Category{}
+		if err := config.DB.First(category, *snapshot.CategoryID).Error; err != nil {
+			return errors.New("the category of this revision no longer exists")
+		}
+	}
+	var brand *models.Brand
+	if snapshot.BrandID != nil {
+		brand = &models.Brand{}
+		if err := config.DB.First(brand, *snapshot.BrandID).Error; err != nil {
+			return errors.New("the brand of this revision no longer exists")
+		}
+	}
+
+	var taxClass *models.TaxClass
+	if snapshot.TaxClassID != nil {
+		taxClass = &models.TaxClass{}
+		if err := config.DB.First(taxClass, *snapshot.TaxClassID).Error; err != nil {
+			return errors.New("the tax class of this revision no longer exists")
+		}
+	}
+
+	if err := changeProductStatus(product, snapshot.Status); err != nil {
+		return err
+	}
+	product.Name = snapshot.Name
+	product.Description = snapshot.Description
+	product.Price = snapshot.Price
+	product.CompareAtAmount, product.SaleAmount = nil, nil
+	if snapshot.CompareAtPrice != nil {
+		product.CompareAtAmount = &snapshot.CompareAtPrice.Amount
+	}
+	if snapshot.SalePrice != nil {
+		product.SaleAmount = &snapshot.SalePrice.Amount
+	}
+	product.SaleStartsAt = snapshot.SaleStartsAt
+	product.SaleEndsAt = snapshot.SaleEndsAt
+	product.SKU = snapshot.SKU
+	product.ReorderThreshold = snapshot.ReorderThreshold
+	product.PublishAt = snapshot.PublishAt
+	product.UnpublishAt = snapshot.UnpublishAt
+
+	// Categories and brands may have been renamed since, so take the
+	// current names
+	product.CategoryID, product.CategoryRef = nil, category
+	product.Category = snapshot.Category
+	if category != nil {
+		product.CategoryID = &category.ID
+		product.Category = category.Name
+	}
+	product.TaxClassID, product.TaxClassRef = nil, taxClass
+	if taxClass != nil {
+		product.TaxClassID = &taxClass.ID
+	}
+	product.BrandID, product.BrandRef, product.Brand = nil, brand, ""
+	if brand != nil {
+		product.BrandID = &brand.ID
+		product.Brand = brand.Name
+	}
+
+	if err := checkProductSchedule(product); err != nil {
+		return err
+	}
+	return checkProductPricing(product)
+}
+
+// toRevisionResponse converts a revision with its preloaded actor
+func toRevisionResponse(revision models.ProductRevision) models.ProductRevisionResponse {
+	response := models.ProductRevisionResponse{
+		ID:           revision.Uuid,
+		Action:       revision.Action,
+		Changes:      revision.PublicChanges(),
+		RestoredFrom: revision.RestoredFrom,
+		CreatedAt:    revision.CreatedAt,
+	}
+	if revision.Actor != nil {
+		response.Actor = &models.RevisionActor{ID: revision.Actor.Uuid, Name: revision.Actor.Name}
+	}
+	return response
+}
@@ -0,0 +1,636 @@
+package controllers
+
+import (
+	"backend/config"
+	"backend/inventory"
+	"backend/jobs"
+	"backend/models"
+	"backend/search"
+	"backend/spreadsheet"
+	"backend/storage"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Import limits and storage locations
+const (
+	MaxImportFileSize   = 20 << 20 // 20MB
+	ImportFilePrefix    = "imports"
+	ImportReportPrefix  = "reports/imports"
+	importProgressEvery = 100
+)
+
+// importRequiredColumns must be present in the header row of an import file
+var importRequiredColumns = []string{"sku", "name", "price"}
+
+// importColumns are the recognised header names; other columns are ignored
+var importColumns = map[string]bool{
+	"sku": true, "name": true, "description": true, "price": true, "stock": true,
+	"category": true, "category_id": true, "brand": true, "brand_id": true, "status": true,
+	"currency": true, "tax_class": true,
+}
+
+// importFieldNames maps ProductCreateRequest fields to import columns
+var importFieldNames = map[string]string{
+	"Name": "name", "Description": "description", "Price": "price", "Stock": "stock",
+	"CategoryID": "category_id", "Category": "category", "BrandID": "brand_id", "Brand": "brand",
+	"SKU": "sku", "Status": "status",
+}
+
+// productImportParams are the Params of a product import job
+type productImportParams struct {
+	Filename string `json:"filename"`
+	Format   string `json:"format"`
+	DryRun   bool   `json:"dry_run"`
+}
+
+// productImportResult is the Result of a product import job
+type productImportResult struct {
+	DryRun          bool `json:"dry_run"`
+	Rows            int  `json:"rows"`
+	Created         int  `json:"created"`
+	Updated         int  `json:"updated"`
+	Failed          int  `json:"failed"`
+	ReportAvailable bool `json:"report_available"`
+}
+
+// importRowError is a line of the error report
+type importRowError struct {
+	Field   string
+	Message string
+}
+
+// ImportProducts accepts a CSV or XLSX file and queues it for import. Rows
+// are matched to existing products by SKU. With dry_run=true the file is
+// only validated.
+func ImportProducts(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "No file provided",
+		})
+		return
+	}
+	if fileHeader.Size > MaxImportFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("File size exceeds maximum limit of %d MB", MaxImportFileSize/(1024*1024)),
+		})
+		return
+	}
+
+	format, err := spreadsheet.FormatFromFilename(fileHeader.Filename)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read file",
+		})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, MaxImportFileSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read file",
+		})
+		return
+	}
+
+	// Check the header row now so that obviously wrong files fail fast
+	reader, err := spreadsheet.NewReader(data, format)
+	if err == nil {
+		_, err = readImportHeader(reader)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid import file",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	dryRun := c.Query("dry_run") == "true" || c.PostForm("dry_run") == "true"
+	params, _ := json.Marshal(productImportParams{Filename: fileHeader.Filename, Format: format, DryRun: dryRun})
+
+	job := models.Job{
+		Uuid:      uuid.New(),
+		Kind:      models.JobProductImport,
+		Params:    string(params),
+		CreatedBy: currentUserID(c),
+	}
+	job.InputKey = fmt.Sprintf("%s/%s.%s", ImportFilePrefix, job.Uuid, format)
+
+	if err := storage.Default.Put(c.Request.Context(), job.InputKey, bytes.NewReader(data), int64(len(data)), "application/octet-stream"); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to store import file",
+		})
+		return
+	}
+
+	if err := jobs.Enqueue(&job); err != nil {
+		storage.Default.Delete(c.Request.Context(), job.InputKey)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to queue import",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Import queued",
+		"data":    toJobResponse(job),
+	})
+}
+
+// GetProductImport returns the status of an import job
+func GetProductImport(c *gin.Context) {
+	job, ok := findJob(c, models.JobProductImport)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Import retrieved successfully",
+		"data":    toJobResponse(*job),
+	})
+}
+
+// GetProductImportReport downloads the CSV report of the rows an import job
+// rejected
+func GetProductImportReport(c *gin.Context) {
+	job, ok := findJob(c, models.JobProductImport)
+	if !ok {
+		return
+	}
+	if job.OutputKey == "" {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Import has no error report",
+		})
+		return
+	}
+
+	report, err := storage.Default.Open(c.Request.Context(), job.OutputKey)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Error report not found",
+		})
+		return
+	}
+	defer report.Close()
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="import-%s-errors.csv"`, job.Uuid))
+	c.DataFromReader(http.StatusOK, -1, "text/csv; charset=utf-8", report, nil)
+}
+
+// RunProductImport is the job handler for product imports
+func RunProductImport(ctx context.Context, job *models.Job) error {
+	var params productImportParams
+	if err := json.Unmarshal([]byte(job.Params), &params); err != nil {
+		return err
+	}
+
+	// The file is no longer needed once the job has finished either way
+	defer storage.Default.Delete(context.Background(), job.InputKey)
+
+	file, err := storage.Default.Open(ctx, job.InputKey)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		return err
+	}
+
+	reader, err := spreadsheet.NewReader(data, params.Format)
+	if err != nil {
+		return err
+	}
+	columns, err := readImportHeader(reader)
+	if err != nil {
+		return err
+	}
+
+	var rows [][]string
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("row %d: %v", len(rows)+2, err)
+		}
+		rows = append(rows, row)
+	}
+	job.Total = len(rows)
+	jobs.ReportProgress(job)
+
+	// Products are attributed to the user who uploaded the file
+	var actor uuid.UUID
+	config.DB.Model(&models.User{}).Where("id = ?", job.CreatedBy).Select("uuid").Scan(&actor)
+
+	var report bytes.Buffer
+	reportWriter := csv.NewWriter(&report)
+	reportWriter.Write([]string{"row", "sku", "column", "error"})
+
+	result := productImportResult{DryRun: params.DryRun}
+	skuRows := make(map[string]int)
+	var changed []uint
+
+	for i, row := range rows {
+		line := i + 2 // 1-based, after the header row
+		job.Processed = i + 1
+		if job.Processed%importProgressEvery == 0 {
+			jobs.ReportProgress(job)
+		}
+		if isEmptyRow(row) {
+			continue
+		}
+		result.Rows++
+
+		values := make(map[string]string, len(columns))
+		for column, index := range columns {
+			if index < len(row) {
+				values[column] = strings.TrimSpace(row[index])
+			}
+		}
+
+		rowErrors := func() []importRowError {
+			if first, ok := skuRows[strings.ToLower(values["sku"])]; ok && values["sku"] != "" {
+				return []importRowError{{"sku", fmt.Sprintf("duplicate SKU, already used on row %d", first)}}
+			}
+			if values["sku"] != "" {
+				skuRows[strings.ToLower(values["sku"])] = line
+			}
+
+			product, before, rowErrors := importProductRow(values, columns, actor)
+			created := before == nil
+			if len(rowErrors) > 0 || params.DryRun {
+				if len(rowErrors) == 0 {
+					countImported(&result, created)
+				}
+				return rowErrors
+			}
+
+			err := config.DB.Transaction(func(tx *gorm.DB) error {
+				// Stock is written through the inventory ledger. The stock
+				// column is the stock of the default warehouse, so the
+				// difference is taken from that warehouse's level, not the
+				// total over all warehouses.
+				stock := product.Stock
+				var movement *inventory.Movement
+				action := models.RevisionUpdate
+				if created {
+					action = models.RevisionCreate
+					product.Stock = 0
+					if err := tx.Create(product).Error; err != nil {
+						return err
+					}
+					if stock > 0 {
+						movement = &inventory.Movement{ProductID: product.ID, Type: models.MovementReceive, Quantity: stock, Reason: "initial stock", ActorID: job.CreatedBy}
+					}
+				} else {
+					product.Stock = before.Stock
+					if err := tx.Omit("stock").Save(product).Error; err != nil {
+						return err
+					}
+					if _, ok := columns["stock"]; ok {
+						current, err := defaultWarehouseStock(tx, product.ID)
+						if err != nil {
+							return err
+						}
+						if current != stock {
+							movement = &inventory.Movement{ProductID: product.ID, Type: models.MovementAdjust, SetStock: &stock, Reason: "import", ActorID: job.CreatedBy}
+						}
+					}
+				}
+				if movement != nil {
+					entry, err := inventory.Move(tx, *movement)
+					if err != nil {
+						return err
+					}
+					product.Stock = entry.StockAfter
+				}
+				if err := models.RecordPriceChange(tx, before, *product, job.CreatedBy); err != nil {
+					return err
+				}
+				return models.RecordProductRevision(tx, action, before, *product, job.CreatedBy)
+			})
+			if err != nil {
+				return []importRowError{{"", "failed to save product: " + err.Error()}}
+			}
+			countImported(&result, created)
+			changed = append(changed, product.ID)
+			return nil
+		}()
+
+		if len(rowErrors) > 0 {
+			result.Failed++
+			for _, rowError := range rowErrors {
+				reportWriter.Write([]string{strconv.Itoa(line), values["sku"], rowError.Field, rowError.Message})
+			}
+		}
+	}
+
+	for start := 0; start < len(changed); start += 500 {
+		search.Sync(changed[start:min(start+500, len(changed))]...)
+	}
+
+	if result.Failed > 0 {
+		reportWriter.Flush()
+		key := fmt.Sprintf("%s/%s.csv", ImportReportPrefix, job.Uuid)
+		if err := storage.Default.Put(ctx, key, bytes.NewReader(report.Bytes()), int64(report.Len()), "text/csv"); err != nil {
+			return fmt.Errorf("failed to store error report: %v", err)
+		}
+		job.OutputKey = key
+		result.ReportAvailable = true
+	}
+
+	resultJSON, _ := json.Marshal(result)
+	job.Result = string(resultJSON)
+	return nil
+}
+
+// readImportHeader reads the header row and returns the column index of
+// every recognised column
+func readImportHeader(reader spreadsheet.RowReader) (map[string]int, error) {
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if importColumns[name] {
+			if _, duplicate := columns[name]; duplicate {
+				return nil, fmt.Errorf("column %q appears more than once", name)
+			}
+			columns[name] = i
+		}
+	}
+
+	var missing []string
+	for _, name := range importRequiredColumns {
+		if _, ok := columns[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required columns: %s", strings.Join(missing, ", "))
+	}
+	return columns, nil
+}
+
+// defaultWarehouseStock returns the stock of a product, without its
+// variants, in the default warehouse
+func defaultWarehouseStock(tx *gorm.DB, productID uint) (int, error) {
+	warehouse, err := inventory.DefaultWarehouse(tx)
+	if err != nil {
+		return 0, err
+	}
+	var level models.StockLevel
+	err = tx.Where("warehouse_id = ? AND product_id = ? AND variant_id = ?", warehouse.ID, productID, 0).Limit(1).Find(&level).Error
+	return level.Quantity, err
+}
+
+// importProductRow validates a row against the ProductCreateRequest rules
+// and returns the product to save: the existing product with the same SKU
+// updated with the row's columns, along with its state before the update,
+// or a new one (before is nil)
+func importProductRow(values map[string]string, columns map[string]int, actor uuid.UUID) (*models.Product, *models.Product, []importRowError) {
+	var rowErrors []importRowError
+
+	request := models.ProductCreateRequest{
+		Name:        values["name"],
+		Description: values["description"],
+		CategoryID:  values["category_id"],
+		Category:    values["category"],
+		BrandID:     values["brand_id"],
+		Brand:       values["brand"],
+		SKU:         values["sku"],
+	}
+	status := values["status"]
+	if request.SKU == "" {
+		rowErrors = append(rowErrors, importRowError{"sku", "is required"})
+	}
+	var product models.Product
+	err := config.DB.Unscoped().Preload("TaxClassRef").Where("sku = ?", request.SKU).First(&product).Error
+	created := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !created {
+		rowErrors = append(rowErrors, importRowError{"", "failed to look up SKU: " + err.Error()})
+	}
+	if err == nil && product.DeletedAt.Valid {
+		rowErrors = append(rowErrors, importRowError{"sku", "SKU belongs to a product in the trash"})
+	}
+
+	// Prices are in the currency column, or else the currency the product
+	// already has
+	currency := values["currency"]
+	if currency == "" && !created {
+		currency = product.Price.Currency
+	}
+	if value := values["price"]; value != "" {
+		price, err := models.ParseMoney(value, currency)
+		if err != nil {
+			rowErrors = append(rowErrors, importRowError{"price", err.Error()})
+		}
+		request.Price = price
+	}
+	if value := values["stock"]; value != "" {
+		stock, err := strconv.Atoi(value)
+		if err != nil {
+			rowErrors = append(rowErrors, importRowError{"stock", "must be a whole number"})
+		}
+		request.Stock = stock
+	}
+
+	if err := binding.Validator.ValidateStruct(&request); err != nil {
+		var validationErrors validator.ValidationErrors
+		if !errors.As(err, &validationErrors) {
+			return nil, nil, append(rowErrors, importRowError{"", err.Error()})
+		}
+		for _, fieldError := range validationErrors {
+			rowErrors = append(rowErrors, importRowError{importFieldNames[fieldError.Field()], validationMessage(fieldError)})
+		}
+	}
+
+	// New products get every column, existing ones only the columns present
+	has := func(column string) bool {
+		_, ok := columns[column]
+		return created || ok
+	}
+
+	var category *models.Category
+	if has("category") || has("category_id") {
+		if category, err = resolveProductCategory(request.CategoryID, request.Category); err != nil {
+			rowErrors = append(rowErrors, importRowError{"category", "category not found"})
+		}
+	}
+
+	var brand *models.Brand
+	if request.BrandID != "" || request.Brand != "" {
+		if brand, err = resolveProductBrand(request.BrandID, request.Brand); err != nil {
+			rowErrors = append(rowErrors, importRowError{"brand", "brand not found"})
+		}
+	}
+	var taxClass *models.TaxClass
+	if value := values["tax_class"]; value != "" {
+		if taxClass, err = resolveTaxClass(value); err != nil {
+			rowErrors = append(rowErrors, importRowError{"tax_class", "tax class not found"})
+		}
+	}
+	// New products start as draft or active, existing ones follow the lifecycle
+	if created && status != "" && status != models.ProductStatusDraft && status != models.ProductStatusActive {
+		rowErrors = append(rowErrors, importRowError{"status", "new products must be draft or active"})
+	}
+	if !created && status != "" && !models.ProductStatusAllowsTransition(product.Status, status) {
+		rowErrors = append(rowErrors, importRowError{"status", errProductStatusTransition{From: product.Status, To: status}.Error()})
+	}
+	if len(rowErrors) > 0 {
+		return nil, nil, rowErrors
+	}
+	var before *models.Product
+	if created {
+		product = models.Product{Uuid: uuid.New(), SKU: request.SKU, Status: initialProductStatus(status, nil), CreatedBy: actor}
+	} else {
+		previous := product
+		before = &previous
+	}
+	product.UpdatedBy = actor
+	product.Name = request.Name
+	product.Price = request.Price
+	if has("description") {
+		product.Description = request.Description
+	}
+	if has("stock") {
+		product.Stock = request.Stock
+	}
+	if status != "" {
+		changeProductStatus(&product, status)
+	}
+	if category != nil {
+		product.CategoryID = &category.ID
+		product.Category = category.Name
+	}
+	if brand != nil {
+		product.BrandID = &brand.ID
+		product.Brand = brand.Name
+	}
+	if taxClass != nil {
+		product.TaxClassID = &taxClass.ID
+		product.TaxClassRef = taxClass
+	}
+
+	// Variant overrides, compare-at and sale prices are amounts in the
+	// product currency and are not imported, so they must fit the new price
+	if before != nil && product.Price.Currency != before.Price.Currency {
+		if err := checkPriceCurrencyChange(*before, product.Price.Currency); err != nil {
+			return nil, nil, []importRowError{{"currency", err.Error()}}
+		}
+		if product.CompareAtAmount != nil || product.SaleAmount != nil {
+			return nil, nil, []importRowError{{"currency", "clear compare_at_price and sale_price before changing the currency"}}
+		}
+	}
+	if err := checkProductPricing(&product); err != nil {
+		return nil, nil, []importRowError{{"price", err.Error()}}
+	}
+
+	return &product, before, nil
+}
+
+// validationMessage describes a failed binding rule for the error report
+func validationMessage(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return "must be at least " + fieldError.Param()
+	case "max":
+		return "must be at most " + fieldError.Param()
+	case "uuid":
+		return "must be a UUID"
+	default:
+		return "failed the " + fieldError.Tag() + " rule"
+	}
+}
+
+func countImported(result *productImportResult, created bool) {
+	if created {
+		result.Created++
+	} else {
+		result.Updated++
+	}
+}
+
+func isEmptyRow(row []string) bool {
+	for _, value := range row {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// findJob loads the job of the given kind named by the :id URL parameter.
+// Users only see their own jobs; those of others are not found. It writes
+// the error response itself when it cannot load the job.
+func findJob(c *gin.Context, kind string) (*models.Job, bool) {
+	jobUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid job ID",
+		})
+		return nil, false
+	}
+
+	var job models.Job
+	if err := config.DB.Where("uuid = ? AND kind = ? AND created_by = ?", jobUUID, kind, currentUserID(c)).First(&job).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Job not found",
+		})
+		return nil, false
+	}
+	return &job, true
+}
+
+func toJobResponse(job models.Job) models.JobResponse {
+	response := models.JobResponse{
+		ID:         job.Uuid,
+		Kind:       job.Kind,
+		Status:     job.Status,
+		Processed:  job.Processed,
+		Total:      job.Total,
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+	}
+	if job.Result != "" {
+		response.Result = json.RawMessage(job.Result)
+	}
+	return response
+}
@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"backend/config"
+	"backend/models"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// lowestPriceDays is the default period of the lowest price, as required
+// for price reductions by consumer protection rules
+const lowestPriceDays = 30
+
+// GetProductPrices returns the pricing of a product with its price history
+// and the lowest price of the last days (default 30). Logged-in users in a
+// customer group also get the price of their price list.
+func GetProductPrices(c *gin.Context) {
+	productUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID",
+		})
+		return
+	}
+
+	days := lowestPriceDays
+	if value := c.Query("days"); value != "" {
+		days, err = strconv.Atoi(value)
+		if err != nil || days < 1 || days > 365 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid query parameters",
+				"details": "days must be between 1 and 365",
+			})
+			return
+		}
+	}
+
+	var product models.Product
+	if err := config.DB.Where("uuid = ?", productUUID).First(&product).Error; err != nil || !productVisibleTo(product, currentUserUUID(c)) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Product not found",
+		})
+		return
+	}
+
+	now := time.Now()
+	from := now.AddDate(0, 0, -days)
+	history, err := priceHistorySince(product.ID, from)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve price history",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	response := models.ProductPricesResponse{
+		Price:          product.Price,
+		CompareAtPrice: product.CompareAtPrice(),
+		SalePrice:      product.SalePrice(),
+		SaleStartsAt:   product.SaleStartsAt,
+		SaleEndsAt:     product.SaleEndsAt,
+		SaleActive:     product.SaleActive(now),
+		CurrentPrice:   product.CurrentPrice(now),
+		CustomerPrice:  customerPrices(currentUserID(c), []models.Product{product}, now)[product.ID],
+		LowestPrice: models.LowestPrice{
+			Days:  days,
+			Price: models.LowestHistoricPrice(history, product.Price.Currency, from, now),
+		},
+		History: []models.PriceHistoryResponse{},
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		response.History = append(response.History, history[i].Response())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Product prices retrieved successfully",
+		"data":    response,
+	})
+}
+
+// priceHistorySince returns the price history of a product from the entry
+// in effect at from onwards, oldest first
+func priceHistorySince(productID uint, from time.Time) ([]models.PriceHistory, error) {
+	var history []models.PriceHistory
+	var first models.PriceHistory
+	err := config.DB.Where("product_id = ? AND created_at <= ?", productID, from).
+		Order("created_at DESC, id DESC").First(&first).Error
+	if err == nil {
+		history = append(history, first)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var later []models.PriceHistory
+	if err := config.DB.Where("product_id = ? AND created_at > ?", productID, from).
+		Order("created_at ASC, id ASC").Find(&later).Error; err != nil {
+		return nil, err
+	}
+	return append(history, later...), nil
+}
+
+// customerPrices returns the price list prices of products for the customer
+// group of a user, keyed by product ID. When several lists of the group
+// price a product, the lowest price wins.
+func customerPrices(userID uint, products []models.Product, at time.Time) map[uint]*models.CustomerPrice {
+	result := make(map[uint]*models.CustomerPrice)
+	if userID == 0 || len(products) == 0 {
+		return result
+	}
+
+	var user models.User
+	if err := config.DB.Select("id", "customer_group").First(&user, userID).Error; err != nil || user.CustomerGroup == "" {
+		return result
+	}
+
+	currencies := make(map[uint]string, len(products))
+	productIDs := make([]uint, len(products))
+	for i, product := range products {
+		currencies[product.ID] = product.Price.Currency
+		productIDs[i] = product.ID
+	}
+
+	var prices []models.PriceListPrice
+	config.DB.Preload("PriceList").
+		Joins("JOIN price_lists ON price_lists.id = price_list_prices.price_list_id").
+		Where("price_lists.customer_group = ? AND price_lists.active = ?", user.CustomerGroup, true).
+		Where("price_lists.starts_at IS NULL OR price_lists.starts_at <= ?", at).
+		Where("price_lists.ends_at IS NULL OR price_lists.ends_at > ?", at).
+		Where("price_list_prices.product_id IN ?", productIDs).
+		Find(&prices)
+
+	for _, price := range prices {
+		if price.Price.Currency != currencies[price.ProductID] {
+			continue
+		}
+		if current := result[price.ProductID]; current != nil && current.Price.Amount <= price.Price.Amount {
+			continue
+		}
+		result[price.ProductID] = &models.CustomerPrice{
+			Price:         price.Price,
+			CustomerGroup: user.CustomerGroup,
+			PriceList:     models.PriceListInfo{ID: price.PriceList.Uuid, Name: price.PriceList.Name},
+		}
+	}
+	return result
+}
+
+// pricingAmount returns a compare-at or sale price as an amount in the
+// product currency
+func pricingAmount(product models.Product, field string, price *models.Money) (*int64, error) {
+	if price == nil {
+		return nil, nil
+	}
+	if price.Amount < 0 {
+		return nil, fmt.Errorf("%s must not be negative", field)
+	}
+	if price.Currency != product.Price.Currency {
+		return nil, fmt.Errorf("%s must be in the product currency %s", field, product.Price.Currency)
+	}
+	return &price.Amount, nil
+}
+
+// updateProductPricing applies the compare-at price and sale of an update
+// request. Both are amounts in the product currency, so when the currency
+// changes they must be sent again or cleared.
+func updateProductPricing(product *models.Product, currencyChanged bool, request models.ProductUpdateRequest) error {
+	var err error
+	if request.CompareAtPrice.Set {
+		if product.CompareAtAmount, err = pricingAmount(*product, "compare_at_price", request.CompareAtPrice.Money); err != nil {
+			return err
+		}
+	} else if currencyChanged && product.CompareAtAmount != nil {
+		return errors.New("compare_at_price must be set again or cleared when the currency changes")
+	}
+
+	if request.SalePrice.Set {
+		if product.SaleAmount, err = pricingAmount(*product, "sale_price", request.SalePrice.Money); err != nil {
+			return err
+		}
+		// Ending the sale drops its schedule unless a new one is sent
+		if product.SaleAmount == nil {
+			product.SaleStartsAt, product.SaleEndsAt = nil, nil
+		}
+	} else if currencyChanged && product.SaleAmount != nil {
+		return errors.New("sale_price must be set again or cleared when the currency changes")
+	}
+
+	if request.SaleStartsAt.Set {
+		product.SaleStartsAt = request.SaleStartsAt.Time
+	}
+	if request.SaleEndsAt.Set {
+		product.SaleEndsAt = request.SaleEndsAt.Time
+	}
+	return checkProductPricing(product)
+}
+
+// checkProductPricing validates the compare-at price and sale of a product
+// against its price
+func checkProductPricing(product *models.Product) error {
+	if product.CompareAtAmount != nil && *product.CompareAtAmount <= product.Price.Amount {
+		return errors.New("compare_at_price must be greater than price")
+	}
+	if product.SaleAmount == nil {
+		if product.SaleStartsAt != nil || product.SaleEndsAt != nil {
+			return errors.New("sale_starts_at and sale_ends_at require a sale_price")
+		}
+		return nil
+	}
+	if *product.SaleAmount >= product.Price.Amount {
+		return errors.New("sale_price must be less than price")
+	}
+	if product.SaleStartsAt != nil && product.SaleEndsAt != nil && !product.SaleEndsAt.After(*product.SaleStartsAt) {
+		return errors.New("sale_ends_at must be after sale_starts_at")
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"backend/models"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// errProductStatusTransition is returned when a product may not move to the
+// requested status
+type errProductStatusTransition struct {
+	From, To string
+}
+
+func (err errProductStatusTransition) Error() string {
+	return fmt.Sprintf("cannot change status from %s to %s", err.From, err.To)
+}
+
+// initialProductStatus returns the status of a new product: the requested
+// one, or draft when the product is scheduled to go live later
+func initialProductStatus(status string, publishAt *time.Time) string {
+	if status != "" {
+		return status
+	}
+	if publishAt != nil && publishAt.After(time.Now()) {
+		return models.ProductStatusDraft
+	}
+	return models.ProductStatusActive
+}
+
+// changeProductStatus moves product to status if the lifecycle allows it.
+// A manual change supersedes the schedule entry that would have made it.
+func changeProductStatus(product *models.Product, status string) error {
+	if !models.ProductStatusAllowsTransition(product.Status, status) {
+		return errProductStatusTransition{From: product.Status, To: status}
+	}
+	if status == product.Status {
+		return nil
+	}
+
+	switch status {
+	case models.ProductStatusActive:
+		product.PublishAt = nil
+	case models.ProductStatusInactive:
+		product.UnpublishAt = nil
+	case models.ProductStatusDiscontinued:
+		product.PublishAt = nil
+		product.UnpublishAt = nil
+	}
+	product.Status = status
+	return nil
+}
+
+// productVisibleTo reports whether a product may be shown to a user on the
+// public routes: active products to everyone, others only to the user who
+// created them
+func productVisibleTo(product models.Product, viewer uuid.UUID) bool {
+	return product.Status == models.ProductStatusActive || (viewer != uuid.Nil && product.CreatedBy == viewer)
+}
+
+// productStatusCondition returns the condition of a status filter for
+// viewer. Anonymous users only see active products. Logged-in users may
+// filter by any status, or send an empty status for all of them, but only
+// see the inactive products they created.
+func productStatusCondition(status string, viewer uuid.UUID) (string, []interface{}, error) {
+	switch {
+	case status == models.ProductStatusActive:
+		return "products.status = ?", []interface{}{status}, nil
+	case viewer == uuid.Nil:
+		return "", nil, errors.New("status filters other than active require authentication")
+	case status == "":
+		return "products.status = ? OR products.created_by = ?", []interface{}{models.ProductStatusActive, viewer}, nil
+	case models.ValidProductStatus(status):
+		return "products.status = ? AND products.created_by = ?", []interface{}{status, viewer}, nil
+	}
+	return "", nil, fmt.Errorf("unknown status %q, must be draft, active, inactive or discontinued", status)
+}
+
+// checkProductSchedule validates the publish_at and unpublish_at of a product
+func checkProductSchedule(product *models.Product) error {
+	// Activating clears publish_at, so an active product with a future one
+	// means the request asked for both
+	if product.Status == models.ProductStatusActive && product.PublishAt != nil && product.PublishAt.After(time.Now()) {
+		return fmt.Errorf("active products cannot have a future publish_at, use status draft to schedule the product")
+	}
+	if product.Status == models.ProductStatusDiscontinued && (product.PublishAt != nil || product.UnpublishAt != nil) {
+		return fmt.Errorf("discontinued products cannot be scheduled")
+	}
+	if product.PublishAt != nil && product.UnpublishAt != nil && !product.UnpublishAt.After(*product.PublishAt) {
+		return fmt.Errorf("unpublish_at must be after publish_at")
+	}
+	return nil
+}
+
+// respondStatusTransitionError reports a rejected status change with the
+// statuses the product may move to instead
+func respondStatusTransitionError(c *gin.Context, err errProductStatusTransition) {
+	c.JSON(http.StatusConflict, gin.H{
+		"error":   "Invalid status transition",
+		"details": err.Error(),
+		"allowed": models.ProductStatusTransitions(err.From),
+	})
+}
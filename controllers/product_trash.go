@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"backend/config"
+	"backend/jobs"
+	"backend/models"
+	"backend/search"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// trashOrder lists deleted products, most recently deleted first
+var trashOrder = keysetOrder{column: "deleted_at", idColumn: "id", desc: true, parse: parseTimeCursor}
+
+// GetProductTrash lists deleted products that can still be restored, with
+// cursor pagination
+func GetProductTrash(c *gin.Context) {
+	limit := pageSize(c, "limit")
+	cursor, err := parseCursor(c, "deleted")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid cursor",
+		})
+		return
+	}
+
+	query := config.DB.Unscoped().Preload("CategoryRef").Preload("BrandRef").Preload("TaxClassRef").Where("deleted_at IS NOT NULL")
+	query, err = trashOrder.apply(query, cursor, limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid cursor",
+		})
+		return
+	}
+
+	var products []models.Product
+	if err := query.Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve trash",
+		})
+		return
+	}
+	products, next, prev := keysetPage(products, limit, cursor, "deleted", func(product models.Product) (string, uint) {
+		return product.DeletedAt.Time.Format(time.RFC3339Nano), product.ID
+	})
+
+	retention := jobs.TrashRetention()
+	responses := []models.TrashedProductResponse{}
+	for _, product := range products {
+		responses = append(responses, models.TrashedProductResponse{
+			ProductResponse: toProductResponse(product),
+			DeletedAt:       product.DeletedAt.Time,
+			PurgeAt:         product.DeletedAt.Time.Add(retention),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Trash retrieved successfully",
+		"data":        responses,
+		"limit":       limit,
+		"retention":   retention.String(),
+		"next_cursor": next,
+		"prev_cursor": prev,
+		"has_next":    next != "",
+		"has_prev":    prev != "",
+	})
+}
+
+// RestoreProduct takes a product out of the trash
+func RestoreProduct(c *gin.Context) {
+	product, ok := findTrashedProduct(c)
+	if !ok {
+		return
+	}
+
+	before := product
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&product).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return models.RecordProductRevision(tx, models.RevisionRestore, &before, product, currentUserID(c))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to restore product",
+			"details": err.Error(),
+		})
+		return
+	}
+	search.Sync(product.ID)
+
+	product.DeletedAt = gorm.DeletedAt{}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Product restored successfully",
+		"data":    toProductResponse(product),
+	})
+}
+
+// PurgeProduct permanently deletes a product from the trash, along with its
+// variants, history and image files
+func PurgeProduct(c *gin.Context) {
+	product, ok := findTrashedProduct(c)
+	if !ok {
+		return
+	}
+
+	if err := jobs.PurgeProduct(c.Request.Context(), product); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to purge product",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Product purged successfully",
+	})
+}
+
+// findTrashedProduct loads the deleted product of the :id parameter,
+// responding with an error if it is not in the trash
+func findTrashedProduct(c *gin.Context) (models.Product, bool) {
+	var product models.Product
+
+	productUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID",
+		})
+		return product, false
+	}
+
+	if err := config.DB.Unscoped().Preload("CategoryRef").Preload("BrandRef").Preload("TaxClassRef").Where("uuid = ?", productUUID).First(&product).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Product not found",
+		})
+		return product, false
+	}
+	if !product.DeletedAt.Valid {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Product is not in the trash",
+		})
+		return product, false
+	}
+	return product, true
+}
@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	"backend/config"
+	"backend/inventory"
+	"backend/models"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreateStockReservation holds available stock of a product or variant for
+// a limited time, e.g. during checkout
+func CreateStockReservation(c *gin.Context) {
+	var request models.StockReservationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var product models.Product
+	if err := config.DB.Where("uuid = ?", request.ProductID).First(&product).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Product not found",
+		})
+		return
+	}
+	hold := inventory.Hold{
+		ProductID: product.ID,
+		Quantity:  request.Quantity,
+		TTL:       inventory.ReservationTTL(),
+		Reference: request.Reference,
+		ActorID:   currentUserID(c),
+	}
+	if request.TTL > 0 {
+		hold.TTL = time.Duration(request.TTL) * time.Second
+	}
+	if request.VariantID != "" {
+		var variant models.ProductVariant
+		if err := config.DB.Where("uuid = ? AND product_id = ?", request.VariantID, product.ID).First(&variant).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Variant not found",
+			})
+			return
+		}
+		hold.VariantID = &variant.ID
+	}
+	if request.Warehouse != "" {
+		warehouse, ok := resolveWarehouse(c, request.Warehouse)
+		if !ok {
+			return
+		}
+		hold.WarehouseID = warehouse.ID
+	}
+
+	var reservation *models.StockReservation
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		reservation, err = inventory.Reserve(tx, hold)
+		return err
+	})
+	if !handleStockMoveError(c, err) {
+		return
+	}
+
+	response, ok := loadReservationResponse(c, reservation.ID)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Stock reserved successfully",
+		"data":    response,
+	})
+}
+
+// GetStockReservation retrieves a reservation
+func GetStockReservation(c *gin.Context) {
+	reservation, ok := findReservation(c)
+	if !ok {
+		return
+	}
+
+	response, ok := loadReservationResponse(c, reservation.ID)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Reservation retrieved successfully",
+		"data":    response,
+	})
+}
+
+// ConfirmStockReservation sells the stock held by an active reservation
+func ConfirmStockReservation(c *gin.Context) {
+	reservation, ok := findReservation(c)
+	if !ok {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		_, err := inventory.Confirm(tx, reservation.ID, currentUserID(c))
+		return err
+	})
+	if !handleReservationError(c, err) {
+		return
+	}
+
+	response, ok := loadReservationResponse(c, reservation.ID)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Reservation confirmed successfully",
+		"data":    response,
+	})
+}
+
+// ReleaseStockReservation gives the stock held by an active reservation back
+func ReleaseStockReservation(c *gin.Context) {
+	reservation, ok := findReservation(c)
+	if !ok {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		_, err := inventory.Release(tx, reservation.ID, models.ReservationReleased)
+		return err
+	})
+	if !handleReservationError(c, err) {
+		return
+	}
+
+	response, ok := loadReservationResponse(c, reservation.ID)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Reservation released successfully",
+		"data":    response,
+	})
+}
+
+// handleReservationError writes the error response for a failed
+// confirmation or release and reports whether it succeeded
+func handleReservationError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, inventory.ErrReservationClosed):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Reservation is not active",
+			"details": err.Error(),
+		})
+	case errors.Is(err, inventory.ErrReservationExpired):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Reservation has expired",
+			"details": "reserve the stock again",
+		})
+	default:
+		return handleStockMoveError(c, err)
+	}
+	return false
+}
+
+// findReservation loads the reservation of the :id parameter and writes the
+// error response when it doesn't exist
+func findReservation(c *gin.Context) (*models.StockReservation, bool) {
+	reservationUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid reservation ID",
+		})
+		return nil, false
+	}
+
+	var reservation models.StockReservation
+	if err := config.DB.Where("uuid = ?", reservationUUID).First(&reservation).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Reservation not found",
+		})
+		return nil, false
+	}
+	return &reservation, true
+}
+
+// loadReservationResponse reloads a reservation with its product, variant,
+// warehouse and movement
+func loadReservationResponse(c *gin.Context, id uint) (models.StockReservationResponse, bool) {
+	var reservation models.StockReservation
+	if err := config.DB.Preload("Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Variant").Preload("Warehouse").Preload("Movement").First(&reservation, id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve reservation",
+			"details": err.Error(),
+		})
+		return models.StockReservationResponse{}, false
+	}
+
+	response := models.StockReservationResponse{
+		ID:        reservation.Uuid,
+		Quantity:  reservation.Quantity,
+		Status:    reservation.Status,
+		ExpiresAt: reservation.ExpiresAt,
+		Reference: reservation.Reference,
+		CreatedAt: reservation.CreatedAt,
+		UpdatedAt: reservation.UpdatedAt,
+	}
+	if reservation.Product != nil {
+		response.ProductID = reservation.Product.Uuid
+	}
+	if reservation.Variant != nil {
+		response.VariantID = &reservation.Variant.Uuid
+	}
+	if reservation.Warehouse != nil {
+		response.Warehouse = reservation.Warehouse.Info()
+	}
+	if reservation.Movement != nil {
+		response.MovementID = &reservation.Movement.Uuid
+	}
+	return response, true
+}
@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"backend/config"
+	"backend/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// lowStockRow is a product at or below its reorder threshold, with the
+// stock of its variants included
+type lowStockRow struct {
+	ID        uint
+	Uuid      uuid.UUID
+	Name      string
+	SKU       string `gorm:"column:sku"`
+	Stock     int
+	Threshold int
+	Shortfall int
+}
+
+// GetLowStockProducts lists the products whose stock, including their
+// variants, is at or below their reorder threshold, the largest shortfall
+// first, with cursor pagination
+func GetLowStockProducts(c *gin.Context) {
+	variantStock := config.DB.Model(&models.ProductVariant{}).
+		Select("product_id, SUM(stock) AS stock").Group("product_id")
+	lowStock := config.DB.Table("products AS p").
+		Select("p.id, p.uuid, p.name, p.sku, p.stock + COALESCE(v.stock, 0) AS stock, p.reorder_threshold AS threshold, "+
+			"p.reorder_threshold - p.stock - COALESCE(v.stock, 0) AS shortfall").
+		Joins("LEFT JOIN (?) AS v ON v.product_id = p.id", variantStock).
+		Where("p.deleted_at IS NULL AND p.reorder_threshold IS NOT NULL AND p.stock + COALESCE(v.stock, 0) <= p.reorder_threshold")
+	query := config.DB.Table("(?) AS low", lowStock)
+
+	limit := pageSize(c, "limit")
+	cursor, err := parseCursor(c, "shortfall")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid cursor",
+		})
+		return
+	}
+	order := keysetOrder{column: "shortfall", idColumn: "id", desc: true, parse: func(value string) (interface{}, error) {
+		return strconv.Atoi(value)
+	}}
+	query, err = order.apply(query, cursor, limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid cursor",
+		})
+		return
+	}
+
+	var rows []lowStockRow
+	if err := query.Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retrieve low-stock products",
+			"details": err.Error(),
+		})
+		return
+	}
+	rows, next, prev := keysetPage(rows, limit, cursor, "shortfall", func(row lowStockRow) (string, uint) {
+		return strconv.Itoa(row.Shortfall), row.ID
+	})
+
+	ids := make([]uint, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	var alerts []models.StockAlert
+	config.DB.Where("product_id IN ? AND resolved_at IS NULL", ids).Find(&alerts)
+	openAlerts := make(map[uint]models.StockAlert, len(alerts))
+	for _, alert := range alerts {
+		openAlerts[alert.ProductID] = alert
+	}
+
+	responses := []models.LowStockProduct{}
+	for _, row := range rows {
+		response := models.LowStockProduct{
+			ProductID: row.Uuid,
+			Name:      row.Name,
+			SKU:       row.SKU,
+			Stock:     row.Stock,
+			Threshold: row.Threshold,
+			Shortfall: row.Shortfall,
+		}
+		if alert, ok := openAlerts[row.ID]; ok {
+			response.AlertID = &alert.Uuid
+			response.AlertSince = &alert.CreatedAt
+		}
+		responses = append(responses, response)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Low-stock products retrieved successfully",
+		"data":        responses,
+		"limit":       limit,
+		"next_cursor": next,
+		"prev_cursor": prev,
+		"has_next":    next != "",
+		"has_prev":    prev != "",
+	})
+}
+
+// GetStockAlerts lists stock alerts with the delivery state of their
+// notifications, newest first, with cursor pagination. Filter with
+// product_id, and with open=true for unresolved alerts.
+func GetStockAlerts(c *gin.Context) {
+	query := config.DB.Preload("Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() })
+	if productID := c.Query("product_id"); productID != "" {
+		query = query.Where("product_id IN (?)", config.DB.Unscoped().Model(&models.Product{}).Select("id").Where("uuid = ?", productID))
+	}
+	if c.Query("open") == "true" {
+		query = query.Where("resolved_at IS NULL")
+	}
+
+	limit := pageSize(c, "limit")
+	cursor, err := parseCursor(c, "newest")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid cursor",
+		})
+		return
+	}
+	query, err = keysetOrder{idColumn: "id", desc: true}.apply(query, cursor, limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid cursor",
+		})
+		return
+	}
+
+	var alerts []models.StockAlert
+	if err := query.Find(&alerts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve stock alerts",
+		})
+		return
+	}
+	alerts, next, prev := keysetPage(alerts, limit, cursor, "newest", func(alert models.StockAlert) (string, uint) {
+		return "", alert.ID
+	})
+
+	references := make([]string, len(alerts))
+	for i, alert := range alerts {
+		references[i] = alert.Uuid.String()
+	}
+	var notifications []models.Notification
+	config.DB.Where("reference IN ?", references).Order("id ASC").Find(&notifications)
+	byReference := make(map[string][]models.NotificationResponse)
+	for _, notification := range notifications {
+		byReference[notification.Reference] = append(byReference[notification.Reference], models.NotificationResponse{
+			Channel:   notification.Channel,
+			Recipient: notification.Recipient,
+			Status:    notification.Status,
+			Attempts:  notification.Attempts,
+			LastError: notification.LastError,
+			SentAt:    notification.SentAt,
+		})
+	}
+
+	responses := []models.StockAlertResponse{}
+	for _, alert := range alerts {
+		response := models.StockAlertResponse{
+			ID:            alert.Uuid,
+			Stock:         alert.Stock,
+			Threshold:     alert.Threshold,
+			ResolvedAt:    alert.ResolvedAt,
+			CreatedAt:     alert.CreatedAt,
+			Notifications: byReference[alert.Uuid.String()],
+		}
+		if response.Notifications == nil {
+			response.Notifications = []models.NotificationResponse{}
+		}
+		if alert.Product != nil {
+			response.ProductID = alert.Product.Uuid
+			response.Name = alert.Product.Name
+			response.SKU = alert.Product.SKU
+		}
+		responses = append(responses, response)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Stock alerts retrieved successfully",
+		"data":        responses,
+		"limit":       limit,
+		"next_cursor": next,
+		"prev_cursor": prev,
+		"has_next":    next != "",
+		"has_prev":    prev != "",
+	})
+}
@@ -0,0 +1,458 @@
+package controllers
+
+import (
+	"backend/config"
+	"backend/models"
+	"backend/tax"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GetTaxClasses retrieves all tax classes with their rates
+func GetTaxClasses(c *gin.Context) {
+	var classes []models.TaxClass
+	if err := config.DB.Order("code ASC").Find(&classes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve tax classes",
+		})
+		return
+	}
+
+	var rates []models.TaxRate
+	if err := config.DB.Order("region ASC, effective_from DESC").Find(&rates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve tax rates",
+		})
+		return
+	}
+	ratesByClass := make(map[uint][]models.TaxRate)
+	for _, rate := range rates {
+		ratesByClass[rate.TaxClassID] = append(ratesByClass[rate.TaxClassID], toTaxRateResponse(rate))
+	}
+
+	responses := make([]models.TaxClassResponse, 0, len(classes))
+	for _, class := range classes {
+		responses = append(responses, toTaxClassResponse(class, ratesByClass[class.ID]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Tax classes retrieved successfully",
+		"data":           responses,
+		"price_mode":     tax.PriceMode(),
+		"default_class":  tax.DefaultClass(),
+		"default_region": tax.DefaultRegion(),
+	})
+}
+
+// CreateTaxClass creates a tax class
+func CreateTaxClass(c *gin.Context) {
+	var request models.TaxClassRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	class := models.TaxClass{
+		Uuid:        uuid.New(),
+		Code:        normalizeTaxClassCode(request.Code),
+		Name:        strings.TrimSpace(request.Name),
+		Description: request.Description,
+	}
+	if taxClassCodeTaken(class.Code, 0) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Tax class code already exists",
+		})
+		return
+	}
+
+	if err := config.DB.Create(&class).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to create tax class",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Tax class created successfully",
+		"data":    toTaxClassResponse(class, nil),
+	})
+}
+
+// UpdateTaxClass replaces the code, name and description of a tax class
+func UpdateTaxClass(c *gin.Context) {
+	class, ok := findTaxClass(c)
+	if !ok {
+		return
+	}
+
+	var request models.TaxClassRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	class.Code = normalizeTaxClassCode(request.Code)
+	class.Name = strings.TrimSpace(request.Name)
+	class.Description = request.Description
+	if taxClassCodeTaken(class.Code, class.ID) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Tax class code already exists",
+		})
+		return
+	}
+
+	if err := config.DB.Save(class).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update tax class",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var rates []models.TaxRate
+	config.DB.Where("tax_class_id = ?", class.ID).Order("region ASC, effective_from DESC").Find(&rates)
+	for i := range rates {
+		rates[i] = toTaxRateResponse(rates[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tax class updated successfully",
+		"data":    toTaxClassResponse(*class, rates),
+	})
+}
+
+// DeleteTaxClass deletes a tax class with its rates. Classes assigned to
+// products, including products in the trash, cannot be deleted.
+func DeleteTaxClass(c *gin.Context) {
+	class, ok := findTaxClass(c)
+	if !ok {
+		return
+	}
+
+	var count int64
+	config.DB.Unscoped().Model(&models.Product{}).Where("tax_class_id = ?", class.ID).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Tax class is in use",
+			"details": "reassign the products of this tax class first",
+		})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tax_class_id = ?", class.ID).Delete(&models.TaxRate{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(class).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete tax class",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tax class deleted successfully",
+	})
+}
+
+// CreateTaxRate adds a rate to a tax class for a region. A rate with the
+// same region and effective time replaces the stored one.
+func CreateTaxRate(c *gin.Context) {
+	class, ok := findTaxClass(c)
+	if !ok {
+		return
+	}
+
+	var request models.TaxRateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	region, err := tax.NormalizeRegion(request.Region)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+	value, err := tax.ParseRate(request.Rate.String())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+	effectiveFrom := time.Now()
+	if request.EffectiveFrom != nil {
+		effectiveFrom = *request.EffectiveFrom
+	}
+
+	rate := models.TaxRate{TaxClassID: class.ID, Region: region, EffectiveFrom: effectiveFrom.Truncate(time.Second)}
+	status := http.StatusOK
+	err = config.DB.Where("tax_class_id = ? AND region = ? AND effective_from = ?", rate.TaxClassID, rate.Region, rate.EffectiveFrom).First(&rate).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		rate.Uuid = uuid.New()
+		status = http.StatusCreated
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to save tax rate",
+			"details": err.Error(),
+		})
+		return
+	}
+	rate.Name = strings.TrimSpace(request.Name)
+	rate.Rate = tax.FormatRate(value)
+
+	if err := config.DB.Save(&rate).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to save tax rate",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(status, gin.H{
+		"message": "Tax rate saved successfully",
+		"data":    toTaxRateResponse(rate),
+	})
+}
+
+// DeleteTaxRate deletes a rate of a tax class. The previous rate of the
+// region, if any, is in effect again.
+func DeleteTaxRate(c *gin.Context) {
+	class, ok := findTaxClass(c)
+	if !ok {
+		return
+	}
+
+	rateUUID, err := uuid.Parse(c.Param("rate_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid tax rate ID",
+		})
+		return
+	}
+
+	result := config.DB.Where("uuid = ? AND tax_class_id = ?", rateUUID, class.ID).Delete(&models.TaxRate{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete tax rate",
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Tax rate not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tax rate deleted successfully",
+	})
+}
+
+// CalculateTax returns the net, tax and gross amounts of product lines at
+// their current prices, with totals per currency
+func CalculateTax(c *gin.Context) {
+	var request models.TaxCalculationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	region := tax.DefaultRegion()
+	if request.Region != "" {
+		var err error
+		if region, err = tax.NormalizeRegion(request.Region); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request data",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	now := time.Now()
+	calculator := tax.NewCalculator(config.DB, region, now)
+	lines := make([]models.TaxCalculationLine, 0, len(request.Items))
+	totals := make(map[string]*models.TaxTotal)
+	var currencies []string
+	for _, item := range request.Items {
+		var product models.Product
+		if err := config.DB.Where("uuid = ?", item.ProductID).First(&product).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Product not found",
+				"details": item.ProductID,
+			})
+			return
+		}
+
+		quantity := item.Quantity
+		if quantity == 0 {
+			quantity = 1
+		}
+		unitPrice := product.CurrentPrice(now)
+		linePrice := models.Money{Amount: unitPrice.Amount * int64(quantity), Currency: unitPrice.Currency}
+
+		breakdown, err := calculator.Calculate(linePrice, product.TaxClassID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to calculate tax",
+				"details": err.Error(),
+			})
+			return
+		}
+		lines = append(lines, models.TaxCalculationLine{
+			ProductID: product.Uuid,
+			Quantity:  quantity,
+			UnitPrice: unitPrice,
+			Tax:       breakdown,
+		})
+
+		total, ok := totals[linePrice.Currency]
+		if !ok {
+			zero := models.Money{Currency: linePrice.Currency}
+			total = &models.TaxTotal{Net: zero, Tax: zero, Gross: zero}
+			totals[linePrice.Currency] = total
+			currencies = append(currencies, linePrice.Currency)
+		}
+		total.Net.Amount += breakdown.Net.Amount
+		total.Tax.Amount += breakdown.Tax.Amount
+		total.Gross.Amount += breakdown.Gross.Amount
+	}
+
+	totalList := make([]models.TaxTotal, 0, len(currencies))
+	for _, currency := range currencies {
+		totalList = append(totalList, *totals[currency])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tax calculated successfully",
+		"data": gin.H{
+			"region":     region,
+			"price_mode": tax.PriceMode(),
+			"lines":      lines,
+			"totals":     totalList,
+		},
+	})
+}
+
+// taxCalculator returns a calculator for the region query parameter, or the
+// default region, and writes the error response itself when the region is
+// invalid
+func taxCalculator(c *gin.Context) (*tax.Calculator, bool) {
+	region := tax.DefaultRegion()
+	if value := c.Query("region"); value != "" {
+		var err error
+		if region, err = tax.NormalizeRegion(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid query parameters",
+				"details": err.Error(),
+			})
+			return nil, false
+		}
+	}
+	return tax.NewCalculator(config.DB, region, time.Now()), true
+}
+
+// addProductTax sets the tax breakdown of the current price of a product
+// response and writes the error response itself when it fails
+func addProductTax(c *gin.Context, calculator *tax.Calculator, product models.Product, response *models.ProductResponse) bool {
+	breakdown, err := calculator.Calculate(response.CurrentPrice, product.TaxClassID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to calculate tax",
+			"details": err.Error(),
+		})
+		return false
+	}
+	response.Tax = &breakdown
+	return true
+}
+
+// resolveTaxClass finds a tax class by code
+func resolveTaxClass(code string) (*models.TaxClass, error) {
+	var class models.TaxClass
+	if err := config.DB.Where("code = ?", normalizeTaxClassCode(code)).First(&class).Error; err != nil {
+		return nil, err
+	}
+	return &class, nil
+}
+
+// findTaxClass loads the tax class named by the :id URL parameter, a UUID
+// or code, and writes the error response itself when it cannot
+func findTaxClass(c *gin.Context) (*models.TaxClass, bool) {
+	var class models.TaxClass
+	query := config.DB.Where("code = ?", normalizeTaxClassCode(c.Param("id")))
+	if classUUID, err := uuid.Parse(c.Param("id")); err == nil {
+		query = config.DB.Where("uuid = ?", classUUID)
+	}
+	if err := query.First(&class).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Tax class not found",
+		})
+		return nil, false
+	}
+	return &class, true
+}
+
+// normalizeTaxClassCode makes tax class codes case-insensitive
+func normalizeTaxClassCode(code string) string {
+	return strings.ToLower(strings.TrimSpace(code))
+}
+
+// taxClassCodeTaken reports whether another tax class has the code
+func taxClassCodeTaken(code string, exceptID uint) bool {
+	var count int64
+	config.DB.Model(&models.TaxClass{}).Where("code = ? AND id <> ?", code, exceptID).Count(&count)
+	return count > 0
+}
+
+// toTaxRateResponse formats the stored decimal without trailing zeros
+func toTaxRateResponse(rate models.TaxRate) models.TaxRate {
+	if value, err := tax.ParseRate(rate.Rate); err == nil {
+		rate.Rate = tax.FormatRate(value)
+	}
+	return rate
+}
+
+func toTaxClassResponse(class models.TaxClass, rates []models.TaxRate) models.TaxClassResponse {
+	if rates == nil {
+		rates = []models.TaxRate{}
+	}
+	return models.TaxClassResponse{
+		ID:          class.Uuid,
+		Code:        class.Code,
+		Name:        class.Name,
+		Description: class.Description,
+		Rates:       rates,
+		CreatedAt:   class.CreatedAt,
+		UpdatedAt:   class.UpdatedAt,
+	}
+}
@@ -0,0 +1,462 @@
+package controllers
+
+import (
+	"backend/config"
+	"backend/middlewares"
+	"backend/models"
+	"backend/storage"
+	"backend/utils"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// ResumableUploadTTL is how long an upload session survives without
+	// receiving data; every chunk extends it
+	ResumableUploadTTL = 24 * time.Hour
+
+	// MaxResumableChunkSize caps how much of a single PATCH body is accepted.
+	// Clients resume from the returned Upload-Offset.
+	MaxResumableChunkSize = 8 << 20 // 8MB
+)
+
+var (
+	errUploadOffsetMismatch = errors.New("upload offset mismatch")
+	errUploadNotClaimable   = errors.New("upload is not ready to finish")
+)
+
+// ResumableUploadOptions advertises the supported tus protocol features
+func ResumableUploadOptions(c *gin.Context) {
+	c.Header("Tus-Version", middlewares.TusVersion)
+	c.Header("Tus-Extension", "creation,expiration,termination")
+	c.Header("Tus-Max-Size", strconv.FormatInt(utils.MaxDirectUploadSize, 10))
+	c.Status(http.StatusNoContent)
+}
+
+// CreateResumableUpload starts a tus upload session for a product image. The
+// product, filename and visibility are passed in Upload-Metadata.
+func CreateResumableUpload(c *gin.Context) {
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Missing or invalid Upload-Length header",
+		})
+		return
+	}
+	if length > utils.MaxDirectUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": "file size exceeds maximum limit of 50MB",
+		})
+		return
+	}
+
+	metadata, err := parseTusMetadata(c.GetHeader("Upload-Metadata"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid Upload-Metadata header",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	productUUID, err := uuid.Parse(metadata["product_id"])
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid product ID",
+		})
+		return
+	}
+
+	var product models.Product
+	if err := config.DB.Where("uuid = ?", productUUID).First(&product).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Product not found",
+		})
+		return
+	}
+
+	if err := utils.ValidateImageFilename(metadata["filename"]); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	sessionUUID := uuid.New()
+	session := models.UploadSession{
+		Uuid:        sessionUUID,
+		ProductID:   product.ID,
+		Filename:    metadata["filename"],
+		Private:     metadata["visibility"] == "private",
+		Length:      length,
+		Status:      models.UploadSessionPending,
+		ExpiresAt:   time.Now().Add(ResumableUploadTTL),
+		CreatedBy:   currentUserID(c),
+		ChunkPrefix: fmt.Sprintf("%s/%s", utils.ResumableUploadPrefix, sessionUUID),
+	}
+
+	if err := config.DB.Create(&session).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to create upload session",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("%s/tus/uploads/%s", config.AppBaseURL, session.Uuid))
+	c.Header("Upload-Expires", session.ExpiresAt.UTC().Format(http.TimeFormat))
+	c.Status(http.StatusCreated)
+}
+
+// GetResumableUploadOffset reports how many bytes of an upload were received
+func GetResumableUploadOffset(c *gin.Context) {
+	session, ok := findUploadSession(c)
+	if !ok {
+		return
+	}
+
+	if session.Status == models.UploadSessionExpired || session.Status == models.UploadSessionCancelled {
+		c.Status(http.StatusGone)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(session.Length, 10))
+	c.Header("Upload-Expires", session.ExpiresAt.UTC().Format(http.TimeFormat))
+	c.Status(http.StatusOK)
+}
+
+// PatchResumableUpload appends a chunk at Upload-Offset. Once every byte has
+// arrived the chunks are assembled and go through the regular image
+// validation and storage path before being attached to the product.
+func PatchResumableUpload(c *gin.Context) {
+	if c.ContentType() != "application/offset+octet-stream" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error": "Content-Type must be application/offset+octet-stream",
+		})
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Missing or invalid Upload-Offset header",
+		})
+		return
+	}
+
+	session, ok := findUploadSession(c)
+	if !ok {
+		return
+	}
+
+	if !uploadSessionWritable(c, session) {
+		return
+	}
+	if offset != session.Offset {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Upload-Offset does not match the current offset",
+		})
+		return
+	}
+
+	// Read the chunk before touching the database. A client that drops the
+	// connection mid-chunk keeps whatever arrived and resumes from there.
+	limit := session.Length - session.Offset
+	if limit > MaxResumableChunkSize {
+		limit = MaxResumableChunkSize
+	}
+	chunk, readErr := io.ReadAll(io.LimitReader(c.Request.Body, limit))
+
+	if len(chunk) > 0 {
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			// Lock the session so concurrent PATCHes cannot interleave chunks
+			var locked models.UploadSession
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, session.ID).Error; err != nil {
+				return err
+			}
+			if locked.Status != models.UploadSessionPending || locked.Offset != offset {
+				return errUploadOffsetMismatch
+			}
+
+			key := utils.UploadChunkKey(&locked, locked.ChunkCount)
+			if err := storage.Default.Put(c.Request.Context(), key, bytes.NewReader(chunk), int64(len(chunk)), "application/octet-stream"); err != nil {
+				return err
+			}
+
+			locked.Offset += int64(len(chunk))
+			locked.ChunkCount++
+			locked.ExpiresAt = time.Now().Add(ResumableUploadTTL)
+			if err := tx.Save(&locked).Error; err != nil {
+				return err
+			}
+
+			*session = locked
+			return nil
+		})
+		if errors.Is(err, errUploadOffsetMismatch) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Upload-Offset does not match the current offset",
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to store chunk",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	if readErr == nil && session.Offset == session.Length {
+		if !finishResumableUpload(c, session) {
+			return
+		}
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	c.Header("Upload-Expires", session.ExpiresAt.UTC().Format(http.TimeFormat))
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteResumableUpload cancels an unfinished upload and discards its chunks
+func DeleteResumableUpload(c *gin.Context) {
+	session, ok := findUploadSession(c)
+	if !ok {
+		return
+	}
+
+	if session.Status != models.UploadSessionPending {
+		c.Status(http.StatusGone)
+		return
+	}
+
+	result := config.DB.Model(&models.UploadSession{}).
+		Where("id = ? AND status = ?", session.ID, models.UploadSessionPending).
+		Update("status", models.UploadSessionCancelled)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to cancel upload",
+		})
+		return
+	}
+	if result.RowsAffected == 1 {
+		utils.DeleteUploadChunks(c.Request.Context(), session)
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetResumableUpload returns the session as JSON, including the outcome of
+// the final processing step
+func GetResumableUpload(c *gin.Context) {
+	session, ok := findUploadSession(c)
+	if !ok {
+		return
+	}
+
+	response := models.UploadSessionResponse{
+		ID:        session.Uuid,
+		Filename:  session.Filename,
+		Length:    session.Length,
+		Offset:    session.Offset,
+		Status:    session.Status,
+		Error:     session.Error,
+		ExpiresAt: session.ExpiresAt,
+	}
+
+	var product models.Product
+	if err := config.DB.Unscoped().First(&product, session.ProductID).Error; err == nil {
+		response.ProductID = product.Uuid
+		if session.Status == models.UploadSessionCompleted && !session.Private {
+			response.ImageURL = storage.Default.PublicURL(session.ImagePath)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Upload retrieved successfully",
+		"data":    response,
+	})
+}
+
+// finishResumableUpload assembles the chunks and attaches the image. It
+// writes the error response itself and returns false on failure.
+func finishResumableUpload(c *gin.Context, session *models.UploadSession) bool {
+	ctx := c.Request.Context()
+
+	if !claimUploadSession(c, session) {
+		return false
+	}
+
+	fail := func(status int, message string) bool {
+		session.Status = models.UploadSessionFailed
+		session.Error = message
+		if err := config.DB.Save(session).Error; err != nil {
+			log.Printf("failed to mark upload session %s as failed: %v", session.Uuid, err)
+		}
+		utils.DeleteUploadChunks(ctx, session)
+		c.JSON(status, gin.H{
+			"error": message,
+		})
+		return false
+	}
+
+	var product models.Product
+	if err := config.DB.First(&product, session.ProductID).Error; err != nil {
+		return fail(http.StatusNotFound, "Product not found")
+	}
+
+	reader := utils.OpenUploadChunks(ctx, session)
+	imageResponse, err := utils.StoreImage(ctx, reader, utils.MaxDirectUploadSize, session.Private)
+	reader.Close()
+	if err != nil {
+		return fail(http.StatusBadRequest, err.Error())
+	}
+
+	if err := attachProductImage(&product, imageResponse, session.Private, currentUserID(c)); err != nil {
+		return fail(http.StatusInternalServerError, "Failed to update product with image info")
+	}
+
+	session.Status = models.UploadSessionCompleted
+	session.ImagePath = imageResponse.ImagePath
+	if err := config.DB.Save(session).Error; err != nil {
+		log.Printf("failed to mark upload session %s as completed: %v", session.Uuid, err)
+	}
+	utils.DeleteUploadChunks(ctx, session)
+
+	return true
+}
+
+// claimUploadSession moves a fully received session to processing. The
+// status is checked and changed under the row lock, so of two concurrent
+// final PATCHes only one goes on to store the image; the other gets the
+// response for the session's new state.
+func claimUploadSession(c *gin.Context, session *models.UploadSession) bool {
+	var locked models.UploadSession
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, session.ID).Error; err != nil {
+			return err
+		}
+		if locked.Status != models.UploadSessionPending || locked.Offset != locked.Length {
+			return errUploadNotClaimable
+		}
+
+		locked.Status = models.UploadSessionProcessing
+		return tx.Save(&locked).Error
+	})
+	if errors.Is(err, errUploadNotClaimable) {
+		if uploadSessionWritable(c, &locked) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "Upload-Offset does not match the current offset",
+			})
+		}
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to finish upload",
+			"details": err.Error(),
+		})
+		return false
+	}
+
+	*session = locked
+	return true
+}
+
+// findUploadSession loads the session named in the URL and checks that it
+// belongs to the requesting user
+func findUploadSession(c *gin.Context) (*models.UploadSession, bool) {
+	sessionUUID, err := uuid.Parse(c.Param("upload_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Upload not found",
+		})
+		return nil, false
+	}
+
+	var session models.UploadSession
+	if err := config.DB.Where("uuid = ?", sessionUUID).First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Upload not found",
+		})
+		return nil, false
+	}
+
+	if session.CreatedBy != currentUserID(c) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "You do not have access to this upload",
+		})
+		return nil, false
+	}
+
+	return &session, true
+}
+
+// uploadSessionWritable rejects chunks for sessions that are finished,
+// being processed, cancelled or expired
+func uploadSessionWritable(c *gin.Context, session *models.UploadSession) bool {
+	switch {
+	case session.Status == models.UploadSessionCompleted:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Upload is already complete",
+		})
+		return false
+	case session.Status == models.UploadSessionProcessing:
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Upload is already being processed",
+		})
+		return false
+	case session.Status == models.UploadSessionFailed:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Upload failed",
+			"details": session.Error,
+		})
+		return false
+	case session.Status != models.UploadSessionPending, time.Now().After(session.ExpiresAt):
+		c.JSON(http.StatusGone, gin.H{
+			"error": "Upload has expired or was cancelled",
+		})
+		return false
+	}
+	return true
+}
+
+// parseTusMetadata decodes an Upload-Metadata header: comma separated pairs
+// of a key and a base64 encoded value
+func parseTusMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, fmt.Errorf("empty metadata key")
+		}
+
+		value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("metadata %q is not valid base64", key)
+		}
+		metadata[key] = string(value)
+	}
+
+	return metadata, nil
+}
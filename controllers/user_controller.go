@@ -32,53 +32,127 @@ func GetProfile(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"id":    user.ID,
-		"name":  user.Name,
-		"email": user.Email,
+		"id":             user.ID,
+		"name":           user.Name,
+		"email":          user.Email,
+		"customer_group": user.CustomerGroup,
+	})
+}
+
+// SetCustomerGroup memasukkan user ke customer group (misal: wholesale) yang
+// menentukan harga price list-nya. customer_group kosong mengeluarkan user
+// dari group-nya.
+func SetCustomerGroup(c *gin.Context) {
+	var userID uint
+	if _, err := fmt.Sscanf(c.Param("user_id"), "%d", &userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id tidak valid"})
+		return
+	}
+
+	var request models.CustomerGroupRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid", "details": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User tidak ditemukan"})
+		return
+	}
+
+	group := normalizeCustomerGroup(request.CustomerGroup)
+	if err := config.DB.Model(&user).Update("customer_group", group).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal menyimpan customer group"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":             user.ID,
+		"name":           user.Name,
+		"email":          user.Email,
+		"customer_group": group,
 	})
 }
 
 func GetAllUsers(c *gin.Context) {
-	// Ambil query parameter page dan pageSize, default: page=1, pageSize=10
-	page := 1
-	pageSize := 10
+	// Ukuran halaman dari limit atau pageSize, default 10, maksimal MaxPageSize
+	limit := pageSize(c, "limit", "pageSize")
+
+	var users []models.User
+	var total int64
+
+	// Mode offset lama tetap didukung bila parameter page dikirim
+	page := 0
 	if p := c.Query("page"); p != "" {
 		fmt.Sscanf(p, "%d", &page)
 	}
-	if ps := c.Query("pageSize"); ps != "" {
-		fmt.Sscanf(ps, "%d", &pageSize)
+	if page > 0 && c.Query("cursor") == "" {
+		offset := (page - 1) * limit
+		if err := config.DB.Order("id ASC").Limit(limit).Offset(offset).Find(&users).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data user"})
+			return
+		}
+
+		config.DB.Model(&models.User{}).Count(&total)
+
+		c.JSON(http.StatusOK, gin.H{
+			"data":       userListResult(users),
+			"page":       page,
+			"pageSize":   limit,
+			"total":      total,
+			"totalPages": (total + int64(limit) - 1) / int64(limit),
+		})
+		return
 	}
-	if page < 1 {
-		page = 1
+
+	// Pagination dengan cursor (keyset) berdasarkan id
+	cursor, err := parseCursor(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cursor tidak valid"})
+		return
 	}
-	if pageSize < 1 {
-		pageSize = 10
+	query, err := keysetOrder{idColumn: "id"}.apply(config.DB, cursor, limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cursor tidak valid"})
+		return
 	}
-
-	var users []models.User
-	offset := (page - 1) * pageSize
-	if err := config.DB.Limit(pageSize).Offset(offset).Find(&users).Error; err != nil {
+	if err := query.Find(&users).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal mengambil data user"})
 		return
 	}
 
-	var total int64
-	config.DB.Model(&models.User{}).Count(&total)
+	users, next, prev := keysetPage(users, limit, cursor, "id", func(user models.User) (string, uint) {
+		return "", user.ID
+	})
+
+	result := gin.H{
+		"data":       userListResult(users),
+		"pageSize":   limit,
+		"nextCursor": next,
+		"prevCursor": prev,
+		"hasNext":    next != "",
+		"hasPrev":    prev != "",
+	}
+
+	// Total hanya dihitung bila diminta
+	if c.Query("includeTotal") == "true" {
+		config.DB.Model(&models.User{}).Count(&total)
+		result["total"] = total
+	}
 
-	var result []gin.H
+	c.JSON(http.StatusOK, result)
+}
+
+func userListResult(users []models.User) []gin.H {
+	result := []gin.H{}
 	for _, user := range users {
 		result = append(result, gin.H{
-			"uuid":  user.Uuid,
-			"name":  user.Name,
-			"email": user.Email,
+			"uuid":           user.Uuid,
+			"name":           user.Name,
+			"email":          user.Email,
+			"customer_group": user.CustomerGroup,
 		})
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"data":       result,
-		"page":       page,
-		"pageSize":   pageSize,
-		"total":      total,
-		"totalPages": (total + int64(pageSize) - 1) / int64(pageSize),
-	})
+	return result
 }
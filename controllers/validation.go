@@ -0,0 +1,22 @@
+package controllers
+
+import (
+	"backend/models"
+	"reflect"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// Binding rules on Money fields apply to the amount in minor units, so
+	// `binding:"required,min=0"` rejects missing and negative prices
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+			if money, ok := field.Interface().(models.Money); ok {
+				return money.Amount
+			}
+			return nil
+		}, models.Money{})
+	}
+}
@@ -0,0 +1,611 @@
+package controllers
+
+import (
+	"backend/config"
+	"backend/inventory"
+	"backend/models"
+	"backend/search"
+	"backend/utils"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var errDuplicateVariantSKU = errors.New("SKU is already used by another product or variant")
+var errDuplicateVariantOptions = errors.New("a variant with these options already exists")
+
+// GetProductVariants lists the variants and option types of a product
+func GetProductVariants(c *gin.Context) {
+	product, ok := findProduct(c)
+	if !ok {
+		return
+	}
+	if !productVisibleTo(*product, currentUserUUID(c)) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Product not found",
+		})
+		return
+	}
+
+	var variants []models.ProductVariant
+	if err := config.DB.Preload("Options.ProductOption").
+		Where("product_id = ?", product.ID).
+		Order("position ASC, id ASC").
+		Find(&variants).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve variants",
+		})
+		return
+	}
+
+	var options []models.ProductOption
+	if err := config.DB.Preload("Values", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC, id ASC")
+	}).
+		Where("product_id = ?", product.ID).
+		Order("position ASC, id ASC").
+		Find(&options).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve variant options",
+		})
+		return
+	}
+
+	responses := make([]models.ProductVariantResponse, 0, len(variants))
+	for _, variant := range variants {
+		responses = append(responses, toVariantResponse(*product, variant))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Variants retrieved successfully",
+		"data":    responses,
+		"options": options,
+	})
+}
+
+// GetProductVariant retrieves a single variant
+func GetProductVariant(c *gin.Context) {
+	product, variant, ok := findVariant(c)
+	if !ok {
+		return
+	}
+	if !productVisibleTo(*product, currentUserUUID(c)) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Product not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Variant retrieved successfully",
+		"data":    toVariantResponse(*product, *variant),
+	})
+}
+
+// CreateProductVariant adds a variant. Option types and values that don't
+// exist yet on the product are created on the fly.
+func CreateProductVariant(c *gin.Context) {
+	product, ok := findProduct(c)
+	if !ok {
+		return
+	}
+
+	var request models.ProductVariantCreateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	options, err := normaliseVariantOptions(request.Options)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	priceAmount, err := variantPriceAmount(*product, request.Price)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	variant := models.ProductVariant{
+		Uuid:        uuid.New(),
+		ProductID:   product.ID,
+		SKU:         strings.TrimSpace(request.SKU),
+		PriceAmount: priceAmount,
+		OptionKey:   variantOptionKey(options),
+		Status:      "active",
+		Position:    request.Position,
+		CreatedBy:   currentUserID(c),
+	}
+	if request.Status != "" {
+		variant.Status = request.Status
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkVariantUnique(tx, &variant); err != nil {
+			return err
+		}
+
+		values, err := resolveVariantOptions(tx, product.ID, options)
+		if err != nil {
+			return err
+		}
+		variant.Options = values
+
+		if err := tx.Create(&variant).Error; err != nil {
+			return err
+		}
+		if request.Stock > 0 {
+			entry, err := inventory.Move(tx, inventory.Movement{
+				ProductID: product.ID,
+				VariantID: &variant.ID,
+				Type:      models.MovementReceive,
+				Quantity:  request.Stock,
+				Reason:    "initial stock",
+				ActorID:   currentUserID(c),
+			})
+			if err != nil {
+				return err
+			}
+			variant.Stock = entry.StockAfter
+		}
+		return nil
+	})
+	if !handleVariantSaveError(c, err, "Failed to create variant") {
+		return
+	}
+	search.Sync(product.ID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Variant created successfully",
+		"data":    toVariantResponse(*product, variant),
+	})
+}
+
+// UpdateProductVariant updates a variant; all fields are optional
+func UpdateProductVariant(c *gin.Context) {
+	product, variant, ok := findVariant(c)
+	if !ok {
+		return
+	}
+
+	var request models.ProductVariantUpdateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var options map[string]string
+	if len(request.Options) > 0 {
+		normalised, err := normaliseVariantOptions(request.Options)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		options = normalised
+		variant.OptionKey = variantOptionKey(options)
+	}
+
+	// Update fields if provided
+	if request.SKU != "" {
+		variant.SKU = strings.TrimSpace(request.SKU)
+	}
+	if request.ResetPrice {
+		variant.PriceAmount = nil
+	} else if request.Price != nil {
+		priceAmount, err := variantPriceAmount(*product, request.Price)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		variant.PriceAmount = priceAmount
+	}
+	if request.Stock != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": errStockWrite,
+		})
+		return
+	}
+	if request.Status != "" {
+		variant.Status = request.Status
+	}
+	if request.Position != nil {
+		variant.Position = *request.Position
+	}
+	variant.UpdatedBy = currentUserID(c)
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkVariantUnique(tx, variant); err != nil {
+			return err
+		}
+
+		if options != nil {
+			values, err := resolveVariantOptions(tx, product.ID, options)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(variant).Association("Options").Replace(values); err != nil {
+				return err
+			}
+			variant.Options = values
+		}
+
+		return tx.Omit("Options", "stock").Save(variant).Error
+	})
+	if !handleVariantSaveError(c, err, "Failed to update variant") {
+		return
+	}
+	search.Sync(product.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Variant updated successfully",
+		"data":    toVariantResponse(*product, *variant),
+	})
+}
+
+// DeleteProductVariant permanently deletes a variant with its stock levels,
+// transfers, reservations and cart items and releases its image. Its stock
+// movements are kept in the ledger.
+func DeleteProductVariant(c *gin.Context) {
+	product, variant, ok := findVariant(c)
+	if !ok {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(variant).Association("Options").Clear(); err != nil {
+			return err
+		}
+		if err := tx.Where("variant_id = ?", variant.ID).Delete(&models.CartItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("variant_id = ?", variant.ID).Delete(&models.StockReservation{}).Error; err != nil {
+			return err
+		}
+		if err := inventory.ArchiveVariantMovements(tx, *variant); err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ? AND variant_id = ?", product.ID, variant.ID).Delete(&models.StockLevel{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(variant).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete variant",
+		})
+		return
+	}
+	search.Sync(product.ID)
+
+	if variant.ImagePath != "" {
+		if err := utils.ReleaseImage(variant.ImagePath); err != nil {
+			log.Printf("failed to release image of variant %s: %v", variant.Uuid, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Variant deleted successfully",
+	})
+}
+
+// UploadProductVariantImage uploads an image for a specific variant
+func UploadProductVariantImage(c *gin.Context) {
+	product, variant, ok := findVariant(c)
+	if !ok {
+		return
+	}
+
+	// Get uploaded file
+	fileHeader, err := c.FormFile("image")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "No image file provided",
+		})
+		return
+	}
+
+	imageResponse, err := utils.SaveUploadedImage(c, fileHeader)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	oldImagePath := variant.ImagePath
+	variant.ImagePath = imageResponse.ImagePath
+	variant.ImageURL = imageResponse.ImageURL
+	variant.ImageHash = imageResponse.ImageHash
+
+	if err := config.DB.Omit("Options", "stock").Save(variant).Error; err != nil {
+		if releaseErr := utils.ReleaseImage(imageResponse.ImagePath); releaseErr != nil {
+			log.Printf("failed to release unattached image %s: %v", imageResponse.ImagePath, releaseErr)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update variant with image info",
+		})
+		return
+	}
+
+	if oldImagePath != "" {
+		if err := utils.ReleaseImage(oldImagePath); err != nil {
+			log.Printf("failed to release replaced image %s: %v", oldImagePath, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Image uploaded successfully",
+		"data":    toVariantResponse(*product, *variant),
+	})
+}
+
+// findVariant loads the product and the variant named in the URL
+func findVariant(c *gin.Context) (*models.Product, *models.ProductVariant, bool) {
+	product, ok := findProduct(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	variantUUID, err := uuid.Parse(c.Param("variant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid variant ID",
+		})
+		return nil, nil, false
+	}
+
+	var variant models.ProductVariant
+	if err := config.DB.Preload("Options.ProductOption").
+		Where("uuid = ? AND product_id = ?", variantUUID, product.ID).
+		First(&variant).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Variant not found",
+		})
+		return nil, nil, false
+	}
+
+	return product, &variant, true
+}
+
+// normaliseVariantOptions trims option names and values and rejects empty
+// or duplicate (case-insensitive) option names
+func normaliseVariantOptions(options map[string]string) (map[string]string, error) {
+	normalised := make(map[string]string, len(options))
+	seen := make(map[string]bool, len(options))
+
+	for name, value := range options {
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if name == "" || value == "" {
+			return nil, errors.New("option names and values must not be empty")
+		}
+		if seen[strings.ToLower(name)] {
+			return nil, errors.New("duplicate option " + name)
+		}
+		seen[strings.ToLower(name)] = true
+		normalised[name] = value
+	}
+
+	return normalised, nil
+}
+
+// variantOptionKey builds a case-insensitive, order independent key for an
+// option combination, e.g. "colour=red;size=xl"
+func variantOptionKey(options map[string]string) string {
+	pairs := make([]string, 0, len(options))
+	for name, value := range options {
+		pairs = append(pairs, strings.ToLower(name)+"="+strings.ToLower(value))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ";")
+}
+
+// resolveVariantOptions finds or creates the option types and values of a
+// product for the given combination
+func resolveVariantOptions(tx *gorm.DB, productID uint, options map[string]string) ([]models.ProductOptionValue, error) {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	values := make([]models.ProductOptionValue, 0, len(options))
+	for _, name := range names {
+		var option models.ProductOption
+		err := tx.Where("product_id = ? AND LOWER(name) = LOWER(?)", productID, name).First(&option).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			var count int64
+			tx.Model(&models.ProductOption{}).Where("product_id = ?", productID).Count(&count)
+			option = models.ProductOption{ProductID: productID, Name: name, Position: int(count)}
+			err = tx.Create(&option).Error
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var value models.ProductOptionValue
+		err = tx.Where("product_option_id = ? AND LOWER(value) = LOWER(?)", option.ID, options[name]).First(&value).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			var count int64
+			tx.Model(&models.ProductOptionValue{}).Where("product_option_id = ?", option.ID).Count(&count)
+			value = models.ProductOptionValue{ProductOptionID: option.ID, Value: options[name], Position: int(count)}
+			err = tx.Create(&value).Error
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		value.ProductOption = &option
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+// checkVariantUnique makes sure the SKU is not used by any product or other
+// variant and the option combination is new for this product
+func checkVariantUnique(tx *gorm.DB, variant *models.ProductVariant) error {
+	var count int64
+	tx.Model(&models.ProductVariant{}).Where("sku = ? AND id <> ?", variant.SKU, variant.ID).Count(&count)
+	if count > 0 {
+		return errDuplicateVariantSKU
+	}
+
+	tx.Unscoped().Model(&models.Product{}).Where("sku = ?", variant.SKU).Count(&count)
+	if count > 0 {
+		return errDuplicateVariantSKU
+	}
+
+	tx.Model(&models.ProductVariant{}).
+		Where("product_id = ? AND option_key = ? AND id <> ?", variant.ProductID, variant.OptionKey, variant.ID).
+		Count(&count)
+	if count > 0 {
+		return errDuplicateVariantOptions
+	}
+
+	return nil
+}
+
+// handleVariantSaveError writes the response for a failed create or update
+// and reports whether the save succeeded
+func handleVariantSaveError(c *gin.Context, err error, message string) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, errDuplicateVariantSKU), errors.Is(err, errDuplicateVariantOptions):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+	return false
+}
+
+// variantPriceAmount returns the minor units of a variant price override,
+// which must be in the product currency
+func variantPriceAmount(product models.Product, price *models.Money) (*int64, error) {
+	if price == nil {
+		return nil, nil
+	}
+	if price.Currency != product.Price.Currency {
+		return nil, fmt.Errorf("variant price must be in the product currency %s", product.Price.Currency)
+	}
+	return &price.Amount, nil
+}
+
+// checkPriceCurrencyChange refuses to change the currency of a product
+// whose variants override the price, as the overrides are amounts in the
+// product currency
+func checkPriceCurrencyChange(product models.Product, currency string) error {
+	if currency == product.Price.Currency {
+		return nil
+	}
+	var count int64
+	config.DB.Model(&models.ProductVariant{}).Where("product_id = ? AND price_amount IS NOT NULL", product.ID).Count(&count)
+	if count > 0 {
+		return errors.New("cannot change the currency of a product with variant price overrides")
+	}
+	return nil
+}
+
+// toVariantResponse converts a variant into its API representation
+func toVariantResponse(product models.Product, variant models.ProductVariant) models.ProductVariantResponse {
+	price := product.Price
+	var override *models.Money
+	if variant.PriceAmount != nil {
+		price.Amount = *variant.PriceAmount
+		override = &price
+	}
+
+	options := make(map[string]string, len(variant.Options))
+	for _, value := range variant.Options {
+		if value.ProductOption != nil {
+			options[value.ProductOption.Name] = value.Value
+		}
+	}
+
+	return models.ProductVariantResponse{
+		ID:            variant.Uuid,
+		SKU:           variant.SKU,
+		Price:         price,
+		PriceOverride: override,
+		Stock:         variant.Stock,
+		Options:       options,
+		ImageURL:      variant.ImageURL,
+		ImageHash:     variant.ImageHash,
+		Status:        variant.Status,
+		Position:      variant.Position,
+		CreatedAt:     variant.CreatedAt,
+		UpdatedAt:     variant.UpdatedAt,
+	}
+}
+
+// loadVariantSummaries aggregates the variants of the given products,
+// keyed by product ID. Products without variants are absent from the map.
+func loadVariantSummaries(products []models.Product) map[uint]*models.VariantSummary {
+	summaries := make(map[uint]*models.VariantSummary)
+	if len(products) == 0 {
+		return summaries
+	}
+
+	ids := make([]uint, 0, len(products))
+	currencies := make(map[uint]string, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+		currencies[product.ID] = product.Price.Currency
+	}
+
+	var rows []struct {
+		ProductID  uint
+		Count      int64
+		MinPrice   int64
+		MaxPrice   int64
+		TotalStock int64
+	}
+	if err := config.DB.Table("product_variants AS v").
+		Select("v.product_id, COUNT(*) AS count, MIN(COALESCE(v.price_amount, p.price_amount)) AS min_price, MAX(COALESCE(v.price_amount, p.price_amount)) AS max_price, SUM(v.stock) AS total_stock").
+		Joins("JOIN products p ON p.id = v.product_id").
+		Where("v.product_id IN ?", ids).
+		Group("v.product_id").
+		Scan(&rows).Error; err != nil {
+		log.Printf("failed to aggregate variants: %v", err)
+		return summaries
+	}
+
+	for _, row := range rows {
+		summaries[row.ProductID] = &models.VariantSummary{
+			Count:      row.Count,
+			MinPrice:   models.Money{Amount: row.MinPrice, Currency: currencies[row.ProductID]},
+			MaxPrice:   models.Money{Amount: row.MaxPrice, Currency: currencies[row.ProductID]},
+			TotalStock: row.TotalStock,
+		}
+	}
+
+	return summaries
+}
@@ -0,0 +1,260 @@
+package controllers
+
+import (
+	"backend/config"
+	"backend/inventory"
+	"backend/models"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GetWarehouses retrieves all warehouses, the default one first
+func GetWarehouses(c *gin.Context) {
+	var warehouses []models.Warehouse
+	if err := config.DB.Order("is_default DESC, code ASC").Find(&warehouses).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve warehouses",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Warehouses retrieved successfully",
+		"data":    warehouses,
+	})
+}
+
+// CreateWarehouse creates a warehouse. With is_default it replaces the
+// current default warehouse.
+func CreateWarehouse(c *gin.Context) {
+	var request models.WarehouseRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	warehouse := models.Warehouse{Uuid: uuid.New(), Active: true}
+	if !applyWarehouseRequest(c, &warehouse, request) {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&warehouse).Error; err != nil {
+			return err
+		}
+		return makeDefaultWarehouse(tx, warehouse)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to create warehouse",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Warehouse created successfully",
+		"data":    warehouse,
+	})
+}
+
+// UpdateWarehouse replaces the settings of a warehouse. The default
+// warehouse stays active and stays the default until another warehouse is
+// made the default.
+func UpdateWarehouse(c *gin.Context) {
+	warehouse, ok := findWarehouse(c)
+	if !ok {
+		return
+	}
+
+	var request models.WarehouseRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if !applyWarehouseRequest(c, warehouse, request) {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(warehouse).Error; err != nil {
+			return err
+		}
+		return makeDefaultWarehouse(tx, *warehouse)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to update warehouse",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Warehouse updated successfully",
+		"data":    warehouse,
+	})
+}
+
+// DeleteWarehouse deletes an empty warehouse without stock movements. The
+// default warehouse and warehouses with a ledger cannot be deleted; the
+// latter can be deactivated instead.
+func DeleteWarehouse(c *gin.Context) {
+	warehouse, ok := findWarehouse(c)
+	if !ok {
+		return
+	}
+
+	if warehouse.IsDefault {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Warehouse is the default",
+			"details": "make another warehouse the default first",
+		})
+		return
+	}
+	var count int64
+	config.DB.Model(&models.InventoryMovement{}).Where("warehouse_id = ?", warehouse.ID).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Warehouse has stock movements",
+			"details": "deactivate the warehouse instead",
+		})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("warehouse_id = ?", warehouse.ID).Delete(&models.StockLevel{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(warehouse).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete warehouse",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Warehouse deleted successfully",
+	})
+}
+
+// applyWarehouseRequest sets the request fields on warehouse and writes the
+// error response when they are invalid
+func applyWarehouseRequest(c *gin.Context, warehouse *models.Warehouse, request models.WarehouseRequest) bool {
+	warehouse.Code = normalizeWarehouseCode(request.Code)
+	warehouse.Name = strings.TrimSpace(request.Name)
+	warehouse.Address = request.Address
+	if request.Active != nil {
+		warehouse.Active = *request.Active
+	}
+	// The default only moves by making another warehouse the default
+	warehouse.IsDefault = warehouse.IsDefault || request.IsDefault
+
+	if warehouse.Code == "" || warehouse.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": "code and name must not be blank",
+		})
+		return false
+	}
+	if warehouse.IsDefault && !warehouse.Active {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"details": "the default warehouse must be active",
+		})
+		return false
+	}
+	if warehouseCodeTaken(warehouse.Code, warehouse.ID) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Warehouse code already exists",
+		})
+		return false
+	}
+	return true
+}
+
+// makeDefaultWarehouse unsets the default flag of the other warehouses when
+// warehouse is the default
+func makeDefaultWarehouse(tx *gorm.DB, warehouse models.Warehouse) error {
+	if !warehouse.IsDefault {
+		return nil
+	}
+	return tx.Model(&models.Warehouse{}).Where("id <> ? AND is_default = ?", warehouse.ID, true).Update("is_default", false).Error
+}
+
+// findWarehouse loads the warehouse of the :id parameter, an ID or a code,
+// and writes the error response when it doesn't exist
+func findWarehouse(c *gin.Context) (*models.Warehouse, bool) {
+	warehouse, err := lookupWarehouse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Warehouse not found",
+		})
+		return nil, false
+	}
+	return warehouse, true
+}
+
+// lookupWarehouse finds a warehouse by ID or code
+func lookupWarehouse(ref string) (*models.Warehouse, error) {
+	var warehouse models.Warehouse
+	query := config.DB.Where("code = ?", normalizeWarehouseCode(ref))
+	if warehouseUUID, err := uuid.Parse(ref); err == nil {
+		query = config.DB.Where("uuid = ?", warehouseUUID)
+	}
+	if err := query.First(&warehouse).Error; err != nil {
+		return nil, err
+	}
+	return &warehouse, nil
+}
+
+// resolveWarehouse looks up the warehouse named by a request, or the default
+// warehouse when ref is empty, and writes the error response when it doesn't
+// exist
+func resolveWarehouse(c *gin.Context, ref string) (*models.Warehouse, bool) {
+	var warehouse *models.Warehouse
+	var err error
+	if ref == "" {
+		warehouse, err = inventory.DefaultWarehouse(config.DB)
+	} else {
+		warehouse, err = lookupWarehouse(ref)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Warehouse not found",
+		})
+		return nil, false
+	}
+	if err != nil {
+		handleStockMoveError(c, err)
+		return nil, false
+	}
+	return warehouse, true
+}
+
+// normalizeWarehouseCode makes warehouse codes case-insensitive
+func normalizeWarehouseCode(code string) string {
+	return strings.ToLower(strings.TrimSpace(code))
+}
+
+// warehouseCodeTaken reports whether another warehouse has the code
+func warehouseCodeTaken(code string, exceptID uint) bool {
+	var count int64
+	config.DB.Model(&models.Warehouse{}).Where("code = ? AND id <> ?", code, exceptID).Count(&count)
+	return count > 0
+}
@@ -0,0 +1,229 @@
+// Package currency converts Money between currencies with the rates of the
+// exchange_rates table.
+package currency
+
+import (
+	"backend/config"
+	"backend/models"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Rounding modes for converted amounts
+const (
+	RoundHalfUp   = "half_up"   // 0.5 rounds away from zero
+	RoundHalfEven = "half_even" // 0.5 rounds to the even neighbour (banker's rounding)
+	RoundDown     = "down"      // truncate towards zero
+	RoundUp       = "up"        // away from zero
+)
+
+// RateScale is the number of decimal places rates are stored with
+const RateScale = 12
+
+var (
+	ErrNoRate          = errors.New("no exchange rate")
+	ErrInvalidRate     = errors.New("rate must be a positive decimal")
+	ErrInvalidRounding = errors.New("rounding must be half_up, half_even, down or up")
+)
+
+// DefaultRounding returns the rounding mode set with EXCHANGE_ROUNDING
+func DefaultRounding() string {
+	return config.GetEnv("EXCHANGE_ROUNDING", RoundHalfUp)
+}
+
+// ValidRounding reports whether mode is a known rounding mode
+func ValidRounding(mode string) bool {
+	switch mode {
+	case RoundHalfUp, RoundHalfEven, RoundDown, RoundUp:
+		return true
+	}
+	return false
+}
+
+// ParseRate parses a positive decimal rate, rounded to RateScale places
+func ParseRate(value string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok || rate.Sign() <= 0 {
+		return nil, ErrInvalidRate
+	}
+	rate, _ = new(big.Rat).SetString(rate.FloatString(RateScale))
+	if rate.Sign() <= 0 {
+		return nil, ErrInvalidRate
+	}
+	return rate, nil
+}
+
+// FormatRate formats a rate without trailing zeros
+func FormatRate(rate *big.Rat) string {
+	formatted := strings.TrimRight(rate.FloatString(RateScale), "0")
+	return strings.TrimSuffix(formatted, ".")
+}
+
+// Rate is an exchange rate as it was applied
+type Rate struct {
+	Value         *big.Rat
+	EffectiveFrom *time.Time // nil for the identity rate
+}
+
+// Converter converts Money at a point in time. It caches the rates it looks
+// up, so use one per request.
+type Converter struct {
+	db       *gorm.DB
+	at       time.Time
+	rounding string
+	rates    map[string]*Rate
+}
+
+// NewConverter returns a converter using the rates effective at at
+func NewConverter(db *gorm.DB, at time.Time, rounding string) (*Converter, error) {
+	if !ValidRounding(rounding) {
+		return nil, ErrInvalidRounding
+	}
+	return &Converter{db: db, at: at, rounding: rounding, rates: make(map[string]*Rate)}, nil
+}
+
+// Convert converts money to the target currency
+func (c *Converter) Convert(money models.Money, target string) (*models.PriceConversion, error) {
+	rate, err := c.Rate(money.Currency, target)
+	if err != nil {
+		return nil, err
+	}
+
+	fromExponent, _ := models.CurrencyExponent(money.Currency)
+	toExponent, _ := models.CurrencyExponent(target)
+
+	// minor units * rate * 10^(target exponent - source exponent)
+	value := new(big.Rat).Mul(new(big.Rat).SetInt64(money.Amount), rate.Value)
+	shift := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(toExponent-fromExponent))), nil))
+	if toExponent >= fromExponent {
+		value.Mul(value, shift)
+	} else {
+		value.Quo(value, shift)
+	}
+
+	return &models.PriceConversion{
+		Price:             models.Money{Amount: round(value, c.rounding), Currency: target},
+		Rate:              FormatRate(rate.Value),
+		RateEffectiveFrom: rate.EffectiveFrom,
+		Rounding:          c.rounding,
+	}, nil
+}
+
+// Rate returns the rate from one currency to another: the stored rate of the
+// pair, the inverse of the opposite pair, or a cross rate through
+// models.DefaultCurrency. A cross rate reports the older of its two dates.
+func (c *Converter) Rate(from, to string) (*Rate, error) {
+	if from == to {
+		return &Rate{Value: big.NewRat(1, 1)}, nil
+	}
+
+	key := from + "/" + to
+	if rate, ok := c.rates[key]; ok {
+		if rate == nil {
+			return nil, fmt.Errorf("%w from %s to %s", ErrNoRate, from, to)
+		}
+		return rate, nil
+	}
+
+	rate, err := c.pairRate(from, to)
+	if errors.Is(err, ErrNoRate) && from != models.DefaultCurrency && to != models.DefaultCurrency {
+		var first, second *Rate
+		if first, err = c.pairRate(from, models.DefaultCurrency); err == nil {
+			if second, err = c.pairRate(models.DefaultCurrency, to); err == nil {
+				rate = &Rate{Value: new(big.Rat).Mul(first.Value, second.Value), EffectiveFrom: first.EffectiveFrom}
+				if second.EffectiveFrom.Before(*rate.EffectiveFrom) {
+					rate.EffectiveFrom = second.EffectiveFrom
+				}
+			}
+		}
+	}
+	if errors.Is(err, ErrNoRate) {
+		c.rates[key] = nil
+		return nil, fmt.Errorf("%w from %s to %s", ErrNoRate, from, to)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.rates[key] = rate
+	return rate, nil
+}
+
+// pairRate looks up the stored rate of a pair, or the inverse of the
+// opposite pair, effective at the converter's time
+func (c *Converter) pairRate(from, to string) (*Rate, error) {
+	stored, err := EffectiveRate(c.db, from, to, c.at)
+	if err == nil {
+		value, err := ParseRate(stored.Rate)
+		if err != nil {
+			return nil, err
+		}
+		return &Rate{Value: value, EffectiveFrom: &stored.EffectiveFrom}, nil
+	}
+	if !errors.Is(err, ErrNoRate) {
+		return nil, err
+	}
+
+	stored, err = EffectiveRate(c.db, to, from, c.at)
+	if err != nil {
+		return nil, err
+	}
+	value, err := ParseRate(stored.Rate)
+	if err != nil {
+		return nil, err
+	}
+	return &Rate{Value: new(big.Rat).Inv(value), EffectiveFrom: &stored.EffectiveFrom}, nil
+}
+
+// EffectiveRate returns the stored rate of a pair that is in effect at at
+func EffectiveRate(db *gorm.DB, base, quote string, at time.Time) (*models.ExchangeRate, error) {
+	var rate models.ExchangeRate
+	err := db.Where("base_currency = ? AND quote_currency = ? AND effective_from <= ?", base, quote, at).
+		Order("effective_from DESC").
+		First(&rate).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoRate
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// round rounds value to an integer with the given mode
+func round(value *big.Rat, mode string) int64 {
+	quotient, remainder := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+	if remainder.Sign() == 0 {
+		return quotient.Int64()
+	}
+
+	// Compare twice the remainder with the denominator to find the half
+	twice := new(big.Int).Abs(new(big.Int).Mul(remainder, big.NewInt(2)))
+	half := twice.Cmp(value.Denom())
+
+	awayFromZero := false
+	switch mode {
+	case RoundUp:
+		awayFromZero = true
+	case RoundHalfUp:
+		awayFromZero = half >= 0
+	case RoundHalfEven:
+		awayFromZero = half > 0 || (half == 0 && quotient.Bit(0) == 1)
+	}
+	if awayFromZero {
+		quotient.Add(quotient, big.NewInt(int64(value.Sign())))
+	}
+	return quotient.Int64()
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,112 @@
+package currency
+
+import (
+	"backend/models"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		num, denom                 int64
+		halfUp, halfEven, down, up int64
+	}{
+		{5, 2, 3, 2, 2, 3},      // 2.5
+		{7, 2, 4, 4, 3, 4},      // 3.5
+		{-5, 2, -3, -2, -2, -3}, // -2.5
+		{-7, 2, -4, -4, -3, -4},
+		{12, 5, 2, 2, 2, 3},      // 2.4
+		{13, 5, 3, 3, 2, 3},      // 2.6
+		{-12, 5, -2, -2, -2, -3}, // -2.4
+		{-13, 5, -3, -3, -2, -3},
+		{1, 2, 1, 0, 0, 1}, // 0.5
+		{-1, 2, -1, 0, 0, -1},
+		{1, 1000, 0, 0, 0, 1},
+		{4, 1, 4, 4, 4, 4},
+		{0, 1, 0, 0, 0, 0},
+	}
+	for _, test := range tests {
+		value := big.NewRat(test.num, test.denom)
+		for mode, want := range map[string]int64{
+			RoundHalfUp:   test.halfUp,
+			RoundHalfEven: test.halfEven,
+			RoundDown:     test.down,
+			RoundUp:       test.up,
+		} {
+			if got := round(value, mode); got != want {
+				t.Errorf("round(%d/%d, %s) = %d, want %d", test.num, test.denom, mode, got, want)
+			}
+		}
+	}
+}
+
+// converter returns a converter with the given rates, so no database is used
+func converter(t *testing.T, rounding string, rates map[string]string) *Converter {
+	t.Helper()
+	c, err := NewConverter(nil, time.Now(), rounding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for pair, value := range rates {
+		rate, err := ParseRate(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.rates[pair] = &Rate{Value: rate}
+	}
+	return c
+}
+
+func TestConvert(t *testing.T) {
+	rates := map[string]string{
+		"USD/JPY": "150.5",
+		"USD/KWD": "0.3075",
+		"JPY/KWD": "0.0025",
+		"KWD/JPY": "500",
+		"USD/EUR": "0.925",
+	}
+	tests := []struct {
+		amount           int64
+		from, to         string
+		halfUp, halfEven int64
+	}{
+		{100, "USD", "JPY", 151, 150},    // 150.5 yen, exponent 2 to 0
+		{300, "USD", "JPY", 452, 452},    // 451.5 yen
+		{-100, "USD", "JPY", -151, -150}, //
+		{1999, "USD", "JPY", 3008, 3008}, // 3008.495 yen
+		{1000, "USD", "KWD", 3075, 3075}, // 3.075 dinar, exponent 2 to 3
+		{1, "USD", "KWD", 3, 3},          // 3.075 fils
+		{1, "JPY", "KWD", 3, 2},          // 2.5 fils, exponent 0 to 3
+		{-1, "JPY", "KWD", -3, -2},
+		{1, "KWD", "JPY", 1, 0}, // 0.5 yen, exponent 3 to 0
+		{3, "KWD", "JPY", 2, 2}, // 1.5 yen
+		{1234, "KWD", "JPY", 617, 617},
+		{10, "USD", "EUR", 9, 9},   // 9.25 cents
+		{20, "USD", "EUR", 19, 18}, // 18.5 cents
+		{-20, "USD", "EUR", -19, -18},
+		{1999, "USD", "USD", 1999, 1999},
+	}
+	for mode, pick := range map[string]func(halfUp, halfEven int64) int64{
+		RoundHalfUp:   func(halfUp, _ int64) int64 { return halfUp },
+		RoundHalfEven: func(_, halfEven int64) int64 { return halfEven },
+	} {
+		c := converter(t, mode, rates)
+		for _, test := range tests {
+			conversion, err := c.Convert(models.Money{Amount: test.amount, Currency: test.from}, test.to)
+			if err != nil {
+				t.Fatalf("Convert(%d %s, %s): %v", test.amount, test.from, test.to, err)
+			}
+			want := pick(test.halfUp, test.halfEven)
+			if conversion.Price != (models.Money{Amount: want, Currency: test.to}) {
+				t.Errorf("%s: Convert(%d %s, %s) = %v, want %d", mode, test.amount, test.from, test.to, conversion.Price, want)
+			}
+		}
+	}
+}
+
+func TestNewConverterRounding(t *testing.T) {
+	if _, err := NewConverter(nil, time.Now(), "nearest"); err != ErrInvalidRounding {
+		t.Errorf("NewConverter with an unknown rounding = %v, want %v", err, ErrInvalidRounding)
+	}
+}
@@ -0,0 +1,171 @@
+package currency
+
+import (
+	"backend/models"
+	"backend/spreadsheet"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// csvColumns are the columns of an exchange rate file; effective_from is
+// optional
+var csvColumns = []string{"base", "quote", "rate", "effective_from"}
+
+// RowError is a rejected row of an exchange rate file
+type RowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// NewRate validates a rate and returns it ready to save. A nil
+// effectiveFrom means now.
+func NewRate(base, quote, rate string, effectiveFrom *time.Time, source string, actorID uint) (*models.ExchangeRate, error) {
+	base, quote = strings.ToUpper(strings.TrimSpace(base)), strings.ToUpper(strings.TrimSpace(quote))
+	for _, code := range []string{base, quote} {
+		if !models.ValidCurrency(code) {
+			return nil, fmt.Errorf("%w %q", models.ErrUnknownCurrency, code)
+		}
+	}
+	if base == quote {
+		return nil, errors.New("base and quote currency must differ")
+	}
+
+	value, err := ParseRate(rate)
+	if err != nil {
+		return nil, err
+	}
+
+	effective := time.Now()
+	if effectiveFrom != nil {
+		effective = *effectiveFrom
+	}
+	return &models.ExchangeRate{
+		Uuid:          uuid.New(),
+		BaseCurrency:  base,
+		QuoteCurrency: quote,
+		Rate:          FormatRate(value),
+		EffectiveFrom: effective.Truncate(time.Second),
+		Source:        source,
+		CreatedBy:     actorID,
+	}, nil
+}
+
+// SaveRate inserts a rate, replacing the rate of the same pair and
+// effective time if there is one
+func SaveRate(tx *gorm.DB, rate *models.ExchangeRate) error {
+	var existing models.ExchangeRate
+	err := tx.Where("base_currency = ? AND quote_currency = ? AND effective_from = ?",
+		rate.BaseCurrency, rate.QuoteCurrency, rate.EffectiveFrom).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return tx.Create(rate).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	existing.Rate = rate.Rate
+	existing.Source = rate.Source
+	existing.CreatedBy = rate.CreatedBy
+	if err := tx.Save(&existing).Error; err != nil {
+		return err
+	}
+	*rate = existing
+	return nil
+}
+
+// LoadCSV saves the rates of a CSV file with the columns base, quote, rate
+// and optionally effective_from (RFC 3339 or YYYY-MM-DD, default now). The
+// file is loaded completely or not at all: if any row is invalid nothing is
+// saved and the row errors are returned.
+func LoadCSV(db *gorm.DB, r io.Reader, actorID uint) (int, []RowError, error) {
+	reader := spreadsheet.NewCSVReader(r)
+	header, err := reader.Read()
+	if err == io.EOF {
+		return 0, nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range csvColumns[:3] {
+		if _, ok := columns[name]; !ok {
+			return 0, nil, fmt.Errorf("missing column %q, expected %s", name, strings.Join(csvColumns, ","))
+		}
+	}
+	value := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	var rates []*models.ExchangeRate
+	var rowErrors []RowError
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, nil, fmt.Errorf("row %d: %v", line, err)
+		}
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+
+		var effectiveFrom *time.Time
+		if text := value(row, "effective_from"); text != "" {
+			parsed, err := parseEffectiveFrom(text)
+			if err != nil {
+				rowErrors = append(rowErrors, RowError{line, err.Error()})
+				continue
+			}
+			effectiveFrom = &parsed
+		}
+
+		rate, err := NewRate(value(row, "base"), value(row, "quote"), value(row, "rate"), effectiveFrom, models.ExchangeRateCSV, actorID)
+		if err != nil {
+			rowErrors = append(rowErrors, RowError{line, err.Error()})
+			continue
+		}
+		rates = append(rates, rate)
+	}
+	if len(rowErrors) > 0 {
+		return 0, rowErrors, nil
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, rate := range rates {
+			if err := SaveRate(tx, rate); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return len(rates), nil, nil
+}
+
+// parseEffectiveFrom accepts RFC 3339 timestamps and plain dates, which
+// take effect at midnight UTC
+func parseEffectiveFrom(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("effective_from must be RFC 3339 or YYYY-MM-DD, got %q", value)
+}
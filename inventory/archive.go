@@ -0,0 +1,44 @@
+package inventory
+
+import (
+	"backend/models"
+
+	"gorm.io/gorm"
+)
+
+// ArchiveVariantMovements keeps the ledger of a variant that is about to be
+// deleted within tx. Its movements stay, without the reference to the
+// variant but with its SKU, and its transfers are deleted after their
+// movements let go of them; both sides of a transfer remain as movements.
+func ArchiveVariantMovements(tx *gorm.DB, variant models.ProductVariant) error {
+	if err := deleteTransfers(tx, tx.Model(&models.StockTransfer{}).Select("id").Where("variant_id = ?", variant.ID)); err != nil {
+		return err
+	}
+	return tx.Model(&models.InventoryMovement{}).Where("variant_id = ?", variant.ID).
+		Updates(map[string]interface{}{"variant_id": nil, "variant_sku": variant.SKU}).Error
+}
+
+// ArchiveProductMovements is ArchiveVariantMovements for a product that is
+// about to be purged with its variants. The movements keep the ID of the
+// product.
+func ArchiveProductMovements(tx *gorm.DB, productID uint, variants []models.ProductVariant) error {
+	for _, variant := range variants {
+		if err := ArchiveVariantMovements(tx, variant); err != nil {
+			return err
+		}
+	}
+	return deleteTransfers(tx, tx.Model(&models.StockTransfer{}).Select("id").Where("product_id = ?", productID))
+}
+
+// deleteTransfers deletes the transfers whose IDs transferIDs selects,
+// keeping their movements
+func deleteTransfers(tx *gorm.DB, transferIDs *gorm.DB) error {
+	var ids []uint
+	if err := transferIDs.Scan(&ids).Error; err != nil || len(ids) == 0 {
+		return err
+	}
+	if err := tx.Model(&models.InventoryMovement{}).Where("transfer_id IN ?", ids).Update("transfer_id", nil).Error; err != nil {
+		return err
+	}
+	return tx.Where("id IN ?", ids).Delete(&models.StockTransfer{}).Error
+}
@@ -0,0 +1,280 @@
+// Package inventory keeps the stock of products and variants. Stock is only
+// changed by Move and MoveTransfer, which lock the rows, update the stock and
+// record the movements in the inventory_movements ledger in one transaction.
+//
+// Stock is held per warehouse in stock_levels; the stock of a product or
+// variant is the sum of its levels. Reservations hold part of a level
+// without changing the stock, see Reserve.
+package inventory
+
+import (
+	"backend/models"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrInvalidQuantity   = errors.New("invalid quantity")
+	ErrNoWarehouse       = errors.New("no default warehouse")
+	ErrInvalidTransfer   = errors.New("invalid transfer")
+)
+
+// Movement describes a stock change. Quantity is positive for receive, sell
+// and return, which set the sign themselves, and signed for adjust and
+// transfer. Set SetStock instead of Quantity to adjust to a counted stock of
+// the warehouse. WarehouseID 0 moves the stock of the default warehouse.
+type Movement struct {
+	ProductID   uint
+	VariantID   *uint
+	WarehouseID uint
+	TransferID  *uint
+	Type        string
+	Quantity    int
+	SetStock    *int
+	Reason      string
+	Reference   string
+	ActorID     uint
+}
+
+// Transfer describes stock moved between two warehouses
+type Transfer struct {
+	ProductID       uint
+	VariantID       *uint
+	FromWarehouseID uint
+	ToWarehouseID   uint
+	Quantity        int
+	Reason          string
+	Reference       string
+	ActorID         uint
+}
+
+// DefaultWarehouse returns the warehouse used by movements that name none
+func DefaultWarehouse(tx *gorm.DB) (*models.Warehouse, error) {
+	var warehouse models.Warehouse
+	err := tx.Where("is_default = ?", true).First(&warehouse).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoWarehouse
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &warehouse, nil
+}
+
+// Move applies a movement within tx and returns the ledger entry. The
+// product or variant row is locked until tx ends, so concurrent movements
+// are applied one after the other. Stock never drops below zero in any
+// warehouse, and sales and transfers leave reserved stock alone. Movements
+// that cross the reorder threshold of the product raise or resolve a stock
+// alert. Products in the trash keep their stock and can still be moved.
+func Move(tx *gorm.DB, movement Movement) (*models.InventoryMovement, error) {
+	delta, err := signedQuantity(movement)
+	if err != nil {
+		return nil, err
+	}
+
+	if movement.WarehouseID == 0 {
+		warehouse, err := DefaultWarehouse(tx)
+		if err != nil {
+			return nil, err
+		}
+		movement.WarehouseID = warehouse.ID
+	}
+
+	stock, target, err := lockStock(tx, movement.ProductID, movement.VariantID)
+	if err != nil {
+		return nil, err
+	}
+	level, err := lockLevel(tx, movement.WarehouseID, movement.ProductID, movement.VariantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if movement.SetStock != nil {
+		delta = *movement.SetStock - level.Quantity
+	}
+	if level.Quantity+delta < 0 {
+		return nil, fmt.Errorf("%w: %d in stock, %d requested", ErrInsufficientStock, level.Quantity, -delta)
+	}
+	// Sales and transfers cannot take reserved stock; adjustments record
+	// stock that is already gone
+	if delta < 0 && movement.Type != models.MovementAdjust && level.Available()+delta < 0 {
+		return nil, fmt.Errorf("%w: %d available, %d requested", ErrInsufficientStock, level.Available(), -delta)
+	}
+
+	if err := tx.Model(level).Update("quantity", level.Quantity+delta).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Unscoped().Model(target).UpdateColumn("stock", stock+delta).Error; err != nil {
+		return nil, err
+	}
+	// Transfers don't change the stock of the product as a whole
+	if movement.Type != models.MovementTransfer {
+		if err := checkReorderThreshold(tx, movement.ProductID, delta); err != nil {
+			return nil, err
+		}
+	}
+
+	entry := models.InventoryMovement{
+		Uuid:                uuid.New(),
+		ProductID:           movement.ProductID,
+		VariantID:           movement.VariantID,
+		WarehouseID:         &movement.WarehouseID,
+		TransferID:          movement.TransferID,
+		Type:                movement.Type,
+		Quantity:            delta,
+		StockAfter:          stock + delta,
+		WarehouseStockAfter: level.Quantity + delta,
+		Reason:              movement.Reason,
+		Reference:           movement.Reference,
+	}
+	if movement.ActorID != 0 {
+		entry.ActorID = &movement.ActorID
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// MoveTransfer moves stock between two warehouses within tx. The total
+// stock of the product or variant is unchanged. The destination must be
+// active.
+func MoveTransfer(tx *gorm.DB, transfer Transfer) (*models.StockTransfer, error) {
+	if transfer.Quantity <= 0 {
+		return nil, fmt.Errorf("%w: transfer needs a positive quantity", ErrInvalidQuantity)
+	}
+	if transfer.FromWarehouseID == transfer.ToWarehouseID {
+		return nil, fmt.Errorf("%w: source and destination are the same warehouse", ErrInvalidTransfer)
+	}
+	var destination models.Warehouse
+	if err := tx.First(&destination, transfer.ToWarehouseID).Error; err != nil {
+		return nil, err
+	}
+	if !destination.Active {
+		return nil, fmt.Errorf("%w: warehouse %s is inactive", ErrInvalidTransfer, destination.Code)
+	}
+
+	record := models.StockTransfer{
+		Uuid:            uuid.New(),
+		ProductID:       transfer.ProductID,
+		VariantID:       transfer.VariantID,
+		FromWarehouseID: transfer.FromWarehouseID,
+		ToWarehouseID:   transfer.ToWarehouseID,
+		Quantity:        transfer.Quantity,
+		Reason:          transfer.Reason,
+		Reference:       transfer.Reference,
+	}
+	if transfer.ActorID != 0 {
+		record.ActorID = &transfer.ActorID
+	}
+	if err := tx.Create(&record).Error; err != nil {
+		return nil, err
+	}
+
+	sides := []struct {
+		warehouseID uint
+		quantity    int
+	}{
+		{transfer.FromWarehouseID, -transfer.Quantity},
+		{transfer.ToWarehouseID, transfer.Quantity},
+	}
+	for _, side := range sides {
+		_, err := Move(tx, Movement{
+			ProductID:   transfer.ProductID,
+			VariantID:   transfer.VariantID,
+			WarehouseID: side.warehouseID,
+			TransferID:  &record.ID,
+			Type:        models.MovementTransfer,
+			Quantity:    side.quantity,
+			Reason:      transfer.Reason,
+			Reference:   transfer.Reference,
+			ActorID:     transfer.ActorID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &record, nil
+}
+
+// lockStock locks the row that holds the total stock of a product or
+// variant and returns the stock along with the row. Its lock also guards
+// the stock levels and reservations of the product or variant, so it is
+// taken first. Variants lock their product row before their own, so that
+// checkReorderThreshold, which reads the stock of every variant under the
+// product lock, never waits for a movement that waits for it.
+func lockStock(tx *gorm.DB, productID uint, variantID *uint) (int, interface{}, error) {
+	product := &models.Product{}
+	err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "stock").
+		Where("id = ?", productID).First(product).Error
+	if err != nil || variantID == nil {
+		return product.Stock, product, err
+	}
+
+	variant := &models.ProductVariant{}
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "stock").
+		Where("id = ? AND product_id = ?", *variantID, productID).First(variant).Error
+	return variant.Stock, variant, err
+}
+
+// lockLevel locks the stock level of a product or variant in a warehouse,
+// creating an empty one on first use
+func lockLevel(tx *gorm.DB, warehouseID, productID uint, variantID *uint) (*models.StockLevel, error) {
+	level := models.StockLevel{WarehouseID: warehouseID, ProductID: productID}
+	if variantID != nil {
+		level.VariantID = *variantID
+	}
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("warehouse_id = ? AND product_id = ? AND variant_id = ?", level.WarehouseID, level.ProductID, level.VariantID).
+		First(&level).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = tx.Create(&level).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &level, nil
+}
+
+// signedQuantity returns the change of stock of a movement, checking the
+// quantity against its type
+func signedQuantity(movement Movement) (int, error) {
+	switch movement.Type {
+	case models.MovementReceive, models.MovementReturn:
+		if movement.Quantity <= 0 || movement.SetStock != nil {
+			return 0, fmt.Errorf("%w: %s needs a positive quantity", ErrInvalidQuantity, movement.Type)
+		}
+		return movement.Quantity, nil
+	case models.MovementSell:
+		if movement.Quantity <= 0 || movement.SetStock != nil {
+			return 0, fmt.Errorf("%w: sell needs a positive quantity", ErrInvalidQuantity)
+		}
+		return -movement.Quantity, nil
+	case models.MovementAdjust:
+		if movement.SetStock != nil {
+			if movement.Quantity != 0 {
+				return 0, fmt.Errorf("%w: adjust takes either quantity or stock", ErrInvalidQuantity)
+			}
+			if *movement.SetStock < 0 {
+				return 0, fmt.Errorf("%w: stock must not be negative", ErrInvalidQuantity)
+			}
+			return 0, nil
+		}
+		if movement.Quantity == 0 {
+			return 0, fmt.Errorf("%w: adjust needs a non-zero quantity or a stock", ErrInvalidQuantity)
+		}
+		return movement.Quantity, nil
+	case models.MovementTransfer:
+		if movement.Quantity == 0 || movement.SetStock != nil || movement.TransferID == nil {
+			return 0, fmt.Errorf("%w: transfer movements are made by MoveTransfer", ErrInvalidQuantity)
+		}
+		return movement.Quantity, nil
+	}
+	return 0, fmt.Errorf("unknown movement type %q", movement.Type)
+}
@@ -0,0 +1,217 @@
+package inventory
+
+import (
+	"backend/config"
+	"backend/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrReservationClosed  = errors.New("reservation is no longer active")
+	ErrReservationExpired = errors.New("reservation has expired")
+)
+
+// DefaultReservationTTL is how long reservations hold stock unless the
+// request says otherwise
+const DefaultReservationTTL = 15 * time.Minute
+
+// ReservationTTL returns the configured default lifetime of reservations
+func ReservationTTL() time.Duration {
+	return config.GetDuration("RESERVATION_TTL", DefaultReservationTTL)
+}
+
+// Hold describes stock to reserve. WarehouseID 0 reserves in the first
+// active warehouse with enough available stock, the default one first.
+type Hold struct {
+	ProductID   uint
+	VariantID   *uint
+	WarehouseID uint
+	Quantity    int
+	TTL         time.Duration
+	Reference   string
+	ActorID     uint
+}
+
+// Reserve holds stock within tx until the reservation is confirmed,
+// released or expires. The stock stays on hand but is no longer available
+// to other reservations, sales or transfers. Like Move it locks the product
+// or variant row first, so concurrent reservations of the same stock are
+// applied one after the other and never hold more than is available.
+func Reserve(tx *gorm.DB, hold Hold) (*models.StockReservation, error) {
+	if hold.Quantity <= 0 {
+		return nil, fmt.Errorf("%w: reservations need a positive quantity", ErrInvalidQuantity)
+	}
+	if _, _, err := lockStock(tx, hold.ProductID, hold.VariantID); err != nil {
+		return nil, err
+	}
+
+	var level *models.StockLevel
+	if hold.WarehouseID != 0 {
+		locked, err := lockLevel(tx, hold.WarehouseID, hold.ProductID, hold.VariantID)
+		if err != nil {
+			return nil, err
+		}
+		if locked.Available() < hold.Quantity {
+			return nil, fmt.Errorf("%w: %d available, %d requested", ErrInsufficientStock, locked.Available(), hold.Quantity)
+		}
+		level = locked
+	} else {
+		var variantID uint
+		if hold.VariantID != nil {
+			variantID = *hold.VariantID
+		}
+		var warehouses []models.Warehouse
+		if err := tx.Where("active = ?", true).Order("is_default DESC, code ASC").Find(&warehouses).Error; err != nil {
+			return nil, err
+		}
+		warehouseIDs := make([]uint, len(warehouses))
+		for i, warehouse := range warehouses {
+			warehouseIDs[i] = warehouse.ID
+		}
+		var levels []models.StockLevel
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("product_id = ? AND variant_id = ? AND warehouse_id IN ?", hold.ProductID, variantID, warehouseIDs).
+			Find(&levels).Error; err != nil {
+			return nil, err
+		}
+		levelsByWarehouse := make(map[uint]*models.StockLevel, len(levels))
+		for i := range levels {
+			levelsByWarehouse[levels[i].WarehouseID] = &levels[i]
+		}
+
+		available := 0
+		for _, warehouseID := range warehouseIDs {
+			candidate, ok := levelsByWarehouse[warehouseID]
+			if !ok {
+				continue
+			}
+			available += candidate.Available()
+			if level == nil && candidate.Available() >= hold.Quantity {
+				level = candidate
+			}
+		}
+		if level == nil {
+			return nil, fmt.Errorf("%w: no warehouse has %d available, %d in total", ErrInsufficientStock, hold.Quantity, available)
+		}
+	}
+
+	if err := tx.Model(level).Update("reserved", level.Reserved+hold.Quantity).Error; err != nil {
+		return nil, err
+	}
+
+	reservation := models.StockReservation{
+		Uuid:        uuid.New(),
+		ProductID:   hold.ProductID,
+		VariantID:   hold.VariantID,
+		WarehouseID: level.WarehouseID,
+		Quantity:    hold.Quantity,
+		Status:      models.ReservationActive,
+		ExpiresAt:   time.Now().Add(hold.TTL),
+		Reference:   hold.Reference,
+	}
+	if hold.ActorID != 0 {
+		reservation.ActorID = &hold.ActorID
+	}
+	if err := tx.Create(&reservation).Error; err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// Confirm sells the stock held by an active reservation within tx. The sale
+// is recorded as a sell movement. Expired reservations cannot be confirmed,
+// even before the expirer has released them.
+func Confirm(tx *gorm.DB, reservationID uint, actorID uint) (*models.StockReservation, error) {
+	reservation, err := lockReservation(tx, reservationID)
+	if err != nil {
+		return nil, err
+	}
+	if !time.Now().Before(reservation.ExpiresAt) {
+		return nil, ErrReservationExpired
+	}
+	if err := unreserve(tx, *reservation); err != nil {
+		return nil, err
+	}
+
+	reference := reservation.Reference
+	if reference == "" {
+		reference = reservation.Uuid.String()
+	}
+	entry, err := Move(tx, Movement{
+		ProductID:   reservation.ProductID,
+		VariantID:   reservation.VariantID,
+		WarehouseID: reservation.WarehouseID,
+		Type:        models.MovementSell,
+		Quantity:    reservation.Quantity,
+		Reason:      "reservation confirmed",
+		Reference:   reference,
+		ActorID:     actorID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reservation.Status = models.ReservationConfirmed
+	reservation.MovementID = &entry.ID
+	reservation.Movement = entry
+	if err := tx.Model(reservation).Updates(map[string]interface{}{"status": reservation.Status, "movement_id": entry.ID}).Error; err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// Release gives the stock held by an active reservation back within tx.
+// status is ReservationReleased, or ReservationExpired for the expirer.
+func Release(tx *gorm.DB, reservationID uint, status string) (*models.StockReservation, error) {
+	reservation, err := lockReservation(tx, reservationID)
+	if err != nil {
+		return nil, err
+	}
+	if err := unreserve(tx, *reservation); err != nil {
+		return nil, err
+	}
+
+	reservation.Status = status
+	if err := tx.Model(reservation).Update("status", status).Error; err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// lockReservation locks the stock row and then an active reservation,
+// keeping the lock order of Reserve and Move
+func lockReservation(tx *gorm.DB, reservationID uint) (*models.StockReservation, error) {
+	var reservation models.StockReservation
+	if err := tx.First(&reservation, reservationID).Error; err != nil {
+		return nil, err
+	}
+	if _, _, err := lockStock(tx, reservation.ProductID, reservation.VariantID); err != nil {
+		return nil, err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&reservation, reservationID).Error; err != nil {
+		return nil, err
+	}
+	if reservation.Status != models.ReservationActive {
+		return nil, fmt.Errorf("%w: it is %s", ErrReservationClosed, reservation.Status)
+	}
+	return &reservation, nil
+}
+
+// unreserve takes the quantity of a reservation off its stock level
+func unreserve(tx *gorm.DB, reservation models.StockReservation) error {
+	level, err := lockLevel(tx, reservation.WarehouseID, reservation.ProductID, reservation.VariantID)
+	if err != nil {
+		return err
+	}
+	reserved := level.Reserved - reservation.Quantity
+	if reserved < 0 {
+		reserved = 0
+	}
+	return tx.Model(level).Update("reserved", reserved).Error
+}
@@ -0,0 +1,94 @@
+package inventory
+
+import (
+	"backend/models"
+	"backend/notifications"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EventLowStock is the notification event of a raised stock alert
+const EventLowStock = "low_stock"
+
+// checkReorderThreshold raises a stock alert when a movement of delta takes
+// the stock of a product, including its variants, from above its reorder
+// threshold to at or below it, and resolves open alerts when it goes back
+// above. The product row is locked, and the variant stock and open alerts
+// are read with locking reads, which see the latest committed rows rather
+// than the transaction's snapshot, so concurrent movements of its variants
+// see each other's stock and alerts.
+func checkReorderThreshold(tx *gorm.DB, productID uint, delta int) error {
+	var product models.Product
+	if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "uuid", "name", "sku", "stock", "reorder_threshold").
+		First(&product, productID).Error; err != nil {
+		return err
+	}
+	if product.ReorderThreshold == nil || delta == 0 {
+		return nil
+	}
+
+	var variantStock int
+	if err := tx.Model(&models.ProductVariant{}).Clauses(clause.Locking{Strength: "SHARE"}).
+		Where("product_id = ?", productID).
+		Select("COALESCE(SUM(stock), 0)").Scan(&variantStock).Error; err != nil {
+		return err
+	}
+	threshold := *product.ReorderThreshold
+	after := product.Stock + variantStock
+	before := after - delta
+
+	switch {
+	case before > threshold && after <= threshold:
+		return raiseStockAlert(tx, product, after, threshold)
+	case before <= threshold && after > threshold:
+		return tx.Model(&models.StockAlert{}).Where("product_id = ? AND resolved_at IS NULL", productID).
+			Update("resolved_at", time.Now()).Error
+	}
+	return nil
+}
+
+// raiseStockAlert records a stock alert and its notifications, unless an
+// alert of the product is still open
+func raiseStockAlert(tx *gorm.DB, product models.Product, stock, threshold int) error {
+	var open int64
+	if err := tx.Model(&models.StockAlert{}).Clauses(clause.Locking{Strength: "SHARE"}).
+		Where("product_id = ? AND resolved_at IS NULL", product.ID).Count(&open).Error; err != nil {
+		return err
+	}
+	if open > 0 {
+		return nil
+	}
+
+	alert := models.StockAlert{
+		Uuid:      uuid.New(),
+		ProductID: product.ID,
+		Stock:     stock,
+		Threshold: threshold,
+	}
+	if err := tx.Create(&alert).Error; err != nil {
+		return err
+	}
+
+	return notifications.Notify(tx, notifications.Message{
+		Event:     EventLowStock,
+		Reference: alert.Uuid.String(),
+		Subject:   fmt.Sprintf("Low stock: %s (%s)", product.Name, product.SKU),
+		Body: fmt.Sprintf("The stock of %s (SKU %s) is down to %d, at or below its reorder threshold of %d.\n",
+			product.Name, product.SKU, stock, threshold),
+		Payload: map[string]interface{}{
+			"event":      EventLowStock,
+			"alert_id":   alert.Uuid,
+			"product_id": product.Uuid,
+			"name":       product.Name,
+			"sku":        product.SKU,
+			"stock":      stock,
+			"threshold":  threshold,
+			"created_at": alert.CreatedAt,
+		},
+	})
+}
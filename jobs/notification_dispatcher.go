@@ -0,0 +1,34 @@
+package jobs
+
+import (
+	"backend/config"
+	"backend/notifications"
+	"context"
+	"log"
+	"time"
+)
+
+// StartNotificationDispatcher sends the pending notifications every interval
+// until ctx is cancelled
+func StartNotificationDispatcher(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				sent, err := notifications.Dispatch(ctx, config.DB)
+				if err != nil {
+					log.Printf("notification dispatcher: %v", err)
+					continue
+				}
+				if sent > 0 {
+					log.Printf("notification dispatcher: sent %d notifications", sent)
+				}
+			}
+		}
+	}()
+}
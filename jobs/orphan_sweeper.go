@@ -0,0 +1,196 @@
+package jobs
+
+import (
+	"backend/config"
+	"backend/models"
+	"backend/storage"
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultOrphanGracePeriod protects files that were just uploaded but are not
+// attached yet (presigned uploads, or a request still between storing the
+// file and saving the product)
+const DefaultOrphanGracePeriod = 24 * time.Hour
+
+// sweptPrefixes are the storage prefixes that only contain product images,
+// brand logos and staged presigned uploads
+var sweptPrefixes = []string{"products/", "private/products/", "brands/", "incoming/"}
+
+// OrphanSweepOptions controls a single reconciliation run
+type OrphanSweepOptions struct {
+	GracePeriod time.Duration
+	DryRun      bool
+}
+
+// DanglingReference is a product that points at a file missing from storage
+type DanglingReference struct {
+	ProductID uuid.UUID `json:"product_id"`
+	ImagePath string    `json:"image_path"`
+}
+
+// OrphanReport summarises a reconciliation run
+type OrphanReport struct {
+	Scanned     int                 `json:"scanned"`
+	Orphaned    []string            `json:"orphaned"`
+	Deleted     []string            `json:"deleted"`
+	Failed      []string            `json:"failed"`
+	Dangling    []DanglingReference `json:"dangling"`
+	GracePeriod string              `json:"grace_period"`
+	DryRun      bool                `json:"dry_run"`
+}
+
+// SweepOrphanedUploads reconciles stored files against product image
+// references. Unreferenced files older than the grace period are deleted
+// (unless DryRun is set) and products pointing at missing files are reported.
+func SweepOrphanedUploads(ctx context.Context, opts OrphanSweepOptions) (*OrphanReport, error) {
+	if opts.GracePeriod <= 0 {
+		opts.GracePeriod = DefaultOrphanGracePeriod
+	}
+
+	report := &OrphanReport{GracePeriod: opts.GracePeriod.String(), DryRun: opts.DryRun}
+
+	references, err := collectImageReferences()
+	if err != nil {
+		return nil, err
+	}
+
+	stored := make(map[string]bool)
+	cutoff := time.Now().Add(-opts.GracePeriod)
+
+	for _, prefix := range sweptPrefixes {
+		objects, err := storage.Default.List(ctx, prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, object := range objects {
+			report.Scanned++
+			stored[object.Key] = true
+
+			if _, referenced := references[object.Key]; referenced || object.LastModified.After(cutoff) {
+				continue
+			}
+
+			report.Orphaned = append(report.Orphaned, object.Key)
+			if opts.DryRun {
+				continue
+			}
+
+			if err := storage.Default.Delete(ctx, object.Key); err != nil {
+				log.Printf("orphan sweeper: failed to delete %s: %v", object.Key, err)
+				report.Failed = append(report.Failed, object.Key)
+				continue
+			}
+			report.Deleted = append(report.Deleted, object.Key)
+		}
+	}
+
+	for key, ref := range references {
+		if !stored[key] && ref.live {
+			report.Dangling = append(report.Dangling, DanglingReference{ProductID: ref.productID, ImagePath: key})
+		}
+	}
+
+	return report, nil
+}
+
+type imageReference struct {
+	productID uuid.UUID
+	// live references belong to records in use and are reported when their
+	// file is missing
+	live bool
+}
+
+// collectImageReferences returns every storage key referenced by a product,
+// including soft-deleted ones whose files must survive until purge, and
+// every content-addressed blob that is still counted as in use
+func collectImageReferences() (map[string]imageReference, error) {
+	references := make(map[string]imageReference)
+
+	var blobKeys []string
+	if err := config.DB.Model(&models.ImageBlob{}).
+		Where("ref_count > 0").
+		Pluck("storage_key", &blobKeys).Error; err != nil {
+		return nil, err
+	}
+	for _, key := range blobKeys {
+		references[key] = imageReference{}
+	}
+
+	var products []models.Product
+	if err := config.DB.Unscoped().
+		Select("uuid", "image_path", "deleted_at").
+		Where("image_path <> ''").
+		Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	for _, product := range products {
+		key := storage.KeyFromPath(product.ImagePath)
+		if existing, ok := references[key]; ok && existing.live {
+			continue
+		}
+		references[key] = imageReference{
+			productID: product.Uuid,
+			live:      !product.DeletedAt.Valid,
+		}
+	}
+
+	var variants []struct {
+		ImagePath   string
+		ProductUuid uuid.UUID
+	}
+	if err := config.DB.Table("product_variants AS v").
+		Select("v.image_path, p.uuid AS product_uuid").
+		Joins("JOIN products p ON p.id = v.product_id").
+		Where("v.image_path <> ''").
+		Scan(&variants).Error; err != nil {
+		return nil, err
+	}
+	for _, variant := range variants {
+		references[storage.KeyFromPath(variant.ImagePath)] = imageReference{productID: variant.ProductUuid, live: true}
+	}
+
+	var logoPaths []string
+	if err := config.DB.Model(&models.Brand{}).
+		Where("logo_path <> ''").
+		Pluck("logo_path", &logoPaths).Error; err != nil {
+		return nil, err
+	}
+	for _, path := range logoPaths {
+		references[storage.KeyFromPath(path)] = imageReference{live: true}
+	}
+
+	return references, nil
+}
+
+// StartOrphanSweeper runs SweepOrphanedUploads every interval until ctx is
+// cancelled
+func StartOrphanSweeper(ctx context.Context, interval, gracePeriod time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				report, err := SweepOrphanedUploads(ctx, OrphanSweepOptions{GracePeriod: gracePeriod})
+				if err != nil {
+					log.Printf("orphan sweeper: %v", err)
+					continue
+				}
+				log.Printf("orphan sweeper: scanned %d files, deleted %d orphans, %d failed, %d dangling references",
+					report.Scanned, len(report.Deleted), len(report.Failed), len(report.Dangling))
+				for _, dangling := range report.Dangling {
+					log.Printf("orphan sweeper: product %s references missing file %s", dangling.ProductID, dangling.ImagePath)
+				}
+			}
+		}
+	}()
+}
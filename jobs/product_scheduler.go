@@ -0,0 +1,99 @@
+package jobs
+
+import (
+	"backend/config"
+	"backend/models"
+	"backend/search"
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PublishScheduledProducts applies due publish_at and unpublish_at times:
+// draft and inactive products whose publish_at has passed become active,
+// active products whose unpublish_at has passed become inactive. The applied
+// time is cleared. It returns the number of products published and
+// unpublished.
+func PublishScheduledProducts(ctx context.Context) (int, int, error) {
+	now := time.Now()
+
+	published, err := applyProductSchedule(ctx, "publish_at",
+		[]string{models.ProductStatusDraft, models.ProductStatusInactive}, models.ProductStatusActive, now)
+	if err != nil {
+		return published, 0, err
+	}
+	unpublished, err := applyProductSchedule(ctx, "unpublish_at",
+		[]string{models.ProductStatusActive}, models.ProductStatusInactive, now)
+	return published, unpublished, err
+}
+
+// applyProductSchedule moves the products in one of the from statuses whose
+// column is due to the to status, recording a revision without an actor
+func applyProductSchedule(ctx context.Context, column string, from []string, to string, now time.Time) (int, error) {
+	var products []models.Product
+	if err := config.DB.WithContext(ctx).
+		Where("status IN ? AND "+column+" <= ?", from, now).
+		Find(&products).Error; err != nil {
+		return 0, err
+	}
+
+	var changed []uint
+	for _, product := range products {
+		updated := false
+		err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			// Conditional update so a concurrent manual change wins the race
+			result := tx.Model(&models.Product{}).
+				Where("id = ? AND status IN ? AND "+column+" <= ?", product.ID, from, now).
+				Updates(map[string]interface{}{"status": to, column: nil})
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+
+			var after models.Product
+			if err := tx.First(&after, product.ID).Error; err != nil {
+				return err
+			}
+			updated = true
+			return models.RecordProductRevision(tx, models.RevisionUpdate, &product, after, 0)
+		})
+		if err != nil {
+			log.Printf("product scheduler: failed to update %s: %v", product.Uuid, err)
+			continue
+		}
+		if updated {
+			changed = append(changed, product.ID)
+		}
+	}
+
+	if len(changed) > 0 {
+		search.Sync(changed...)
+	}
+	return len(changed), nil
+}
+
+// StartProductScheduler runs PublishScheduledProducts every interval until
+// ctx is cancelled
+func StartProductScheduler(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				published, unpublished, err := PublishScheduledProducts(ctx)
+				if err != nil {
+					log.Printf("product scheduler: %v", err)
+					continue
+				}
+				if published > 0 || unpublished > 0 {
+					log.Printf("product scheduler: published %d and unpublished %d products", published, unpublished)
+				}
+			}
+		}
+	}()
+}
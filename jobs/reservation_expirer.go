@@ -0,0 +1,70 @@
+package jobs
+
+import (
+	"backend/config"
+	"backend/inventory"
+	"backend/models"
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExpireReservations gives back the stock of active reservations that have
+// expired. It returns the number of reservations expired.
+func ExpireReservations(ctx context.Context) (int, error) {
+	var reservations []models.StockReservation
+	if err := config.DB.WithContext(ctx).
+		Where("status = ? AND expires_at <= ?", models.ReservationActive, time.Now()).
+		Find(&reservations).Error; err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, reservation := range reservations {
+		if err := ctx.Err(); err != nil {
+			return expired, err
+		}
+		err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			_, err := inventory.Release(tx, reservation.ID, models.ReservationExpired)
+			return err
+		})
+		// Confirmed or released in the meantime
+		if errors.Is(err, inventory.ErrReservationClosed) {
+			continue
+		}
+		if err != nil {
+			log.Printf("reservation expirer: failed to expire %s: %v", reservation.Uuid, err)
+			continue
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+// StartReservationExpirer runs ExpireReservations every interval until ctx
+// is cancelled
+func StartReservationExpirer(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				expired, err := ExpireReservations(ctx)
+				if err != nil {
+					log.Printf("reservation expirer: %v", err)
+					continue
+				}
+				if expired > 0 {
+					log.Printf("reservation expirer: expired %d reservations", expired)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,142 @@
+package jobs
+
+import (
+	"backend/config"
+	"backend/inventory"
+	"backend/models"
+	"backend/utils"
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultTrashRetention is how long deleted products stay restorable
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// TrashRetention returns the configured retention of deleted products
+func TrashRetention() time.Duration {
+	return config.GetDuration("PRODUCT_TRASH_RETENTION", DefaultTrashRetention)
+}
+
+// PurgeProduct permanently deletes a product with its variants, options and
+// history, then releases its images. Its stock movements are kept in the
+// ledger. The product is expected to be in the
+// trash already, so it is no longer in the search index.
+func PurgeProduct(ctx context.Context, product models.Product) error {
+	var variants []models.ProductVariant
+	err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", product.ID).Find(&variants).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", product.ID).Delete(&models.CartItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", product.ID).Delete(&models.StockAlert{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", product.ID).Delete(&models.StockReservation{}).Error; err != nil {
+			return err
+		}
+		if err := inventory.ArchiveProductMovements(tx, product.ID, variants); err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", product.ID).Delete(&models.StockLevel{}).Error; err != nil {
+			return err
+		}
+		if len(variants) > 0 {
+			variantIDs := make([]uint, len(variants))
+			for i, variant := range variants {
+				variantIDs[i] = variant.ID
+			}
+			if err := tx.Exec("DELETE FROM product_variant_options WHERE product_variant_id IN ?", variantIDs).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("id IN ?", variantIDs).Delete(&models.ProductVariant{}).Error; err != nil {
+				return err
+			}
+		}
+
+		optionIDs := tx.Model(&models.ProductOption{}).Select("id").Where("product_id = ?", product.ID)
+		if err := tx.Where("product_option_id IN (?)", optionIDs).Delete(&models.ProductOptionValue{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", product.ID).Delete(&models.ProductOption{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", product.ID).Delete(&models.ProductRevision{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", product.ID).Delete(&models.PriceHistory{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", product.ID).Delete(&models.PriceListPrice{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&product).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	// Anything that fails to release is picked up by the orphan sweeper
+	if err := utils.ReleaseImage(product.ImagePath); err != nil {
+		log.Printf("failed to release image of product %s: %v", product.Uuid, err)
+	}
+	for _, variant := range variants {
+		if err := utils.ReleaseImage(variant.ImagePath); err != nil {
+			log.Printf("failed to release image of variant %s: %v", variant.Uuid, err)
+		}
+	}
+	return nil
+}
+
+// PurgeExpiredProducts permanently deletes the products that have been in
+// the trash for longer than retention. It returns the number purged.
+func PurgeExpiredProducts(ctx context.Context, retention time.Duration) (int, error) {
+	var products []models.Product
+	if err := config.DB.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", time.Now().Add(-retention)).
+		Find(&products).Error; err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, product := range products {
+		if err := ctx.Err(); err != nil {
+			return purged, err
+		}
+		if err := PurgeProduct(ctx, product); err != nil {
+			log.Printf("trash purger: failed to purge %s: %v", product.Uuid, err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// StartTrashPurger runs PurgeExpiredProducts every interval until ctx is
+// cancelled
+func StartTrashPurger(ctx context.Context, interval, retention time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				purged, err := PurgeExpiredProducts(ctx, retention)
+				if err != nil {
+					log.Printf("trash purger: %v", err)
+					continue
+				}
+				if purged > 0 {
+					log.Printf("trash purger: purged %d products", purged)
+				}
+			}
+		}
+	}()
+}
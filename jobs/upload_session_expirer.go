@@ -0,0 +1,72 @@
+package jobs
+
+import (
+	"backend/config"
+	"backend/models"
+	"backend/utils"
+	"context"
+	"log"
+	"time"
+)
+
+// unfinishedUploadStatuses are the statuses of sessions that still hold chunks
+var unfinishedUploadStatuses = []string{models.UploadSessionPending, models.UploadSessionProcessing}
+
+// ExpireUploadSessions marks abandoned resumable uploads as expired and
+// deletes their chunks. Sessions left processing by a crash expire the same
+// way. It returns the number of sessions expired.
+func ExpireUploadSessions(ctx context.Context) (int, error) {
+	var sessions []models.UploadSession
+	if err := config.DB.
+		Where("status IN ? AND expires_at < ?", unfinishedUploadStatuses, time.Now()).
+		Find(&sessions).Error; err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for i := range sessions {
+		session := &sessions[i]
+
+		// Conditional update so a chunk arriving right now wins the race
+		result := config.DB.Model(&models.UploadSession{}).
+			Where("id = ? AND status IN ? AND expires_at < ?", session.ID, unfinishedUploadStatuses, time.Now()).
+			Update("status", models.UploadSessionExpired)
+		if result.Error != nil {
+			log.Printf("upload session expirer: failed to expire %s: %v", session.Uuid, result.Error)
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		utils.DeleteUploadChunks(ctx, session)
+		expired++
+	}
+
+	return expired, nil
+}
+
+// StartUploadSessionExpirer runs ExpireUploadSessions every interval until
+// ctx is cancelled
+func StartUploadSessionExpirer(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				expired, err := ExpireUploadSessions(ctx)
+				if err != nil {
+					log.Printf("upload session expirer: %v", err)
+					continue
+				}
+				if expired > 0 {
+					log.Printf("upload session expirer: expired %d abandoned uploads", expired)
+				}
+			}
+		}
+	}()
+}
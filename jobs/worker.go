@@ -0,0 +1,153 @@
+package jobs
+
+import (
+	"backend/config"
+	"backend/models"
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// StaleJobTimeout is how long a job may report no progress while running
+// before it is considered abandoned, e.g. by a crashed process, and queued
+// again
+const StaleJobTimeout = time.Hour
+
+// Handler runs a job of one kind. It may update job.Processed, job.Total,
+// job.Result and job.OutputKey; the worker saves them with the final status.
+type Handler func(ctx context.Context, job *models.Job) error
+
+var (
+	handlersMu sync.RWMutex
+	handlers   = make(map[string]Handler)
+)
+
+// Register sets the handler for a job kind
+func Register(kind string, handler Handler) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	handlers[kind] = handler
+}
+
+// Enqueue stores a pending job for the worker to pick up
+func Enqueue(job *models.Job) error {
+	job.Status = models.JobPending
+	return config.DB.Create(job).Error
+}
+
+// ReportProgress saves the progress of a running job. Saving also marks the
+// job as alive, see StaleJobTimeout.
+func ReportProgress(job *models.Job) {
+	if err := config.DB.Model(job).Updates(map[string]interface{}{
+		"processed": job.Processed,
+		"total":     job.Total,
+	}).Error; err != nil {
+		log.Printf("job worker: failed to save progress of job %s: %v", job.Uuid, err)
+	}
+}
+
+// RunPendingJobs claims and runs pending jobs one after another until none
+// is left. It returns the number of jobs run.
+func RunPendingJobs(ctx context.Context) (int, error) {
+	// Queue abandoned jobs again
+	if err := config.DB.Model(&models.Job{}).
+		Where("status = ? AND updated_at < ?", models.JobRunning, time.Now().Add(-StaleJobTimeout)).
+		Update("status", models.JobPending).Error; err != nil {
+		return 0, err
+	}
+
+	run := 0
+	for ctx.Err() == nil {
+		var job models.Job
+		err := config.DB.Where("status = ?", models.JobPending).Order("id ASC").Limit(1).Find(&job).Error
+		if err != nil {
+			return run, err
+		}
+		if job.ID == 0 {
+			return run, nil
+		}
+
+		// Conditional update so that only one worker claims the job
+		now := time.Now()
+		result := config.DB.Model(&models.Job{}).
+			Where("id = ? AND status = ?", job.ID, models.JobPending).
+			Updates(map[string]interface{}{"status": models.JobRunning, "started_at": now})
+		if result.Error != nil {
+			return run, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		job.Status, job.StartedAt = models.JobRunning, &now
+
+		runJob(ctx, &job)
+		run++
+	}
+	return run, ctx.Err()
+}
+
+// runJob runs a claimed job and records its outcome
+func runJob(ctx context.Context, job *models.Job) {
+	handlersMu.RLock()
+	handler, ok := handlers[job.Kind]
+	handlersMu.RUnlock()
+
+	var err error
+	if !ok {
+		err = fmt.Errorf("no handler for job kind %q", job.Kind)
+	} else {
+		err = safeRun(ctx, handler, job)
+	}
+
+	now := time.Now()
+	job.FinishedAt = &now
+	job.Status = models.JobCompleted
+	if err != nil {
+		job.Status = models.JobFailed
+		job.Error = err.Error()
+		log.Printf("job worker: job %s (%s) failed: %v", job.Uuid, job.Kind, err)
+	}
+
+	if err := config.DB.Model(job).Updates(map[string]interface{}{
+		"status":      job.Status,
+		"error":       job.Error,
+		"result":      job.Result,
+		"output_key":  job.OutputKey,
+		"processed":   job.Processed,
+		"total":       job.Total,
+		"finished_at": job.FinishedAt,
+	}).Error; err != nil {
+		log.Printf("job worker: failed to save job %s: %v", job.Uuid, err)
+	}
+}
+
+// safeRun turns a panicking handler into a failed job
+func safeRun(ctx context.Context, handler Handler, job *models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// StartJobWorker runs RunPendingJobs every interval until ctx is cancelled
+func StartJobWorker(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := RunPendingJobs(ctx); err != nil {
+					log.Printf("job worker: %v", err)
+				}
+			}
+		}
+	}()
+}
@@ -2,33 +2,114 @@ package main
 
 import (
 	"backend/config"
+	"backend/controllers"
+	"backend/jobs"
+	"backend/migrations"
 	"backend/models"
+	"backend/notifications"
 	"backend/routes"
-	"backend/utils"
+	"backend/search"
+	"backend/storage"
+	"context"
 	"log"
+	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
 	config.ConnectDatabase()
-	
+
 	// Auto-migrate database models
-	if err := config.DB.AutoMigrate(&models.User{}, &models.Product{}); err != nil {
+	if err := config.DB.AutoMigrate(
+		&models.User{},
+		&models.Product{},
+		&models.ImageBlob{},
+		&models.UploadSession{},
+		&models.ProductOption{},
+		&models.ProductOptionValue{},
+		&models.ProductVariant{},
+		&models.Category{},
+		&models.Brand{},
+		&models.Job{},
+		&models.ProductRevision{},
+		&models.ExchangeRate{},
+		&models.PriceHistory{},
+		&models.PriceList{},
+		&models.PriceListPrice{},
+		&models.TaxClass{},
+		&models.TaxRate{},
+		&models.Warehouse{},
+		&models.StockLevel{},
+		&models.StockTransfer{},
+		&models.InventoryMovement{},
+		&models.StockReservation{},
+		&models.StockAlert{},
+		&models.Notification{},
+		&models.Cart{},
+		&models.CartItem{},
+	); err != nil {
 		log.Fatal("Failed to migrate database: ", err)
 	}
 
-	// Initialize upload directory
-	if err := utils.InitUploadDir(); err != nil {
-		log.Fatal("Failed to create upload directory: ", err)
+	// Convert existing data to new schemas
+	if err := migrations.Run(config.DB); err != nil {
+		log.Fatal("Failed to run data migrations: ", err)
+	}
+
+	// Initialize file storage backend
+	if err := storage.Init(); err != nil {
+		log.Fatal("Failed to initialize storage: ", err)
 	}
 
+	// Initialize stock alert notification channels
+	if err := notifications.Init(); err != nil {
+		log.Fatal("Failed to initialize notifications: ", err)
+	}
+
+	// CLI subcommands, e.g. `go run . gc-uploads -dry-run`
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
+	// Initialize product search backend
+	if err := search.Init(); err != nil {
+		log.Fatal("Failed to initialize search: ", err)
+	}
+
+	// Background jobs
+	ctx := context.Background()
+	jobs.StartOrphanSweeper(ctx,
+		config.GetDuration("ORPHAN_SWEEP_INTERVAL", 6*time.Hour),
+		config.GetDuration("ORPHAN_GRACE_PERIOD", jobs.DefaultOrphanGracePeriod))
+	jobs.StartUploadSessionExpirer(ctx, config.GetDuration("UPLOAD_SESSION_EXPIRY_INTERVAL", 15*time.Minute))
+	jobs.StartProductScheduler(ctx, config.GetDuration("PRODUCT_SCHEDULE_INTERVAL", time.Minute))
+	jobs.StartTrashPurger(ctx, config.GetDuration("PRODUCT_TRASH_PURGE_INTERVAL", time.Hour), jobs.TrashRetention())
+	jobs.StartReservationExpirer(ctx, config.GetDuration("RESERVATION_EXPIRY_INTERVAL", time.Minute))
+	jobs.StartNotificationDispatcher(ctx, config.GetDuration("NOTIFICATION_DISPATCH_INTERVAL", 30*time.Second))
+
+	// Queued background work such as product imports and exports
+	jobs.Register(models.JobProductImport, controllers.RunProductImport)
+	jobs.Register(models.JobProductExport, controllers.RunProductExport)
+	jobs.StartJobWorker(ctx, config.GetDuration("JOB_POLL_INTERVAL", 5*time.Second))
+
 	r := gin.Default()
 
 	// Set up routes
 	routes.AuthRoutes(r)
 	routes.UserRoutes(r)
 	routes.ProductRoutes(r)
+	routes.CategoryRoutes(r)
+	routes.BrandRoutes(r)
+	routes.FileRoutes(r)
+	routes.UploadRoutes(r)
+	routes.ExchangeRateRoutes(r)
+	routes.PriceListRoutes(r)
+	routes.TaxRoutes(r)
+	routes.WarehouseRoutes(r)
+	routes.InventoryRoutes(r)
+	routes.CartRoutes(r)
 
 	r.Run(":8081")
-}
\ No newline at end of file
+}
@@ -21,39 +21,58 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Cek algoritma token harus HS256
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return JwtSecret, nil
-		})
-
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token tidak valid"})
+		userID, err := parseUserID(strings.TrimPrefix(authHeader, "Bearer "))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			c.Abort()
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token tidak valid"})
-			c.Abort()
-			return
+		c.Set("user_id", userID)
+		c.Next()
+	}
+
+}
+
+// OptionalAuthMiddleware sets user_id when a valid Bearer token is present but
+// never rejects the request, for routes that are public but may behave
+// differently for a logged-in user
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if strings.HasPrefix(authHeader, "Bearer ") {
+			if userID, err := parseUserID(strings.TrimPrefix(authHeader, "Bearer ")); err == nil {
+				c.Set("user_id", userID)
+			}
 		}
+		c.Next()
+	}
+}
 
-		// Validasi `user_id` ada
-		userIDFloat, ok := claims["user_id"].(float64)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token tidak memiliki user_id"})
-			c.Abort()
-			return
+// parseUserID validates the token and returns the user_id claim
+func parseUserID(tokenString string) (uint, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		// Cek algoritma token harus HS256
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
+		return JwtSecret, nil
+	})
 
-		c.Set("user_id", uint(userIDFloat))
-		c.Next()
+	if err != nil || !token.Valid {
+		return 0, fmt.Errorf("Token tidak valid")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return 0, fmt.Errorf("Token tidak valid")
+	}
+
+	// Validasi `user_id` ada
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return 0, fmt.Errorf("Token tidak memiliki user_id")
 	}
 
+	return uint(userIDFloat), nil
 }
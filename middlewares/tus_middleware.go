@@ -0,0 +1,27 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TusVersion is the tus resumable upload protocol version we implement
+const TusVersion = "1.0.0"
+
+// TusResumable adds the Tus-Resumable header to every response and rejects
+// requests (other than OPTIONS) from clients speaking another version
+func TusResumable() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Tus-Resumable", TusVersion)
+
+		if c.Request.Method != http.MethodOptions && c.GetHeader("Tus-Resumable") != TusVersion {
+			c.Header("Tus-Version", TusVersion)
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Unsupported tus protocol version"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
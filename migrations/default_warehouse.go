@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"backend/models"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// migrateDefaultWarehouse creates the default warehouse and moves the stock
+// of every product and variant, and their ledger, into it
+func migrateDefaultWarehouse(tx *gorm.DB) error {
+	var warehouse models.Warehouse
+	err := tx.Where("is_default = ?", true).First(&warehouse).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		warehouse = models.Warehouse{Uuid: uuid.New(), Code: "main", Name: "Main warehouse", Active: true, IsDefault: true}
+		err = tx.Create(&warehouse).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := tx.Exec(`INSERT INTO stock_levels (warehouse_id, product_id, variant_id, quantity, updated_at)
+		SELECT ?, p.id, 0, p.stock, ? FROM products p WHERE p.stock > 0`, warehouse.ID, now).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec(`INSERT INTO stock_levels (warehouse_id, product_id, variant_id, quantity, updated_at)
+		SELECT ?, v.product_id, v.id, v.stock, ? FROM product_variants v WHERE v.stock > 0`, warehouse.ID, now).Error; err != nil {
+		return err
+	}
+	return tx.Exec(`UPDATE inventory_movements SET warehouse_id = ?, warehouse_stock_after = stock_after
+		WHERE warehouse_id IS NULL`, warehouse.ID).Error
+}
@@ -0,0 +1,65 @@
+package migrations
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SchemaMigration records a data migration that has been applied
+type SchemaMigration struct {
+	ID        string    `gorm:"primaryKey;size:100"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// migration converts existing data after AutoMigrate has created the new
+// tables and columns. Each one runs exactly once, inside a transaction.
+type migration struct {
+	id  string
+	run func(tx *gorm.DB) error
+}
+
+// all lists the data migrations in the order they must be applied. Never
+// reorder or rename entries that have shipped.
+var all = []migration{
+	{id: "20261018_product_categories_from_strings", run: migrateProductCategories},
+	{id: "20261018_product_brands_from_strings", run: migrateProductBrands},
+	{id: "20261018_normalize_product_statuses", run: migrateProductStatuses},
+	{id: "20261018_clear_released_images_of_trashed_products", run: migrateTrashedProductImages},
+	{id: "20261018_product_prices_to_money", run: migrateProductPrices},
+	{id: "20261018_seed_price_history", run: migratePriceHistory},
+	{id: "20261018_opening_stock_movements", run: migrateOpeningStock},
+	{id: "20261018_default_warehouse", run: migrateDefaultWarehouse},
+}
+
+// Run applies every data migration that has not been applied yet
+func Run(db *gorm.DB) error {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return err
+	}
+
+	for _, m := range all {
+		var count int64
+		if err := db.Model(&SchemaMigration{}).Where("id = ?", m.id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.run(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{ID: m.id, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %s: %v", m.id, err)
+		}
+		log.Printf("applied migration %s", m.id)
+	}
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package migrations
+
+import (
+	"backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrateOpeningStock records the stock of every product and variant as an
+// opening balance in the inventory ledger, so that the ledger adds up to the
+// current stock
+func migrateOpeningStock(tx *gorm.DB) error {
+	now := time.Now()
+	if err := tx.Exec(`INSERT INTO inventory_movements (uuid, product_id, type, quantity, stock_after, reason, created_at)
+		SELECT UUID(), p.id, ?, p.stock, p.stock, 'opening balance', ? FROM products p
+		WHERE p.stock > 0`, models.MovementAdjust, now).Error; err != nil {
+		return err
+	}
+	return tx.Exec(`INSERT INTO inventory_movements (uuid, product_id, variant_id, type, quantity, stock_after, reason, created_at)
+		SELECT UUID(), v.product_id, v.id, ?, v.stock, v.stock, 'opening balance', ? FROM product_variants v
+		WHERE v.stock > 0`, models.MovementAdjust, now).Error
+}
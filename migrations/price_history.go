@@ -0,0 +1,15 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migratePriceHistory records the current price of every product, including
+// those in the trash, as the start of its price history
+func migratePriceHistory(tx *gorm.DB) error {
+	return tx.Exec(`INSERT INTO price_history (product_id, price_amount, price_currency, created_at)
+		SELECT p.id, p.price_amount, p.price_currency, ? FROM products p
+		WHERE NOT EXISTS (SELECT 1 FROM price_history h WHERE h.product_id = p.id)`, time.Now()).Error
+}
@@ -0,0 +1,60 @@
+package migrations
+
+import (
+	"backend/models"
+	"backend/utils"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// migrateProductBrands turns the free-text products.brand values into rows of
+// the brands table, merging spellings that share a slug the same way
+// migrateProductCategories does.
+func migrateProductBrands(tx *gorm.DB) error {
+	var rows []struct {
+		Brand string
+		Total int64
+	}
+	if err := tx.Unscoped().Model(&models.Product{}).
+		Select("brand, COUNT(*) AS total").
+		Where("brand_id IS NULL AND brand <> ''").
+		Group("brand").
+		Order("total DESC").
+		Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	brands := make(map[string]*models.Brand)
+	for _, row := range rows {
+		name := strings.TrimSpace(row.Brand)
+		slug := utils.Slugify(name)
+		if slug == "" {
+			continue
+		}
+
+		brand, ok := brands[slug]
+		if !ok {
+			brand = &models.Brand{}
+			err := tx.Where("slug = ?", slug).First(brand).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				brand = &models.Brand{Uuid: uuid.New(), Name: name, Slug: slug, Active: true}
+				err = tx.Create(brand).Error
+			}
+			if err != nil {
+				return err
+			}
+			brands[slug] = brand
+		}
+
+		if err := tx.Unscoped().Model(&models.Product{}).
+			Where("brand_id IS NULL AND brand = ?", row.Brand).
+			Updates(map[string]interface{}{"brand_id": brand.ID, "brand": brand.Name}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
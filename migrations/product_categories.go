@@ -0,0 +1,61 @@
+package migrations
+
+import (
+	"backend/models"
+	"backend/utils"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// migrateProductCategories turns the free-text products.category values into
+// rows of the categories table. Spellings that only differ in case or
+// punctuation ("Electronics", "electronics ") share one slug and end up in
+// the same category, named after the most common spelling.
+func migrateProductCategories(tx *gorm.DB) error {
+	var rows []struct {
+		Category string
+		Total    int64
+	}
+	if err := tx.Unscoped().Model(&models.Product{}).
+		Select("category, COUNT(*) AS total").
+		Where("category_id IS NULL AND category <> ''").
+		Group("category").
+		Order("total DESC").
+		Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	categories := make(map[string]*models.Category)
+	for _, row := range rows {
+		name := strings.TrimSpace(row.Category)
+		slug := utils.Slugify(name)
+		if slug == "" {
+			continue
+		}
+
+		category, ok := categories[slug]
+		if !ok {
+			category = &models.Category{}
+			err := tx.Where("slug = ?", slug).First(category).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				category = &models.Category{Uuid: uuid.New(), Name: name, Slug: slug}
+				err = tx.Create(category).Error
+			}
+			if err != nil {
+				return err
+			}
+			categories[slug] = category
+		}
+
+		if err := tx.Unscoped().Model(&models.Product{}).
+			Where("category_id IS NULL AND category = ?", row.Category).
+			Updates(map[string]interface{}{"category_id": category.ID, "category": category.Name}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
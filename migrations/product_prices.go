@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"backend/models"
+	"math"
+
+	"gorm.io/gorm"
+)
+
+// migrateProductPrices converts the float price columns of products and
+// variants into Money minor units in the default currency, then drops the
+// float columns. Existing prices are rounded to the currency's precision.
+func migrateProductPrices(tx *gorm.DB) error {
+	exponent, ok := models.CurrencyExponent(models.DefaultCurrency)
+	if !ok {
+		return models.ErrUnknownCurrency
+	}
+	scale := math.Pow10(exponent)
+
+	migrator := tx.Migrator()
+	if migrator.HasColumn(&models.Product{}, "price") {
+		if err := tx.Exec("UPDATE products SET price_amount = ROUND(price * ?), price_currency = ?",
+			scale, models.DefaultCurrency).Error; err != nil {
+			return err
+		}
+		if err := migrator.DropColumn(&models.Product{}, "price"); err != nil {
+			return err
+		}
+	}
+	if err := tx.Exec("UPDATE products SET price_currency = ? WHERE price_currency = ''", models.DefaultCurrency).Error; err != nil {
+		return err
+	}
+
+	// Variant overrides are in the product currency, which is the default
+	// currency for every product at this point
+	if migrator.HasColumn(&models.ProductVariant{}, "price") {
+		if err := tx.Exec("UPDATE product_variants SET price_amount = ROUND(price * ?) WHERE price IS NOT NULL", scale).Error; err != nil {
+			return err
+		}
+		if err := migrator.DropColumn(&models.ProductVariant{}, "price"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"backend/models"
+
+	"gorm.io/gorm"
+)
+
+// migrateProductStatuses brings the free-text products.status values into
+// the lifecycle: empty values become active and anything unrecognised
+// becomes inactive, so it stays hidden until someone reviews it.
+func migrateProductStatuses(tx *gorm.DB) error {
+	if err := tx.Unscoped().Model(&models.Product{}).
+		Where("status IS NOT NULL").
+		Update("status", gorm.Expr("LOWER(TRIM(status))")).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Model(&models.Product{}).
+		Where("status = '' OR status IS NULL").
+		Update("status", models.ProductStatusActive).Error; err != nil {
+		return err
+	}
+
+	known := []string{
+		models.ProductStatusDraft,
+		models.ProductStatusActive,
+		models.ProductStatusInactive,
+		models.ProductStatusDiscontinued,
+	}
+	return tx.Unscoped().Model(&models.Product{}).
+		Where("status NOT IN ?", known).
+		Update("status", models.ProductStatusInactive).Error
+}
@@ -0,0 +1,16 @@
+package migrations
+
+import (
+	"backend/models"
+
+	"gorm.io/gorm"
+)
+
+// migrateTrashedProductImages clears the image of products deleted before
+// the trash existed. Deleting a product used to release its image right
+// away, so purging them must not release it a second time.
+func migrateTrashedProductImages(tx *gorm.DB) error {
+	return tx.Unscoped().Model(&models.Product{}).
+		Where("deleted_at IS NOT NULL AND image_path <> ''").
+		Updates(map[string]interface{}{"image_path": "", "image_url": "", "image_hash": ""}).Error
+}
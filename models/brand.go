@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Brand struct {
+	gorm.Model
+	Uuid        uuid.UUID `gorm:"type:char(36);uniqueIndex" json:"id"`
+	Name        string    `gorm:"size:100;not null" json:"name"`
+	Slug        string    `gorm:"size:120;not null;uniqueIndex" json:"slug"`
+	Description string    `gorm:"type:text" json:"description"`
+	LogoPath    string    `json:"-"`
+	LogoURL     string    `json:"logo_url"`
+	LogoHash    string    `gorm:"type:char(64)" json:"logo_hash"`
+	Active      bool      `gorm:"not null;default:true" json:"active"`
+}
+
+type BrandResponse struct {
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	Slug         string    `json:"slug"`
+	Description  string    `json:"description"`
+	LogoURL      string    `json:"logo_url"`
+	Active       bool      `json:"active"`
+	ProductCount int64     `json:"product_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type BrandCreateRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Slug        string `json:"slug" binding:"omitempty,max=120"`
+	Description string `json:"description"`
+	Active      *bool  `json:"active"`
+}
+
+type BrandUpdateRequest struct {
+	Name        string  `json:"name" binding:"omitempty,max=100"`
+	Slug        string  `json:"slug" binding:"omitempty,max=120"`
+	Description *string `json:"description,omitempty"`
+	Active      *bool   `json:"active,omitempty"`
+}
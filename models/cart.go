@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cart holds the items a customer intends to buy. A user has at most one
+// cart; guests are given a cart token instead, of which only the SHA-256
+// hash is stored. Carts don't hold stock, see StockReservation.
+type Cart struct {
+	ID        uint       `gorm:"primaryKey"`
+	Uuid      uuid.UUID  `gorm:"type:char(36);uniqueIndex"`
+	UserID    *uint      `gorm:"uniqueIndex"`               // nil for guest carts
+	TokenHash *string    `gorm:"type:char(64);uniqueIndex"` // nil for user carts
+	Items     []CartItem `gorm:"foreignKey:CartID"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// CartItem is a product or variant in a cart. UnitPrice is the price when
+// the item was added, the customer's price list price or the current price,
+// whichever is lower, and is kept while the item stays in the cart.
+type CartItem struct {
+	ID        uint            `gorm:"primaryKey"`
+	Uuid      uuid.UUID       `gorm:"type:char(36);uniqueIndex"`
+	CartID    uint            `gorm:"not null;index"`
+	ProductID uint            `gorm:"not null;index"`
+	Product   *Product        `gorm:"foreignKey:ProductID"`
+	VariantID *uint           `gorm:"index"`
+	Variant   *ProductVariant `gorm:"foreignKey:VariantID"`
+	Quantity  int             `gorm:"not null"`
+	UnitPrice Money           `gorm:"embedded;embeddedPrefix:unit_price_"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type CartItemRequest struct {
+	ProductID string `json:"product_id" binding:"required,uuid"`
+	VariantID string `json:"variant_id" binding:"omitempty,uuid"` // required for products with variants
+	Quantity  int    `json:"quantity" binding:"required,min=1"`
+}
+
+type CartItemUpdateRequest struct {
+	Quantity int `json:"quantity" binding:"required,min=1"`
+}
+
+type CartResponse struct {
+	ID        *uuid.UUID         `json:"id"`              // nil until the first item is added
+	Token     string             `json:"token,omitempty"` // set once, when a guest cart is created
+	Items     []CartItemResponse `json:"items"`
+	Quantity  int                `json:"quantity"`  // of all items
+	Subtotals []Money            `json:"subtotals"` // of the snapshot prices, one per currency
+	UpdatedAt *time.Time         `json:"updated_at"`
+}
+
+type CartItemResponse struct {
+	ID           uuid.UUID  `json:"id"`
+	ProductID    uuid.UUID  `json:"product_id"`
+	VariantID    *uuid.UUID `json:"variant_id"`
+	Name         string     `json:"name"`
+	SKU          string     `json:"sku"` // of the variant, if any
+	ImageURL     string     `json:"image_url"`
+	Quantity     int        `json:"quantity"`
+	UnitPrice    Money      `json:"unit_price"`    // when the item was added
+	CurrentPrice Money      `json:"current_price"` // what the item would cost if added now
+	PriceChanged bool       `json:"price_changed"`
+	LineTotal    Money      `json:"line_total"`
+	Available    int        `json:"available"`   // in active warehouses
+	Purchasable  bool       `json:"purchasable"` // false when the product or variant is no longer sold or lacks stock
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
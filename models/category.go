@@ -0,0 +1,47 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Category is a node in the product category tree. Root categories have no
+// parent.
+type Category struct {
+	gorm.Model
+	Uuid        uuid.UUID  `gorm:"type:char(36);uniqueIndex" json:"id"`
+	ParentID    *uint      `gorm:"index" json:"-"`
+	Name        string     `gorm:"size:100;not null" json:"name"`
+	Slug        string     `gorm:"size:120;not null;uniqueIndex" json:"slug"`
+	Description string     `gorm:"type:text" json:"description"`
+	Position    int        `gorm:"not null;default:0" json:"position"`
+	Parent      *Category  `gorm:"foreignKey:ParentID" json:"-"`
+	Children    []Category `gorm:"foreignKey:ParentID" json:"-"`
+}
+
+type CategoryResponse struct {
+	ID           uuid.UUID          `json:"id"`
+	ParentID     *uuid.UUID         `json:"parent_id"`
+	Name         string             `json:"name"`
+	Slug         string             `json:"slug"`
+	Description  string             `json:"description"`
+	Position     int                `json:"position"`
+	ProductCount int64              `json:"product_count"`
+	Children     []CategoryResponse `json:"children,omitempty"`
+}
+
+type CategoryCreateRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Slug        string `json:"slug" binding:"omitempty,max=120"`
+	Description string `json:"description"`
+	ParentID    string `json:"parent_id" binding:"omitempty,uuid"`
+	Position    int    `json:"position"`
+}
+
+type CategoryUpdateRequest struct {
+	Name        string  `json:"name" binding:"omitempty,max=100"`
+	Slug        string  `json:"slug" binding:"omitempty,max=120"`
+	Description *string `json:"description,omitempty"`
+	ParentID    *string `json:"parent_id,omitempty"` // "" moves the category to the root
+	Position    *int    `json:"position,omitempty"`
+}
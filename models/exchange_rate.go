@@ -0,0 +1,48 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExchangeRate is the price of one unit of BaseCurrency in QuoteCurrency
+// from EffectiveFrom until the next rate of the pair takes effect
+type ExchangeRate struct {
+	ID            uint      `gorm:"primaryKey" json:"-"`
+	Uuid          uuid.UUID `gorm:"type:char(36);uniqueIndex" json:"id"`
+	BaseCurrency  string    `gorm:"type:char(3);not null;uniqueIndex:idx_exchange_rate_pair_date,priority:1" json:"base"`
+	QuoteCurrency string    `gorm:"type:char(3);not null;uniqueIndex:idx_exchange_rate_pair_date,priority:2" json:"quote"`
+	Rate          string    `gorm:"type:decimal(24,12);not null" json:"rate"` // exact decimal, see currency.ParseRate
+	EffectiveFrom time.Time `gorm:"not null;uniqueIndex:idx_exchange_rate_pair_date,priority:3" json:"effective_from"`
+	Source        string    `gorm:"size:20;not null" json:"source"` // manual or csv
+	CreatedBy     uint      `json:"-"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Exchange rate sources
+const (
+	ExchangeRateManual = "manual"
+	ExchangeRateCSV    = "csv"
+)
+
+type ExchangeRateRequest struct {
+	Base          string      `json:"base" binding:"required,len=3"`
+	Quote         string      `json:"quote" binding:"required,len=3"`
+	Rate          json.Number `json:"rate" binding:"required"` // decimal, e.g. "0.0000583"
+	EffectiveFrom *time.Time  `json:"effective_from"`          // defaults to now
+}
+
+// PriceConversion is a price converted to the requested currency, with the
+// rate that was applied
+type PriceConversion struct {
+	Price             Money      `json:"price"`
+	CurrentPrice      *Money     `json:"current_price,omitempty"`    // of products, like ProductResponse.CurrentPrice
+	SalePrice         *Money     `json:"sale_price,omitempty"`       // set when the product has one
+	CompareAtPrice    *Money     `json:"compare_at_price,omitempty"` // set when the product has one
+	Rate              string     `json:"rate"`                       // units of the requested currency per unit of the product currency
+	RateEffectiveFrom *time.Time `json:"rate_effective_from"`        // null when no conversion was needed
+	Rounding          string     `json:"rounding"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// ImageBlob is a content-addressed image in storage. Identical uploads share
+// one blob; RefCount tracks how many records point at it and the file is
+// deleted when it drops to zero.
+type ImageBlob struct {
+	ID          uint      `gorm:"primaryKey" json:"-"`
+	Hash        string    `gorm:"type:char(64);not null;index" json:"hash"` // SHA-256 of the stored (metadata-free) bytes
+	StorageKey  string    `gorm:"size:255;not null;uniqueIndex" json:"storage_key"`
+	Size        int64     `gorm:"not null" json:"size"`
+	ContentType string    `gorm:"size:50;not null" json:"content_type"`
+	RefCount    int       `gorm:"not null;default:0" json:"ref_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Inventory movement types
+const (
+	MovementReceive  = "receive"  // goods received, increases stock
+	MovementSell     = "sell"     // goods sold, decreases stock
+	MovementAdjust   = "adjust"   // correction such as a stock count, either way
+	MovementReturn   = "return"   // goods returned by a customer, increases stock
+	MovementTransfer = "transfer" // one side of a stock transfer between warehouses
+)
+
+// InventoryMovement is an entry of the stock ledger of a product, or of one
+// of its variants when VariantID is set, in a warehouse. Stock only changes
+// through movements, see inventory.Move. Movements are never deleted, not
+// even with their variant or product, see inventory.ArchiveVariantMovements.
+type InventoryMovement struct {
+	ID                  uint            `gorm:"primaryKey" json:"-"`
+	Uuid                uuid.UUID       `gorm:"type:char(36);uniqueIndex" json:"id"`
+	ProductID           uint            `gorm:"not null;index" json:"-"`
+	VariantID           *uint           `gorm:"index" json:"-"`
+	Variant             *ProductVariant `gorm:"foreignKey:VariantID" json:"-"`
+	VariantSKU          string          `gorm:"size:100" json:"-"` // of a deleted variant, whose VariantID was cleared
+	WarehouseID         *uint           `gorm:"index" json:"-"`    // set for every movement once the default warehouse migration ran
+	Warehouse           *Warehouse      `gorm:"foreignKey:WarehouseID" json:"-"`
+	TransferID          *uint           `gorm:"index" json:"-"`
+	Transfer            *StockTransfer  `gorm:"foreignKey:TransferID" json:"-"`
+	Type                string          `gorm:"size:20;not null;index" json:"type"`
+	Quantity            int             `gorm:"not null" json:"quantity"`              // signed change of stock
+	StockAfter          int             `gorm:"not null" json:"stock_after"`           // stock of the product or variant after the movement
+	WarehouseStockAfter int             `gorm:"not null" json:"warehouse_stock_after"` // its stock in the warehouse after the movement
+	Reason              string          `gorm:"size:255" json:"reason"`
+	Reference           string          `gorm:"size:100;index" json:"reference"` // e.g. a purchase or order number
+	ActorID             *uint           `json:"-"`                               // nil for movements without a user, e.g. migrations
+	Actor               *User           `gorm:"foreignKey:ActorID" json:"-"`
+	CreatedAt           time.Time       `json:"created_at"`
+}
+
+// StockAdjustRequest records a movement in a warehouse, given by ID or code,
+// or in the default warehouse. Receive, sell and return take a positive
+// quantity; adjust takes a signed quantity or the counted stock of the
+// warehouse.
+type StockAdjustRequest struct {
+	Type      string `json:"type" binding:"required,oneof=receive sell adjust return"`
+	Quantity  int    `json:"quantity"`
+	Stock     *int   `json:"stock" binding:"omitempty,min=0"` // adjust only: set stock to this count
+	VariantID string `json:"variant_id" binding:"omitempty,uuid"`
+	Warehouse string `json:"warehouse"`
+	Reason    string `json:"reason" binding:"max=255"`
+	Reference string `json:"reference" binding:"max=100"`
+}
+
+type InventoryMovementResponse struct {
+	ID                  uuid.UUID      `json:"id"`
+	VariantID           *uuid.UUID     `json:"variant_id"`
+	VariantSKU          string         `json:"variant_sku,omitempty"` // set when the variant was deleted
+	Warehouse           *WarehouseInfo `json:"warehouse"`
+	TransferID          *uuid.UUID     `json:"transfer_id"`
+	Type                string         `json:"type"`
+	Quantity            int            `json:"quantity"`
+	StockAfter          int            `json:"stock_after"`
+	WarehouseStockAfter int            `json:"warehouse_stock_after"`
+	Reason              string         `json:"reason"`
+	Reference           string         `json:"reference"`
+	Actor               *RevisionActor `json:"actor"`
+	CreatedAt           time.Time      `json:"created_at"`
+}
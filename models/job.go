@@ -0,0 +1,56 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Job statuses
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+)
+
+// Job kinds
+const (
+	JobProductImport = "product_import"
+	JobProductExport = "product_export"
+)
+
+// Job is a unit of background work picked up by the job worker. Params and
+// Result hold kind-specific JSON. Input and output files live in storage.
+type Job struct {
+	gorm.Model
+	Uuid       uuid.UUID  `gorm:"type:char(36);uniqueIndex" json:"id"`
+	Kind       string     `gorm:"size:50;not null;index" json:"kind"`
+	Status     string     `gorm:"size:20;not null;default:pending;index" json:"status"`
+	Params     string     `gorm:"type:text" json:"-"`
+	Result     string     `gorm:"type:text" json:"-"`
+	Error      string     `gorm:"type:text" json:"error,omitempty"`
+	InputKey   string     `json:"-"`
+	OutputKey  string     `json:"-"`
+	Processed  int        `gorm:"not null;default:0" json:"processed"`
+	Total      int        `gorm:"not null;default:0" json:"total"`
+	CreatedBy  uint       `gorm:"index" json:"-"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+// JobResponse is the JSON view of a job
+type JobResponse struct {
+	ID         uuid.UUID       `json:"id"`
+	Kind       string          `json:"kind"`
+	Status     string          `json:"status"`
+	Processed  int             `json:"processed"`
+	Total      int             `json:"total"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	StartedAt  *time.Time      `json:"started_at"`
+	FinishedAt *time.Time      `json:"finished_at"`
+}
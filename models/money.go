@@ -0,0 +1,203 @@
+package models
+
+import (
+	"backend/config"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is used for prices given without a currency and for
+// amounts that are compared across products (filters, sorting, facets).
+// Override with DEFAULT_CURRENCY.
+var DefaultCurrency = strings.ToUpper(config.GetEnv("DEFAULT_CURRENCY", "IDR"))
+
+// currencyExponents are the ISO 4217 minor unit exponents of the supported
+// currencies, e.g. 2 for USD (cents) and 0 for JPY
+var currencyExponents = map[string]int{
+	"AED": 2, "AUD": 2, "BHD": 3, "BRL": 2, "CAD": 2, "CHF": 2, "CLP": 0, "CNY": 2,
+	"DKK": 2, "EUR": 2, "GBP": 2, "HKD": 2, "IDR": 2, "INR": 2, "ISK": 0, "JOD": 3,
+	"JPY": 0, "KRW": 0, "KWD": 3, "MXN": 2, "MYR": 2, "NOK": 2, "NZD": 2, "OMR": 3,
+	"PHP": 2, "SAR": 2, "SEK": 2, "SGD": 2, "THB": 2, "TND": 3, "TWD": 2, "USD": 2,
+	"VND": 0, "ZAR": 2,
+}
+
+var (
+	ErrUnknownCurrency = errors.New("unknown currency")
+	ErrInvalidAmount   = errors.New("invalid amount")
+)
+
+// Money is an amount in integer minor units of an ISO 4217 currency, so
+// 19.99 USD is {Amount: 1999, Currency: "USD"}. Embed it with a column
+// prefix, e.g. gorm:"embedded;embeddedPrefix:price_".
+//
+// In JSON it is {"amount": "19.99", "currency": "USD"}, with the amount as
+// a decimal string so it survives clients that parse numbers as floats.
+type Money struct {
+	Amount   int64  `gorm:"not null;default:0"`
+	Currency string `gorm:"type:char(3);not null"`
+}
+
+// CurrencyExponent returns the number of decimal places of currency
+func CurrencyExponent(currency string) (int, bool) {
+	exponent, ok := currencyExponents[strings.ToUpper(currency)]
+	return exponent, ok
+}
+
+// ValidCurrency reports whether currency is a supported ISO 4217 code
+func ValidCurrency(currency string) bool {
+	_, ok := CurrencyExponent(currency)
+	return ok
+}
+
+// ParseMoney parses a decimal amount such as "19.99" in currency. The
+// amount may not have more decimal places than the currency allows.
+func ParseMoney(amount, currency string) (Money, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	exponent, ok := CurrencyExponent(currency)
+	if !ok {
+		return Money{}, fmt.Errorf("%w %q", ErrUnknownCurrency, currency)
+	}
+
+	minor, err := parseMinorUnits(strings.TrimSpace(amount), exponent)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+// ParseAmount parses a decimal amount in currency and returns its minor
+// units
+func ParseAmount(amount, currency string) (int64, error) {
+	money, err := ParseMoney(amount, currency)
+	return money.Amount, err
+}
+
+// parseMinorUnits converts a decimal string to minor units without going
+// through floating point. The amount has at most one sign and digits on
+// both sides of the decimal point, if there is one.
+func parseMinorUnits(amount string, exponent int) (int64, error) {
+	negative := false
+	if strings.HasPrefix(amount, "-") || strings.HasPrefix(amount, "+") {
+		negative = amount[0] == '-'
+		amount = amount[1:]
+	}
+
+	whole, fraction, hasPoint := strings.Cut(amount, ".")
+	if !isDigits(whole) || (hasPoint && !isDigits(fraction)) {
+		return 0, ErrInvalidAmount
+	}
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > exponent {
+		return 0, fmt.Errorf("%w: at most %d decimal places allowed", ErrInvalidAmount, exponent)
+	}
+
+	digits := strings.TrimLeft(whole+fraction+strings.Repeat("0", exponent-len(fraction)), "0")
+	if digits == "" {
+		return 0, nil
+	}
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: out of range", ErrInvalidAmount)
+	}
+	if negative {
+		minor = -minor
+	}
+	return minor, nil
+}
+
+// isDigits reports whether s is a non-empty string of ASCII digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Decimal formats the amount in major units, e.g. "19.99"
+func (m Money) Decimal() string {
+	exponent, _ := CurrencyExponent(m.Currency)
+	return FormatMinorUnits(m.Amount, exponent)
+}
+
+// FormatMinorUnits formats minor units as a decimal with exponent places
+func FormatMinorUnits(minor int64, exponent int) string {
+	sign := ""
+	if minor < 0 {
+		sign, minor = "-", -minor
+	}
+	digits := strconv.FormatInt(minor, 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+type moneyJSON struct {
+	Amount   json.RawMessage `json:"amount"`
+	Currency string          `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{m.Decimal(), m.Currency})
+}
+
+// UnmarshalJSON accepts {"amount": "19.99", "currency": "USD"}, with the
+// amount as a string or number, and a bare amount in DefaultCurrency for
+// clients that still send prices as plain numbers. Numbers are parsed from
+// their literal text, never through float64.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var raw moneyJSON
+	if len(data) > 0 && data[0] == '{' {
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+		if len(raw.Amount) == 0 {
+			return fmt.Errorf("%w: amount is required", ErrInvalidAmount)
+		}
+	} else {
+		raw.Amount = data
+	}
+
+	amount := string(raw.Amount)
+	if strings.HasPrefix(amount, `"`) {
+		if err := json.Unmarshal(raw.Amount, &amount); err != nil {
+			return err
+		}
+	} else if strings.ContainsAny(amount, "eE") {
+		return fmt.Errorf("%w: exponent notation is not supported", ErrInvalidAmount)
+	}
+
+	money, err := ParseMoney(amount, raw.Currency)
+	if err != nil {
+		return err
+	}
+	*m = money
+	return nil
+}
@@ -0,0 +1,156 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		want     int64
+		wantErr  error
+	}{
+		{"19.99", "USD", 1999, nil},
+		{"19.9", "USD", 1990, nil},
+		{"19", "usd", 1900, nil},
+		{"19.990", "USD", 1999, nil},
+		{" 0.01 ", "USD", 1, nil},
+		{"0", "USD", 0, nil},
+		{"-0.50", "USD", -50, nil},
+		{"+5", "USD", 500, nil},
+		{"-+5", "USD", 0, ErrInvalidAmount},
+		{"+-5", "USD", 0, ErrInvalidAmount},
+		{"--5", "USD", 0, ErrInvalidAmount},
+		{"1.", "USD", 0, ErrInvalidAmount},
+		{".5", "USD", 0, ErrInvalidAmount},
+		{".", "USD", 0, ErrInvalidAmount},
+		{"", "USD", 0, ErrInvalidAmount},
+		{"-", "USD", 0, ErrInvalidAmount},
+		{"1.2.3", "USD", 0, ErrInvalidAmount},
+		{"1,50", "USD", 0, ErrInvalidAmount},
+		{"1e3", "USD", 0, ErrInvalidAmount},
+		{"١٢", "USD", 0, ErrInvalidAmount},
+		{"1.999", "USD", 0, ErrInvalidAmount},
+		{"1500", "JPY", 1500, nil},
+		{"1500.0", "JPY", 1500, nil},
+		{"1500.5", "JPY", 0, ErrInvalidAmount},
+		{"1.234", "KWD", 1234, nil},
+		{"1.2345", "KWD", 0, ErrInvalidAmount},
+		{"0.001", "BHD", 1, nil},
+		{"92233720368547758.07", "USD", 9223372036854775807, nil},
+		{"92233720368547758.08", "USD", 0, ErrInvalidAmount},
+		{"9223372036854775808", "JPY", 0, ErrInvalidAmount},
+		{"100000000000000000000", "USD", 0, ErrInvalidAmount},
+		{"10", "XXX", 0, ErrUnknownCurrency},
+	}
+	for _, test := range tests {
+		got, err := ParseMoney(test.amount, test.currency)
+		if test.wantErr != nil {
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("ParseMoney(%q, %q) error = %v, want %v", test.amount, test.currency, err, test.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseMoney(%q, %q) error = %v", test.amount, test.currency, err)
+			continue
+		}
+		if got.Amount != test.want {
+			t.Errorf("ParseMoney(%q, %q) = %d, want %d", test.amount, test.currency, got.Amount, test.want)
+		}
+	}
+}
+
+func TestParseMoneyDefaultCurrency(t *testing.T) {
+	got, err := ParseMoney("10", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Currency != DefaultCurrency {
+		t.Errorf("currency = %s, want %s", got.Currency, DefaultCurrency)
+	}
+}
+
+func TestFormatMinorUnits(t *testing.T) {
+	tests := []struct {
+		minor    int64
+		exponent int
+		want     string
+	}{
+		{1999, 2, "19.99"},
+		{5, 2, "0.05"},
+		{0, 2, "0.00"},
+		{-50, 2, "-0.50"},
+		{1500, 0, "1500"},
+		{-1500, 0, "-1500"},
+		{1, 3, "0.001"},
+		{1234, 3, "1.234"},
+		{9223372036854775807, 2, "92233720368547758.07"},
+	}
+	for _, test := range tests {
+		if got := FormatMinorUnits(test.minor, test.exponent); got != test.want {
+			t.Errorf("FormatMinorUnits(%d, %d) = %q, want %q", test.minor, test.exponent, got, test.want)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	tests := []struct {
+		json    string
+		want    Money
+		wantErr bool
+	}{
+		{`{"amount": "19.99", "currency": "USD"}`, Money{1999, "USD"}, false},
+		{`{"amount": 19.99, "currency": "USD"}`, Money{1999, "USD"}, false},
+		{`{"amount": "1500", "currency": "JPY"}`, Money{1500, "JPY"}, false},
+		{`{"amount": "1.234", "currency": "KWD"}`, Money{1234, "KWD"}, false},
+		{`{"amount": "0.1", "currency": "USD"}`, Money{10, "USD"}, false},
+		{`{"amount": 1e3, "currency": "USD"}`, Money{}, true},
+		{`{"amount": "-+5", "currency": "USD"}`, Money{}, true},
+		{`{"currency": "USD"}`, Money{}, true},
+		{`{"amount": "1", "currency": "XXX"}`, Money{}, true},
+	}
+	for _, test := range tests {
+		var got Money
+		err := json.Unmarshal([]byte(test.json), &got)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("Unmarshal(%s) = %v, want an error", test.json, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unmarshal(%s) error = %v", test.json, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Unmarshal(%s) = %v, want %v", test.json, got, test.want)
+		}
+
+		// Round trip through the string form
+		data, err := json.Marshal(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var again Money
+		if err := json.Unmarshal(data, &again); err != nil {
+			t.Errorf("Unmarshal(%s) error = %v", data, err)
+		} else if again != got {
+			t.Errorf("round trip of %v = %v", got, again)
+		}
+	}
+}
+
+func TestMoneyJSONBareAmount(t *testing.T) {
+	var got Money
+	if err := json.Unmarshal([]byte(`12`), &got); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := ParseMoney("12", DefaultCurrency)
+	if got != want {
+		t.Errorf("Unmarshal(12) = %v, want %v", got, want)
+	}
+}
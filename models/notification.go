@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification delivery statuses
+const (
+	NotificationPending = "pending" // waiting for the dispatcher, also between retries
+	NotificationSent    = "sent"
+	NotificationFailed  = "failed" // gave up after notifications.MaxAttempts
+)
+
+// Notification is a message to one recipient over one channel, e.g. a
+// webhook URL or an email address. Notifications are recorded in the
+// transaction of the change they report and sent afterwards by the
+// notification dispatcher, see notifications.Dispatch.
+type Notification struct {
+	ID            uint       `gorm:"primaryKey" json:"-"`
+	Uuid          uuid.UUID  `gorm:"type:char(36);uniqueIndex" json:"id"`
+	Event         string     `gorm:"size:50;not null;index" json:"event"` // e.g. low_stock
+	Reference     string     `gorm:"size:100;index" json:"reference"`     // what it is about, e.g. a stock alert ID
+	Channel       string     `gorm:"size:20;not null" json:"channel"`     // webhook or email
+	Recipient     string     `gorm:"size:255;not null" json:"recipient"`  // URL or email address
+	Subject       string     `gorm:"size:255;not null" json:"subject"`
+	Body          string     `gorm:"type:text" json:"body"`
+	Payload       string     `gorm:"type:text" json:"-"` // JSON sent to webhooks
+	Status        string     `gorm:"size:20;not null;index:idx_notification_status_next,priority:1" json:"status"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	LastError     string     `gorm:"type:text" json:"last_error"`
+	NextAttemptAt time.Time  `gorm:"not null;index:idx_notification_status_next,priority:2" json:"-"`
+	SentAt        *time.Time `json:"sent_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PriceHistory records the pricing of a product from CreatedAt until the
+// next entry of the product. A new entry is written whenever the price,
+// compare-at price or sale of a product changes.
+type PriceHistory struct {
+	ID              uint       `gorm:"primaryKey"`
+	ProductID       uint       `gorm:"not null;index:idx_price_history_product,priority:1"`
+	Price           Money      `gorm:"embedded;embeddedPrefix:price_"`
+	CompareAtAmount *int64     // in the currency of Price
+	SaleAmount      *int64     // in the currency of Price
+	SaleStartsAt    *time.Time // nil means the sale has no start
+	SaleEndsAt      *time.Time // nil means the sale runs until changed
+	ChangedBy       *uint      // nil for changes without a user, e.g. migrations
+	CreatedAt       time.Time  `gorm:"index:idx_price_history_product,priority:2"`
+}
+
+func (PriceHistory) TableName() string {
+	return "price_history"
+}
+
+type PriceHistoryResponse struct {
+	Price          Money      `json:"price"`
+	CompareAtPrice *Money     `json:"compare_at_price"`
+	SalePrice      *Money     `json:"sale_price"`
+	SaleStartsAt   *time.Time `json:"sale_starts_at"`
+	SaleEndsAt     *time.Time `json:"sale_ends_at"`
+	ChangedAt      time.Time  `json:"changed_at"`
+}
+
+func (entry PriceHistory) Response() PriceHistoryResponse {
+	return PriceHistoryResponse{
+		Price:          entry.Price,
+		CompareAtPrice: entry.Price.withAmount(entry.CompareAtAmount),
+		SalePrice:      entry.Price.withAmount(entry.SaleAmount),
+		SaleStartsAt:   entry.SaleStartsAt,
+		SaleEndsAt:     entry.SaleEndsAt,
+		ChangedAt:      entry.CreatedAt,
+	}
+}
+
+// PriceHistoryOf returns the history entry for the current pricing of a
+// product
+func PriceHistoryOf(product Product, actorID uint) PriceHistory {
+	entry := PriceHistory{
+		ProductID:       product.ID,
+		Price:           product.Price,
+		CompareAtAmount: product.CompareAtAmount,
+		SaleAmount:      product.SaleAmount,
+		SaleStartsAt:    product.SaleStartsAt,
+		SaleEndsAt:      product.SaleEndsAt,
+	}
+	if actorID != 0 {
+		entry.ChangedBy = &actorID
+	}
+	return entry
+}
+
+// RecordPriceChange writes a price history entry when the pricing of a
+// product differs from before. Pass a nil before for new products.
+func RecordPriceChange(tx *gorm.DB, before *Product, after Product, actorID uint) error {
+	if before != nil && samePricing(*before, after) {
+		return nil
+	}
+	entry := PriceHistoryOf(after, actorID)
+	return tx.Create(&entry).Error
+}
+
+func samePricing(a, b Product) bool {
+	return a.Price == b.Price &&
+		equalAmount(a.CompareAtAmount, b.CompareAtAmount) &&
+		equalAmount(a.SaleAmount, b.SaleAmount) &&
+		equalTime(a.SaleStartsAt, b.SaleStartsAt) &&
+		equalTime(a.SaleEndsAt, b.SaleEndsAt)
+}
+
+func equalAmount(a, b *int64) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+func equalTime(a, b *time.Time) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && a.Equal(*b))
+}
+
+// LowestHistoricPrice returns the lowest price in currency that applied
+// between from and to, counting sale prices while their sale ran. history
+// must be ordered oldest first and start with the entry in effect at from.
+func LowestHistoricPrice(history []PriceHistory, currency string, from, to time.Time) *Money {
+	var lowest *Money
+	consider := func(amount int64) {
+		if lowest == nil || amount < lowest.Amount {
+			lowest = &Money{Amount: amount, Currency: currency}
+		}
+	}
+
+	for i, entry := range history {
+		// Each entry applies until the next one
+		start, end := entry.CreatedAt, to
+		if i+1 < len(history) {
+			end = history[i+1].CreatedAt
+		}
+		if start.Before(from) {
+			start = from
+		}
+		if !start.Before(end) || entry.Price.Currency != currency {
+			continue
+		}
+
+		consider(entry.Price.Amount)
+		saleOverlaps := (entry.SaleStartsAt == nil || entry.SaleStartsAt.Before(end)) &&
+			(entry.SaleEndsAt == nil || entry.SaleEndsAt.After(start))
+		if entry.SaleAmount != nil && saleOverlaps {
+			consider(*entry.SaleAmount)
+		}
+	}
+	return lowest
+}
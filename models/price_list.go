@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PriceList sets product prices for the users of a customer group, e.g.
+// wholesale. A list applies while it is active and within its optional
+// StartsAt and EndsAt.
+type PriceList struct {
+	ID            uint       `gorm:"primaryKey" json:"-"`
+	Uuid          uuid.UUID  `gorm:"type:char(36);uniqueIndex" json:"id"`
+	Name          string     `gorm:"size:100;not null" json:"name"`
+	CustomerGroup string     `gorm:"size:50;not null;index" json:"customer_group"`
+	Active        bool       `gorm:"not null" json:"active"`
+	StartsAt      *time.Time `json:"starts_at"`
+	EndsAt        *time.Time `json:"ends_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// AppliesAt reports whether the list is in effect at t
+func (list PriceList) AppliesAt(t time.Time) bool {
+	return list.Active && saleWindowContains(list.StartsAt, list.EndsAt, t)
+}
+
+// PriceListPrice is the price of a product in a price list. Prices are in
+// the product currency; prices left in another currency after the product
+// currency changed are ignored.
+type PriceListPrice struct {
+	ID          uint       `gorm:"primaryKey"`
+	PriceListID uint       `gorm:"not null;uniqueIndex:idx_price_list_product,priority:1"`
+	PriceList   *PriceList `gorm:"foreignKey:PriceListID"`
+	ProductID   uint       `gorm:"not null;uniqueIndex:idx_price_list_product,priority:2;index"`
+	Product     *Product   `gorm:"foreignKey:ProductID"`
+	Price       Money      `gorm:"embedded;embeddedPrefix:price_"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// PriceListInfo identifies a price list in other responses
+type PriceListInfo struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+type PriceListRequest struct {
+	Name          string     `json:"name" binding:"required,max=100"`
+	CustomerGroup string     `json:"customer_group" binding:"required,max=50"`
+	Active        *bool      `json:"active"` // default true
+	StartsAt      *time.Time `json:"starts_at"`
+	EndsAt        *time.Time `json:"ends_at"`
+}
+
+type PriceListPricesRequest struct {
+	Prices []PriceListPriceItem `json:"prices" binding:"required,min=1,dive"`
+}
+
+type PriceListPriceItem struct {
+	ProductID string `json:"product_id" binding:"required,uuid"`
+	Price     Money  `json:"price" binding:"required,min=0"` // in the product currency
+}
+
+type PriceListResponse struct {
+	ID            uuid.UUID             `json:"id"`
+	Name          string                `json:"name"`
+	CustomerGroup string                `json:"customer_group"`
+	Active        bool                  `json:"active"`
+	StartsAt      *time.Time            `json:"starts_at"`
+	EndsAt        *time.Time            `json:"ends_at"`
+	ProductCount  int64                 `json:"product_count"`
+	Prices        []PriceListPriceEntry `json:"prices,omitempty"` // only for a single list
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+}
+
+type PriceListPriceEntry struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Name      string    `json:"name"`
+	SKU       string    `json:"sku"`
+	Price     Money     `json:"price"`
+}
+
+type CustomerGroupRequest struct {
+	CustomerGroup string `json:"customer_group" binding:"max=50"` // empty removes the user from their group
+}
@@ -9,54 +9,166 @@ import (
 
 type Product struct {
 	gorm.Model
-	Uuid        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
-	Name        string    `json:"name" gorm:"not null" binding:"required"`
-	Description string    `json:"description" gorm:"type:text"`
-	Price       float64   `json:"price" gorm:"not null" binding:"required,min=0"`
-	Stock       int       `json:"stock" gorm:"not null;default:0" binding:"min=0"`
-	Category    string    `json:"category" gorm:"not null" binding:"required"`
-	Brand       string    `json:"brand"`
-	SKU         string    `json:"sku" gorm:"unique"`
-	ImagePath   string    `json:"image_path"`
-	ImageURL    string    `json:"image_url"`
-	Status      string    `json:"status" gorm:"default:active"` // active, inactive, discontinued
-	CreatedBy   uuid.UUID `json:"created_by" gorm:"type:uuid"`
-	UpdatedBy   uuid.UUID `json:"updated_by" gorm:"type:uuid"`
+	Uuid             uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	Name             string     `json:"name" gorm:"not null" binding:"required"`
+	Description      string     `json:"description" gorm:"type:text"`
+	Price            Money      `json:"price" gorm:"embedded;embeddedPrefix:price_"` // price_amount in minor units, price_currency
+	CompareAtAmount  *int64     `json:"-"`                                           // "was" price in the product currency, see CompareAtPrice
+	SaleAmount       *int64     `json:"-"`                                           // sale price in the product currency, see SalePrice
+	SaleStartsAt     *time.Time `json:"sale_starts_at"`                              // nil starts the sale right away
+	SaleEndsAt       *time.Time `json:"sale_ends_at"`                                // nil runs the sale until changed
+	Stock            int        `json:"stock" gorm:"not null;default:0" binding:"min=0"`
+	ReorderThreshold *int       `json:"reorder_threshold"` // low-stock alert level of the product and its variants, nil for none
+	CategoryID       *uint      `json:"-" gorm:"index"`
+	Category         string     `json:"category" gorm:"not null" binding:"required"` // name of CategoryRef, kept for legacy clients
+	CategoryRef      *Category  `json:"-" gorm:"foreignKey:CategoryID"`
+	BrandID          *uint      `json:"-" gorm:"index"`
+	Brand            string     `json:"brand"` // name of BrandRef, kept for legacy clients
+	BrandRef         *Brand     `json:"-" gorm:"foreignKey:BrandID"`
+	TaxClassID       *uint      `json:"-" gorm:"index"` // nil uses DEFAULT_TAX_CLASS
+	TaxClassRef      *TaxClass  `json:"-" gorm:"foreignKey:TaxClassID"`
+	SKU              string     `json:"sku" gorm:"unique"`
+	ImagePath        string     `json:"image_path"`
+	ImageURL         string     `json:"image_url"`
+	ImageHash        string     `json:"image_hash" gorm:"type:char(64);index"` // SHA-256 of the stored image, see ImageBlob
+	ImagePrivate     bool       `json:"image_private" gorm:"default:false"`    // private images are only served via signed URLs
+	Status           string     `json:"status" gorm:"default:active;index"`    // draft, active, inactive or discontinued, see ProductStatusTransitions
+	PublishAt        *time.Time `json:"publish_at" gorm:"index"`               // activates a draft or inactive product, see jobs.PublishScheduledProducts
+	UnpublishAt      *time.Time `json:"unpublish_at" gorm:"index"`             // deactivates an active product
+	ViewCount        int64      `json:"view_count" gorm:"not null;default:0"`  // product page views, used for popularity sorting
+	CreatedBy        uuid.UUID  `json:"created_by" gorm:"type:uuid"`
+	UpdatedBy        uuid.UUID  `json:"updated_by" gorm:"type:uuid"`
 }
 
 type ProductResponse struct {
-	ID          uuid.UUID `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Price       float64   `json:"price"`
-	Stock       int       `json:"stock"`
-	Category    string    `json:"category"`
-	Brand       string    `json:"brand"`
-	SKU         string    `json:"sku"`
-	ImageURL    string    `json:"image_url"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID               uuid.UUID          `json:"id"`
+	Name             string             `json:"name"`
+	Description      string             `json:"description"`
+	Price            Money              `json:"price"`
+	CompareAtPrice   *Money             `json:"compare_at_price"`
+	SalePrice        *Money             `json:"sale_price"`
+	SaleStartsAt     *time.Time         `json:"sale_starts_at"`
+	SaleEndsAt       *time.Time         `json:"sale_ends_at"`
+	CurrentPrice     Money              `json:"current_price"`          // sale price while the sale runs, price otherwise
+	Converted        *PriceConversion   `json:"converted,omitempty"`    // set when requested with ?currency=
+	Stock            int                `json:"stock"`                  // on hand in all warehouses
+	Availability     *StockAvailability `json:"availability,omitempty"` // of the product and its variants in active warehouses, for listings
+	ReorderThreshold *int               `json:"reorder_threshold"`
+	CategoryID       *uuid.UUID         `json:"category_id"`
+	Category         string             `json:"category"`
+	BrandID          *uuid.UUID         `json:"brand_id"`
+	Brand            string             `json:"brand"`
+	TaxClass         string             `json:"tax_class"` // code of the tax class, empty for the default class
+	SKU              string             `json:"sku"`
+	ImageURL         string             `json:"image_url"`
+	ImageHash        string             `json:"image_hash"`
+	ImagePrivate     bool               `json:"image_private"`
+	Status           string             `json:"status"`
+	PublishAt        *time.Time         `json:"publish_at"`
+	UnpublishAt      *time.Time         `json:"unpublish_at"`
+	Tax              *TaxBreakdown      `json:"tax,omitempty"` // of current_price, for listings
+	Variants         *VariantSummary    `json:"variants,omitempty"`
+	Search           *SearchMatch       `json:"search,omitempty"` // set when listing with ?search=
+	CreatedAt        time.Time          `json:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at"`
+}
+
+// TrashedProductResponse is a deleted product in the trash listing
+type TrashedProductResponse struct {
+	ProductResponse
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"` // when the product is deleted permanently
+}
+
+// SearchMatch explains why a product matched a search. Highlights maps field
+// names to HTML-escaped fragments with the matching words in <mark> tags.
+type SearchMatch struct {
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+// ProductFacets summarises a product listing. Each facet is counted with
+// all filters applied except its own, so clients can offer the other values
+// of a filter that is already active.
+type ProductFacets struct {
+	Brands       []FacetValue      `json:"brands"`
+	Categories   []FacetValue      `json:"categories"`
+	Price        []PriceBucket     `json:"price"`
+	Availability AvailabilityFacet `json:"availability"`
+}
+
+type FacetValue struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Slug  string    `json:"slug"`
+	Count int64     `json:"count"`
+}
+
+// PriceBucket counts the products with Min <= price < Max, in
+// DefaultCurrency
+type PriceBucket struct {
+	Min   Money `json:"min"`
+	Max   Money `json:"max"`
+	Count int64 `json:"count"`
+}
+
+type AvailabilityFacet struct {
+	InStock    int64 `json:"in_stock"`
+	OutOfStock int64 `json:"out_of_stock"`
 }
 
 type ProductCreateRequest struct {
-	Name        string  `json:"name" binding:"required"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price" binding:"required,min=0"`
-	Stock       int     `json:"stock" binding:"min=0"`
-	Category    string  `json:"category" binding:"required"`
-	Brand       string  `json:"brand"`
-	SKU         string  `json:"sku"`
-	Status      string  `json:"status"`
+	Name             string     `json:"name" binding:"required"`
+	Description      string     `json:"description"`
+	Price            Money      `json:"price" binding:"required,min=0"`             // {"amount": "19.99", "currency": "USD"}
+	CompareAtPrice   *Money     `json:"compare_at_price" binding:"omitempty,min=0"` // in the currency of price
+	SalePrice        *Money     `json:"sale_price" binding:"omitempty,min=0"`
+	SaleStartsAt     *time.Time `json:"sale_starts_at"`
+	SaleEndsAt       *time.Time `json:"sale_ends_at"`
+	Stock            int        `json:"stock" binding:"min=0"`
+	ReorderThreshold *int       `json:"reorder_threshold" binding:"omitempty,min=0"`
+	CategoryID       string     `json:"category_id" binding:"omitempty,uuid"`
+	Category         string     `json:"category"` // legacy: name or slug of an existing category
+	BrandID          string     `json:"brand_id" binding:"omitempty,uuid"`
+	Brand            string     `json:"brand"`                                // legacy: name or slug of an existing brand
+	TaxClass         string     `json:"tax_class" binding:"omitempty,max=50"` // code of an existing tax class
+	SKU              string     `json:"sku"`
+	Status           string     `json:"status" binding:"omitempty,oneof=draft active"` // initial status, default active
+	PublishAt        *time.Time `json:"publish_at"`
+	UnpublishAt      *time.Time `json:"unpublish_at"`
 }
 
 type ProductUpdateRequest struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       *float64 `json:"price,omitempty"`
-	Stock       *int    `json:"stock,omitempty"`
-	Category    string  `json:"category"`
-	Brand       string  `json:"brand"`
-	SKU         string  `json:"sku"`
-	Status      string  `json:"status"`
-}
\ No newline at end of file
+	Name             string        `json:"name"`
+	Description      string        `json:"description"`
+	Price            *Money        `json:"price,omitempty" binding:"omitempty,min=0"`
+	CompareAtPrice   OptionalMoney `json:"compare_at_price"` // null removes it
+	SalePrice        OptionalMoney `json:"sale_price"`       // null ends the sale
+	SaleStartsAt     OptionalTime  `json:"sale_starts_at"`
+	SaleEndsAt       OptionalTime  `json:"sale_ends_at"`
+	Stock            *int          `json:"stock,omitempty"`
+	ReorderThreshold OptionalInt   `json:"reorder_threshold"` // null removes it
+	CategoryID       string        `json:"category_id" binding:"omitempty,uuid"`
+	Category         string        `json:"category"`
+	BrandID          string        `json:"brand_id" binding:"omitempty,uuid"`
+	Brand            string        `json:"brand"`
+	TaxClass         *string       `json:"tax_class,omitempty" binding:"omitempty,max=50"` // "" resets to the default class
+	SKU              string        `json:"sku"`
+	Status           string        `json:"status" binding:"omitempty,oneof=draft active inactive discontinued"`
+	PublishAt        OptionalTime  `json:"publish_at"` // null clears the schedule
+	UnpublishAt      OptionalTime  `json:"unpublish_at"`
+}
+
+// ImageUploadURLRequest describes an image the client wants to upload
+// directly to storage
+type ImageUploadURLRequest struct {
+	Filename    string `json:"filename" binding:"required"`
+	ContentType string `json:"content_type" binding:"required"`
+	Size        int64  `json:"size" binding:"required,min=1"`
+	Visibility  string `json:"visibility" binding:"omitempty,oneof=public private"`
+}
+
+// ImageUploadCompleteRequest confirms a finished direct upload
+type ImageUploadCompleteRequest struct {
+	UploadToken string `json:"upload_token" binding:"required"`
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"bytes"
+	"time"
+)
+
+// CompareAtPrice returns the "was" price shown struck through next to the
+// price, or nil
+func (p Product) CompareAtPrice() *Money {
+	return p.Price.withAmount(p.CompareAtAmount)
+}
+
+// SalePrice returns the sale price whether or not the sale is running, or
+// nil
+func (p Product) SalePrice() *Money {
+	return p.Price.withAmount(p.SaleAmount)
+}
+
+// SaleActive reports whether the sale price applies at t
+func (p Product) SaleActive(t time.Time) bool {
+	return p.SaleAmount != nil && saleWindowContains(p.SaleStartsAt, p.SaleEndsAt, t)
+}
+
+// CurrentPrice returns the price customers pay at t: the sale price while
+// the sale runs, the regular price otherwise
+func (p Product) CurrentPrice(t time.Time) Money {
+	if p.SaleActive(t) {
+		return *p.SalePrice()
+	}
+	return p.Price
+}
+
+// withAmount returns an amount in the currency of m, or nil
+func (m Money) withAmount(amount *int64) *Money {
+	if amount == nil {
+		return nil
+	}
+	return &Money{Amount: *amount, Currency: m.Currency}
+}
+
+// saleWindowContains reports whether t is within [startsAt, endsAt); a nil
+// bound is open
+func saleWindowContains(startsAt, endsAt *time.Time, t time.Time) bool {
+	return (startsAt == nil || !t.Before(*startsAt)) && (endsAt == nil || t.Before(*endsAt))
+}
+
+// ProductPricesResponse is the pricing of a product with its price history
+// for GET /products/:id/prices
+type ProductPricesResponse struct {
+	Price          Money                  `json:"price"`
+	CompareAtPrice *Money                 `json:"compare_at_price"`
+	SalePrice      *Money                 `json:"sale_price"`
+	SaleStartsAt   *time.Time             `json:"sale_starts_at"`
+	SaleEndsAt     *time.Time             `json:"sale_ends_at"`
+	SaleActive     bool                   `json:"sale_active"`
+	CurrentPrice   Money                  `json:"current_price"`
+	CustomerPrice  *CustomerPrice         `json:"customer_price,omitempty"` // only for users in a customer group with a price list price
+	LowestPrice    LowestPrice            `json:"lowest_price"`
+	History        []PriceHistoryResponse `json:"history"`
+}
+
+// CustomerPrice is the price a customer group pays according to a price
+// list
+type CustomerPrice struct {
+	Price         Money         `json:"price"`
+	CustomerGroup string        `json:"customer_group"`
+	PriceList     PriceListInfo `json:"price_list"`
+}
+
+// LowestPrice is the lowest price customers paid in the last Days days,
+// sale prices included. Price is nil when the product has no price history
+// in its current currency for that period.
+type LowestPrice struct {
+	Days  int    `json:"days"`
+	Price *Money `json:"price"`
+}
+
+// OptionalMoney is a JSON Money field that tells an explicit null apart
+// from an absent field, like OptionalTime
+type OptionalMoney struct {
+	Set   bool
+	Money *Money
+}
+
+func (o *OptionalMoney) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		o.Money = nil
+		return nil
+	}
+
+	var m Money
+	if err := m.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	o.Money = &m
+	return nil
+}
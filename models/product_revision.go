@@ -0,0 +1,220 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Product revision actions
+const (
+	RevisionCreate  = "create"
+	RevisionUpdate  = "update"
+	RevisionImage   = "image"
+	RevisionDelete  = "delete"
+	RevisionRestore = "restore"
+)
+
+// ProductRevision records one change to a product: the fields that changed
+// and the state of the product afterwards, so it can be restored later.
+// Revisions are append-only.
+type ProductRevision struct {
+	ID           uint       `gorm:"primaryKey" json:"-"`
+	Uuid         uuid.UUID  `gorm:"type:char(36);uniqueIndex" json:"id"`
+	ProductID    uint       `gorm:"not null;index" json:"-"`
+	Action       string     `gorm:"size:20;not null" json:"action"`
+	Changes      string     `gorm:"type:text" json:"-"` // JSON map of field name to FieldChange
+	Snapshot     string     `gorm:"type:text" json:"-"` // JSON ProductSnapshot after the change
+	ActorID      *uint      `gorm:"index" json:"-"`     // nil for changes made by the system
+	Actor        *User      `gorm:"foreignKey:ActorID" json:"-"`
+	RestoredFrom *uuid.UUID `gorm:"type:char(36)" json:"restored_from"` // revision restored by a restore
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// ProductSnapshot holds the revisioned fields of a product
+type ProductSnapshot struct {
+	Name             string     `json:"name"`
+	Description      string     `json:"description"`
+	Price            Money      `json:"price"`
+	CompareAtPrice   *Money     `json:"compare_at_price"`
+	SalePrice        *Money     `json:"sale_price"`
+	SaleStartsAt     *time.Time `json:"sale_starts_at"`
+	SaleEndsAt       *time.Time `json:"sale_ends_at"`
+	Stock            int        `json:"stock"`
+	ReorderThreshold *int       `json:"reorder_threshold"`
+	CategoryID       *uint      `json:"category_id"`
+	Category         string     `json:"category"`
+	BrandID          *uint      `json:"brand_id"`
+	Brand            string     `json:"brand"`
+	TaxClassID       *uint      `json:"tax_class_id"`
+	TaxClass         string     `json:"tax_class"`
+	SKU              string     `json:"sku"`
+	Status           string     `json:"status"`
+	PublishAt        *time.Time `json:"publish_at"`
+	UnpublishAt      *time.Time `json:"unpublish_at"`
+	ImagePath        string     `json:"image_path"`
+	ImageURL         string     `json:"image_url"`
+	ImageHash        string     `json:"image_hash"`
+	ImagePrivate     bool       `json:"image_private"`
+}
+
+// internalSnapshotFields are recorded for restores but not shown in
+// revision responses, which identify categories, brands and tax classes by
+// name or code
+var internalSnapshotFields = map[string]bool{
+	"category_id": true, "brand_id": true, "tax_class_id": true, "image_path": true,
+}
+
+// FieldChange is the value of a field before and after a change
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// SnapshotOf returns the revisioned fields of product
+func SnapshotOf(product Product) ProductSnapshot {
+	return ProductSnapshot{
+		Name:             product.Name,
+		Description:      product.Description,
+		Price:            product.Price,
+		CompareAtPrice:   product.CompareAtPrice(),
+		SalePrice:        product.SalePrice(),
+		SaleStartsAt:     product.SaleStartsAt,
+		SaleEndsAt:       product.SaleEndsAt,
+		Stock:            product.Stock,
+		ReorderThreshold: product.ReorderThreshold,
+		CategoryID:       product.CategoryID,
+		Category:         product.Category,
+		BrandID:          product.BrandID,
+		Brand:            product.Brand,
+		TaxClassID:       product.TaxClassID,
+		TaxClass:         taxClassCode(product),
+		SKU:              product.SKU,
+		Status:           product.Status,
+		PublishAt:        product.PublishAt,
+		UnpublishAt:      product.UnpublishAt,
+		ImagePath:        product.ImagePath,
+		ImageURL:         product.ImageURL,
+		ImageHash:        product.ImageHash,
+		ImagePrivate:     product.ImagePrivate,
+	}
+}
+
+// NewProductRevision builds the revision of a change from before to after.
+// before is nil for a create. For updates it returns false when no
+// revisioned field changed.
+func NewProductRevision(action string, before *Product, after Product, actorID uint) (ProductRevision, bool) {
+	afterFields := snapshotFields(SnapshotOf(after))
+	beforeFields := map[string]interface{}{}
+	if before != nil {
+		beforeFields = snapshotFields(SnapshotOf(*before))
+	}
+
+	changes := make(map[string]FieldChange)
+	if action != RevisionDelete {
+		for field, value := range afterFields {
+			previous, existed := beforeFields[field]
+			if before == nil && isZeroValue(value) {
+				continue
+			}
+			if !existed || !reflect.DeepEqual(previous, value) {
+				changes[field] = FieldChange{Before: previous, After: value}
+			}
+		}
+		if len(changes) == 0 && action != RevisionCreate && action != RevisionRestore {
+			return ProductRevision{}, false
+		}
+	}
+
+	changesJSON, _ := json.Marshal(changes)
+	snapshotJSON, _ := json.Marshal(SnapshotOf(after))
+	revision := ProductRevision{
+		Uuid:      uuid.New(),
+		ProductID: after.ID,
+		Action:    action,
+		Changes:   string(changesJSON),
+		Snapshot:  string(snapshotJSON),
+	}
+	if actorID != 0 {
+		revision.ActorID = &actorID
+	}
+	return revision, true
+}
+
+// RecordProductRevision saves the revision of a change from before to after,
+// if there is one. Call it in the transaction that saves the change.
+func RecordProductRevision(tx *gorm.DB, action string, before *Product, after Product, actorID uint) error {
+	revision, changed := NewProductRevision(action, before, after, actorID)
+	if !changed {
+		return nil
+	}
+	return tx.Create(&revision).Error
+}
+
+// ProductSnapshot decodes the state of the product after the revision
+func (revision ProductRevision) ProductSnapshot() (ProductSnapshot, error) {
+	var snapshot ProductSnapshot
+	err := json.Unmarshal([]byte(revision.Snapshot), &snapshot)
+	return snapshot, err
+}
+
+// PublicChanges decodes the changed fields, leaving out internal ones
+func (revision ProductRevision) PublicChanges() map[string]FieldChange {
+	changes := make(map[string]FieldChange)
+	json.Unmarshal([]byte(revision.Changes), &changes)
+	for field := range changes {
+		if internalSnapshotFields[field] {
+			delete(changes, field)
+		}
+	}
+	return changes
+}
+
+// taxClassCode returns the code of the preloaded tax class of a product
+func taxClassCode(product Product) string {
+	if product.TaxClassRef != nil {
+		return product.TaxClassRef.Code
+	}
+	return ""
+}
+
+// snapshotFields converts a snapshot to its JSON field values
+func snapshotFields(snapshot ProductSnapshot) map[string]interface{} {
+	data, _ := json.Marshal(snapshot)
+	fields := make(map[string]interface{})
+	json.Unmarshal(data, &fields)
+	return fields
+}
+
+func isZeroValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case float64:
+		return v == 0
+	case bool:
+		return !v
+	}
+	return false
+}
+
+// RevisionActor identifies the user who made a change
+type RevisionActor struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+// ProductRevisionResponse represents a revision in API responses
+type ProductRevisionResponse struct {
+	ID           uuid.UUID              `json:"id"`
+	Action       string                 `json:"action"`
+	Changes      map[string]FieldChange `json:"changes"`
+	Actor        *RevisionActor         `json:"actor"` // null for system changes such as scheduled publishing
+	RestoredFrom *uuid.UUID             `json:"restored_from,omitempty"`
+	CreatedAt    time.Time              `json:"created_at"`
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// Product statuses. Only active products are listed publicly.
+const (
+	ProductStatusDraft        = "draft"
+	ProductStatusActive       = "active"
+	ProductStatusInactive     = "inactive"
+	ProductStatusDiscontinued = "discontinued"
+)
+
+// productStatusTransitions lists the statuses each status may change to.
+// Discontinued is final.
+var productStatusTransitions = map[string][]string{
+	ProductStatusDraft:        {ProductStatusActive},
+	ProductStatusActive:       {ProductStatusInactive, ProductStatusDiscontinued},
+	ProductStatusInactive:     {ProductStatusActive, ProductStatusDiscontinued},
+	ProductStatusDiscontinued: {},
+}
+
+// ProductStatusAllowsTransition reports whether a product may move from one
+// status to another. Keeping the current status is always allowed.
+func ProductStatusAllowsTransition(from, to string) bool {
+	if from == to {
+		return true
+	}
+	for _, next := range productStatusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidProductStatus reports whether status is a known product status
+func ValidProductStatus(status string) bool {
+	_, ok := productStatusTransitions[status]
+	return ok
+}
+
+// ProductStatusTransitions returns the statuses a product may move to
+func ProductStatusTransitions(from string) []string {
+	return append([]string{}, productStatusTransitions[from]...)
+}
+
+// OptionalTime is a JSON time field that tells an explicit null apart from
+// an absent field: Set is true when the field was present, Time is nil when
+// it was null.
+type OptionalTime struct {
+	Set  bool
+	Time *time.Time
+}
+
+func (o *OptionalTime) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if bytes.Equal(data, []byte("null")) {
+		o.Time = nil
+		return nil
+	}
+
+	var t time.Time
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	o.Time = &t
+	return nil
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Stock reservation statuses
+const (
+	ReservationActive    = "active"    // holds stock until it expires
+	ReservationConfirmed = "confirmed" // the held stock has been sold
+	ReservationReleased  = "released"  // given back before it expired
+	ReservationExpired   = "expired"   // given back by the reservation expirer
+)
+
+// StockReservation holds stock of a product or variant in a warehouse, e.g.
+// during checkout. Held stock stays on hand but is no longer available
+// until the reservation is confirmed, which sells it, or released.
+type StockReservation struct {
+	ID          uint               `gorm:"primaryKey"`
+	Uuid        uuid.UUID          `gorm:"type:char(36);uniqueIndex"`
+	ProductID   uint               `gorm:"not null;index"`
+	Product     *Product           `gorm:"foreignKey:ProductID"`
+	VariantID   *uint              `gorm:"index"`
+	Variant     *ProductVariant    `gorm:"foreignKey:VariantID"`
+	WarehouseID uint               `gorm:"not null;index"`
+	Warehouse   *Warehouse         `gorm:"foreignKey:WarehouseID"`
+	Quantity    int                `gorm:"not null"`
+	Status      string             `gorm:"size:20;not null;index:idx_stock_reservation_status_expiry,priority:1"`
+	ExpiresAt   time.Time          `gorm:"not null;index:idx_stock_reservation_status_expiry,priority:2"`
+	Reference   string             `gorm:"size:100;index"` // e.g. a cart or order number
+	MovementID  *uint              // the sell movement of a confirmed reservation
+	Movement    *InventoryMovement `gorm:"foreignKey:MovementID"`
+	ActorID     *uint
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// StockReservationRequest holds stock in a warehouse, given by ID or code,
+// or in the first active warehouse with enough available stock, the default
+// one first
+type StockReservationRequest struct {
+	ProductID string `json:"product_id" binding:"required,uuid"`
+	VariantID string `json:"variant_id" binding:"omitempty,uuid"`
+	Warehouse string `json:"warehouse"`
+	Quantity  int    `json:"quantity" binding:"required,min=1"`
+	TTL       int    `json:"ttl" binding:"omitempty,min=1,max=86400"` // seconds, defaults to RESERVATION_TTL
+	Reference string `json:"reference" binding:"max=100"`
+}
+
+type StockReservationResponse struct {
+	ID         uuid.UUID     `json:"id"`
+	ProductID  uuid.UUID     `json:"product_id"`
+	VariantID  *uuid.UUID    `json:"variant_id"`
+	Warehouse  WarehouseInfo `json:"warehouse"`
+	Quantity   int           `json:"quantity"`
+	Status     string        `json:"status"`
+	ExpiresAt  time.Time     `json:"expires_at"`
+	Reference  string        `json:"reference"`
+	MovementID *uuid.UUID    `json:"movement_id"` // the sell movement once confirmed
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+}
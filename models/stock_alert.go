@@ -0,0 +1,80 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StockAlert records that the stock of a product, including its variants,
+// dropped to its reorder threshold. The alert is resolved when movements
+// take the stock above the threshold again.
+type StockAlert struct {
+	ID         uint       `gorm:"primaryKey"`
+	Uuid       uuid.UUID  `gorm:"type:char(36);uniqueIndex"`
+	ProductID  uint       `gorm:"not null;index"`
+	Product    *Product   `gorm:"foreignKey:ProductID"`
+	Stock      int        `gorm:"not null"` // when the alert was raised
+	Threshold  int        `gorm:"not null"`
+	ResolvedAt *time.Time `gorm:"index"`
+	CreatedAt  time.Time
+}
+
+type StockAlertResponse struct {
+	ID            uuid.UUID              `json:"id"`
+	ProductID     uuid.UUID              `json:"product_id"`
+	Name          string                 `json:"name"`
+	SKU           string                 `json:"sku"`
+	Stock         int                    `json:"stock"`
+	Threshold     int                    `json:"threshold"`
+	ResolvedAt    *time.Time             `json:"resolved_at"`
+	CreatedAt     time.Time              `json:"created_at"`
+	Notifications []NotificationResponse `json:"notifications"`
+}
+
+// NotificationResponse is the delivery state of a notification
+type NotificationResponse struct {
+	Channel   string     `json:"channel"`
+	Recipient string     `json:"recipient"`
+	Status    string     `json:"status"`
+	Attempts  int        `json:"attempts"`
+	LastError string     `json:"last_error"`
+	SentAt    *time.Time `json:"sent_at"`
+}
+
+// LowStockProduct is a product whose stock, including its variants, is at
+// or below its reorder threshold
+type LowStockProduct struct {
+	ProductID  uuid.UUID  `json:"product_id"`
+	Name       string     `json:"name"`
+	SKU        string     `json:"sku"`
+	Stock      int        `json:"stock"`
+	Threshold  int        `json:"threshold"`
+	Shortfall  int        `json:"shortfall"`   // threshold minus stock
+	AlertID    *uuid.UUID `json:"alert_id"`    // the open alert, if a movement raised one
+	AlertSince *time.Time `json:"alert_since"` // when it was raised
+}
+
+// OptionalInt is a JSON integer field that tells an explicit null apart
+// from an absent field, like OptionalTime
+type OptionalInt struct {
+	Set   bool
+	Value *int
+}
+
+func (o *OptionalInt) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		o.Value = nil
+		return nil
+	}
+
+	var value int
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	o.Value = &value
+	return nil
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Price modes: whether product prices include tax
+const (
+	TaxInclusive = "inclusive"
+	TaxExclusive = "exclusive"
+)
+
+// TaxClass groups products that are taxed alike, e.g. standard, reduced or
+// exempt. Products without a class use the class named by
+// DEFAULT_TAX_CLASS.
+type TaxClass struct {
+	ID          uint      `gorm:"primaryKey" json:"-"`
+	Uuid        uuid.UUID `gorm:"type:char(36);uniqueIndex" json:"id"`
+	Code        string    `gorm:"size:50;not null;uniqueIndex" json:"code"`
+	Name        string    `gorm:"size:100;not null" json:"name"`
+	Description string    `gorm:"type:text" json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TaxRate is the rate of a tax class in a region from EffectiveFrom until
+// the next rate of the class and region takes effect. Regions are ISO 3166
+// codes: a country such as ID, or a subdivision such as ID-JK, which falls
+// back to the rate of its country.
+type TaxRate struct {
+	ID            uint      `gorm:"primaryKey" json:"-"`
+	Uuid          uuid.UUID `gorm:"type:char(36);uniqueIndex" json:"id"`
+	TaxClassID    uint      `gorm:"not null;uniqueIndex:idx_tax_rate_class_region_date,priority:1" json:"-"`
+	Region        string    `gorm:"size:10;not null;uniqueIndex:idx_tax_rate_class_region_date,priority:2" json:"region"`
+	Name          string    `gorm:"size:100;not null" json:"name"`          // shown on invoices, e.g. PPN
+	Rate          string    `gorm:"type:decimal(7,4);not null" json:"rate"` // percent, e.g. "11"
+	EffectiveFrom time.Time `gorm:"not null;uniqueIndex:idx_tax_rate_class_region_date,priority:3" json:"effective_from"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type TaxClassRequest struct {
+	Code        string `json:"code" binding:"required,max=50"`
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description"`
+}
+
+type TaxRateRequest struct {
+	Region        string      `json:"region" binding:"required,max=10"`
+	Name          string      `json:"name" binding:"required,max=100"`
+	Rate          json.Number `json:"rate" binding:"required"` // percent
+	EffectiveFrom *time.Time  `json:"effective_from"`          // defaults to now
+}
+
+type TaxClassResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Code        string    `json:"code"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Rates       []TaxRate `json:"rates"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TaxBreakdown splits a price into its net amount, tax and gross amount.
+// Rate is in percent; it is "0" when no rate applies.
+type TaxBreakdown struct {
+	Net       Money  `json:"net"`
+	Tax       Money  `json:"tax"`
+	Gross     Money  `json:"gross"`
+	Rate      string `json:"rate"`
+	TaxName   string `json:"tax_name,omitempty"`
+	TaxClass  string `json:"tax_class,omitempty"`
+	Region    string `json:"region"`
+	Inclusive bool   `json:"inclusive"` // whether the price the breakdown was made from included tax
+}
+
+// TaxCalculationRequest asks for the tax of products, as a cart or order
+// would
+type TaxCalculationRequest struct {
+	Region string               `json:"region" binding:"omitempty,max=10"` // default DEFAULT_TAX_REGION
+	Items  []TaxCalculationItem `json:"items" binding:"required,min=1,dive"`
+}
+
+type TaxCalculationItem struct {
+	ProductID string `json:"product_id" binding:"required,uuid"`
+	Quantity  int    `json:"quantity" binding:"omitempty,min=1"` // default 1
+}
+
+type TaxCalculationLine struct {
+	ProductID uuid.UUID    `json:"product_id"`
+	Quantity  int          `json:"quantity"`
+	UnitPrice Money        `json:"unit_price"`
+	Tax       TaxBreakdown `json:"tax"` // of the whole line
+}
+
+// TaxTotal sums the lines of a tax calculation in one currency
+type TaxTotal struct {
+	Net   Money `json:"net"`
+	Tax   Money `json:"tax"`
+	Gross Money `json:"gross"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Upload session statuses
+const (
+	UploadSessionPending    = "pending"
+	UploadSessionProcessing = "processing"
+	UploadSessionCompleted  = "completed"
+	UploadSessionFailed     = "failed"
+	UploadSessionExpired    = "expired"
+	UploadSessionCancelled  = "cancelled"
+)
+
+// UploadSession tracks a resumable (tus) image upload. Received chunks are
+// stored as separate objects under ChunkPrefix until the upload is complete.
+type UploadSession struct {
+	gorm.Model
+	Uuid        uuid.UUID `gorm:"type:char(36);uniqueIndex" json:"id"`
+	ProductID   uint      `gorm:"not null;index" json:"-"`
+	Filename    string    `json:"filename"`
+	Private     bool      `json:"private"`
+	Length      int64     `gorm:"not null" json:"length"`
+	Offset      int64     `gorm:"not null;default:0" json:"offset"`
+	ChunkCount  int       `gorm:"not null;default:0" json:"chunk_count"`
+	Status      string    `gorm:"size:20;not null;default:pending;index" json:"status"`
+	Error       string    `json:"error,omitempty"`
+	ImagePath   string    `json:"-"`
+	ExpiresAt   time.Time `gorm:"index" json:"expires_at"`
+	CreatedBy   uint      `gorm:"index" json:"-"`
+	ChunkPrefix string    `json:"-"`
+}
+
+// UploadSessionResponse is the JSON view of an upload session
+type UploadSessionResponse struct {
+	ID        uuid.UUID `json:"id"`
+	ProductID uuid.UUID `json:"product_id"`
+	Filename  string    `json:"filename"`
+	Length    int64     `json:"length"`
+	Offset    int64     `json:"offset"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	ImageURL  string    `json:"image_url,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
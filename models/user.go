@@ -7,8 +7,9 @@ import (
 
 type User struct {
 	gorm.Model
-	Uuid     uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
-	Name     string    `json:"name"`
-	Email    string    `json:"email" gorm:"unique"`
-	Password string    `json:"-"`
+	Uuid          uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	Name          string    `json:"name"`
+	Email         string    `json:"email" gorm:"unique"`
+	Password      string    `json:"-"`
+	CustomerGroup string    `json:"customer_group" gorm:"size:50;index"` // e.g. wholesale, selects the PriceList prices of the user
 }
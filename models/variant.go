@@ -0,0 +1,96 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProductOption is an option type of a product, e.g. "Size" or "Colour"
+type ProductOption struct {
+	ID        uint                 `gorm:"primaryKey" json:"-"`
+	ProductID uint                 `gorm:"not null;index" json:"-"`
+	Name      string               `gorm:"size:100;not null" json:"name"`
+	Position  int                  `gorm:"not null;default:0" json:"position"`
+	Values    []ProductOptionValue `json:"values"`
+	CreatedAt time.Time            `json:"-"`
+	UpdatedAt time.Time            `json:"-"`
+}
+
+// ProductOptionValue is one value of an option type, e.g. "XL" for "Size"
+type ProductOptionValue struct {
+	ID              uint      `gorm:"primaryKey" json:"-"`
+	ProductOptionID uint      `gorm:"not null;index" json:"-"`
+	Value           string    `gorm:"size:100;not null" json:"value"`
+	Position        int       `gorm:"not null;default:0" json:"position"`
+	CreatedAt       time.Time `json:"-"`
+	UpdatedAt       time.Time `json:"-"`
+
+	ProductOption *ProductOption `json:"-"`
+}
+
+// ProductVariant is a purchasable combination of option values with its own
+// SKU, stock and image. A nil PriceAmount falls back to the product price.
+// Variants are deleted permanently so their SKU and option combination can
+// be reused.
+type ProductVariant struct {
+	ID          uint                 `gorm:"primaryKey" json:"-"`
+	Uuid        uuid.UUID            `gorm:"type:char(36);uniqueIndex" json:"id"`
+	ProductID   uint                 `gorm:"not null;uniqueIndex:idx_product_variant_options" json:"-"`
+	SKU         string               `gorm:"size:100;not null;uniqueIndex" json:"sku"`
+	PriceAmount *int64               `json:"-"` // price override in minor units of the product currency
+	Stock       int                  `gorm:"not null;default:0" json:"stock"`
+	OptionKey   string               `gorm:"size:255;not null;uniqueIndex:idx_product_variant_options" json:"-"` // normalised option combination, see VariantOptionKey
+	Options     []ProductOptionValue `gorm:"many2many:product_variant_options" json:"-"`
+	ImagePath   string               `json:"-"`
+	ImageURL    string               `json:"image_url"`
+	ImageHash   string               `gorm:"type:char(64)" json:"image_hash"`
+	Status      string               `gorm:"size:20;not null;default:active" json:"status"`
+	Position    int                  `gorm:"not null;default:0" json:"position"`
+	CreatedBy   uint                 `json:"-"`
+	UpdatedBy   uint                 `json:"-"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+}
+
+type ProductVariantResponse struct {
+	ID            uuid.UUID         `json:"id"`
+	SKU           string            `json:"sku"`
+	Price         Money             `json:"price"`          // effective price
+	PriceOverride *Money            `json:"price_override"` // nil when the product price applies
+	Stock         int               `json:"stock"`
+	Options       map[string]string `json:"options"`
+	ImageURL      string            `json:"image_url"`
+	ImageHash     string            `json:"image_hash"`
+	Status        string            `json:"status"`
+	Position      int               `json:"position"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// VariantSummary aggregates a product's variants for listings
+type VariantSummary struct {
+	Count      int64 `json:"count"`
+	MinPrice   Money `json:"min_price"`
+	MaxPrice   Money `json:"max_price"`
+	TotalStock int64 `json:"total_stock"`
+}
+
+type ProductVariantCreateRequest struct {
+	SKU      string            `json:"sku" binding:"required"`
+	Price    *Money            `json:"price" binding:"omitempty,min=0"` // in the product currency
+	Stock    int               `json:"stock" binding:"min=0"`
+	Options  map[string]string `json:"options" binding:"required,min=1"`
+	Status   string            `json:"status" binding:"omitempty,oneof=active inactive"`
+	Position int               `json:"position"`
+}
+
+type ProductVariantUpdateRequest struct {
+	SKU        string            `json:"sku"`
+	Price      *Money            `json:"price,omitempty" binding:"omitempty,min=0"`
+	ResetPrice bool              `json:"reset_price"` // drop the override and use the product price
+	Stock      *int              `json:"stock,omitempty" binding:"omitempty,min=0"`
+	Options    map[string]string `json:"options"`
+	Status     string            `json:"status" binding:"omitempty,oneof=active inactive"`
+	Position   *int              `json:"position,omitempty"`
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Warehouse is a location that holds stock. Movements that name no
+// warehouse use the default warehouse, of which there is exactly one.
+// Inactive warehouses keep their stock but don't count as available.
+type Warehouse struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	Uuid      uuid.UUID `gorm:"type:char(36);uniqueIndex" json:"id"`
+	Code      string    `gorm:"size:50;not null;uniqueIndex" json:"code"`
+	Name      string    `gorm:"size:100;not null" json:"name"`
+	Address   string    `gorm:"type:text" json:"address"`
+	Active    bool      `gorm:"not null" json:"active"`
+	IsDefault bool      `gorm:"not null" json:"is_default"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// StockLevel is the stock of a product, or of one of its variants, in a
+// warehouse. Product.Stock and ProductVariant.Stock are the sums of their
+// levels; both only change through inventory movements. Reserved is the
+// part of Quantity held by active reservations.
+type StockLevel struct {
+	ID          uint       `gorm:"primaryKey"`
+	WarehouseID uint       `gorm:"not null;uniqueIndex:idx_stock_level,priority:1"`
+	Warehouse   *Warehouse `gorm:"foreignKey:WarehouseID"`
+	ProductID   uint       `gorm:"not null;uniqueIndex:idx_stock_level,priority:2;index"`
+	VariantID   uint       `gorm:"not null;default:0;uniqueIndex:idx_stock_level,priority:3"` // 0 for the stock of the product itself
+	Quantity    int        `gorm:"not null;default:0"`
+	Reserved    int        `gorm:"not null;default:0"`
+	UpdatedAt   time.Time
+}
+
+// Available is the stock that is neither sold nor reserved
+func (level StockLevel) Available() int {
+	if level.Quantity < level.Reserved {
+		return 0
+	}
+	return level.Quantity - level.Reserved
+}
+
+// StockTransfer moves stock of a product or variant from one warehouse to
+// another. It is booked as two transfer movements that share its ID.
+type StockTransfer struct {
+	ID              uint            `gorm:"primaryKey"`
+	Uuid            uuid.UUID       `gorm:"type:char(36);uniqueIndex"`
+	ProductID       uint            `gorm:"not null;index"`
+	Product         *Product        `gorm:"foreignKey:ProductID"`
+	VariantID       *uint           `gorm:"index"`
+	Variant         *ProductVariant `gorm:"foreignKey:VariantID"`
+	FromWarehouseID uint            `gorm:"not null;index"`
+	FromWarehouse   *Warehouse      `gorm:"foreignKey:FromWarehouseID"`
+	ToWarehouseID   uint            `gorm:"not null;index"`
+	ToWarehouse     *Warehouse      `gorm:"foreignKey:ToWarehouseID"`
+	Quantity        int             `gorm:"not null"`
+	Reason          string          `gorm:"size:255"`
+	Reference       string          `gorm:"size:100;index"`
+	ActorID         *uint
+	Actor           *User `gorm:"foreignKey:ActorID"`
+	CreatedAt       time.Time
+}
+
+type WarehouseRequest struct {
+	Code      string `json:"code" binding:"required,max=50"`
+	Name      string `json:"name" binding:"required,max=100"`
+	Address   string `json:"address"`
+	Active    *bool  `json:"active"` // default true
+	IsDefault bool   `json:"is_default"`
+}
+
+// StockTransferRequest moves stock between warehouses, which are given by
+// ID or code
+type StockTransferRequest struct {
+	ProductID string `json:"product_id" binding:"required,uuid"`
+	VariantID string `json:"variant_id" binding:"omitempty,uuid"`
+	From      string `json:"from" binding:"required"`
+	To        string `json:"to" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"required,min=1"`
+	Reason    string `json:"reason" binding:"max=255"`
+	Reference string `json:"reference" binding:"max=100"`
+}
+
+// WarehouseInfo identifies a warehouse in other responses
+type WarehouseInfo struct {
+	ID   uuid.UUID `json:"id"`
+	Code string    `json:"code"`
+	Name string    `json:"name"`
+}
+
+type StockTransferResponse struct {
+	ID        uuid.UUID      `json:"id"`
+	ProductID uuid.UUID      `json:"product_id"`
+	VariantID *uuid.UUID     `json:"variant_id"`
+	From      WarehouseInfo  `json:"from"`
+	To        WarehouseInfo  `json:"to"`
+	Quantity  int            `json:"quantity"`
+	Reason    string         `json:"reason"`
+	Reference string         `json:"reference"`
+	Actor     *RevisionActor `json:"actor"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// StockLevelResponse is the stock of a product or variant in a warehouse
+type StockLevelResponse struct {
+	Warehouse WarehouseInfo `json:"warehouse"`
+	Active    bool          `json:"active"`
+	VariantID *uuid.UUID    `json:"variant_id"` // null for the product itself
+	SKU       string        `json:"sku"`
+	Quantity  int           `json:"quantity"`  // on hand
+	Reserved  int           `json:"reserved"`  // held by active reservations
+	Available int           `json:"available"` // on hand and not reserved
+}
+
+// WarehouseAvailability is the stock of a product and its variants in a
+// warehouse
+type WarehouseAvailability struct {
+	WarehouseInfo
+	Available int `json:"available"`
+}
+
+// StockAvailability sums the stock of a product and its variants that is
+// not reserved over the active warehouses
+type StockAvailability struct {
+	Available  int                     `json:"available"`
+	Warehouses []WarehouseAvailability `json:"warehouses"`
+}
+
+// Info identifies the warehouse in other responses
+func (warehouse Warehouse) Info() WarehouseInfo {
+	return WarehouseInfo{ID: warehouse.Uuid, Code: warehouse.Code, Name: warehouse.Name}
+}
@@ -0,0 +1,57 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPConfig holds the settings of an SMTP server
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPSender sends notifications as plain text emails
+type SMTPSender struct {
+	config SMTPConfig
+}
+
+// NewSMTPSender checks the settings and returns an SMTP sender. Without a
+// username the server is used without authentication.
+func NewSMTPSender(config SMTPConfig) (*SMTPSender, error) {
+	if config.Host == "" || config.From == "" {
+		return nil, errors.New("SMTP_HOST and SMTP_FROM are required for the smtp email driver")
+	}
+	return &SMTPSender{config: config}, nil
+}
+
+func (s *SMTPSender) Send(ctx context.Context, delivery Delivery) error {
+	var auth smtp.Auth
+	if s.config.Username != "" {
+		auth = smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+	}
+	address := net.JoinHostPort(s.config.Host, s.config.Port)
+	return smtp.SendMail(address, auth, s.config.From, []string{delivery.Recipient}, mailMessage(s.config.From, delivery))
+}
+
+// mailMessage formats a delivery as an RFC 5322 message
+func mailMessage(from string, delivery Delivery) []byte {
+	var message strings.Builder
+	fmt.Fprintf(&message, "From: %s\r\n", from)
+	fmt.Fprintf(&message, "To: %s\r\n", delivery.Recipient)
+	fmt.Fprintf(&message, "Subject: %s\r\n", strings.NewReplacer("\r", " ", "\n", " ").Replace(delivery.Subject))
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	message.WriteString(strings.ReplaceAll(delivery.Body, "\n", "\r\n"))
+	message.WriteString("\r\n")
+	return []byte(message.String())
+}
@@ -0,0 +1,58 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// LogSender is a local stand-in that writes notifications to the log
+type LogSender struct{}
+
+func (LogSender) Send(ctx context.Context, delivery Delivery) error {
+	log.Printf("notification %s to %s: %s", delivery.Event, delivery.Recipient, delivery.Subject)
+	return nil
+}
+
+// FileSender is a local stand-in for email that writes every message as an
+// .eml file to Dir, where it can be opened with a mail client
+type FileSender struct {
+	Dir string
+}
+
+func (s FileSender) Send(ctx context.Context, delivery Delivery) error {
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405.000000000"), delivery.Event)
+	return os.WriteFile(filepath.Join(s.Dir, name), mailMessage("notifications@localhost", delivery), 0644)
+}
+
+// Recorder is an in-memory sender. It keeps every delivery and fails with
+// Err when set.
+type Recorder struct {
+	mu         sync.Mutex
+	deliveries []Delivery
+	Err        error
+}
+
+func (r *Recorder) Send(ctx context.Context, delivery Delivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Err != nil {
+		return r.Err
+	}
+	r.deliveries = append(r.deliveries, delivery)
+	return nil
+}
+
+// Deliveries returns the deliveries recorded so far
+func (r *Recorder) Deliveries() []Delivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Delivery(nil), r.deliveries...)
+}
@@ -0,0 +1,233 @@
+// Package notifications records messages about events, such as low stock,
+// and delivers them by webhook or email. Notify records a notification per
+// configured recipient within the caller's transaction, so nothing is sent
+// for changes that are rolled back; Dispatch sends them afterwards and
+// retries failures.
+package notifications
+
+import (
+	"backend/config"
+	"backend/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Channels
+const (
+	ChannelWebhook = "webhook"
+	ChannelEmail   = "email"
+)
+
+// MaxAttempts is how often a notification is tried before it is marked
+// failed
+const MaxAttempts = 5
+
+// Message is an event to notify about. Subject and Body are used for email,
+// Payload is sent to webhooks as JSON.
+type Message struct {
+	Event     string
+	Reference string
+	Subject   string
+	Body      string
+	Payload   interface{}
+}
+
+// Delivery is a message addressed to one recipient
+type Delivery struct {
+	Event     string
+	Recipient string
+	Subject   string
+	Body      string
+	Payload   json.RawMessage
+}
+
+// Sender delivers notifications over a channel
+type Sender interface {
+	Send(ctx context.Context, delivery Delivery) error
+}
+
+type channel struct {
+	sender     Sender
+	recipients []string
+}
+
+var (
+	mu       sync.RWMutex
+	channels = map[string]channel{}
+)
+
+// Init configures the channels from the environment. Webhooks are posted to
+// NOTIFICATION_WEBHOOK_URLS and emails go to NOTIFICATION_EMAILS, both comma
+// separated. NOTIFICATION_WEBHOOK_DRIVER is "http" or "log" and
+// NOTIFICATION_EMAIL_DRIVER is "smtp", "file" or "log"; "file" and "log"
+// are local stand-ins that write the messages to NOTIFICATION_MAIL_DIR or
+// the log.
+func Init() error {
+	var webhook Sender
+	switch driver := config.GetEnv("NOTIFICATION_WEBHOOK_DRIVER", "http"); driver {
+	case "http":
+		webhook = NewWebhookSender(config.GetEnv("NOTIFICATION_WEBHOOK_SECRET", ""))
+	case "log":
+		webhook = LogSender{}
+	default:
+		return fmt.Errorf("unknown webhook driver %q", driver)
+	}
+
+	var email Sender
+	switch driver := config.GetEnv("NOTIFICATION_EMAIL_DRIVER", "file"); driver {
+	case "smtp":
+		smtp, err := NewSMTPSender(SMTPConfig{
+			Host:     config.GetEnv("SMTP_HOST", ""),
+			Port:     config.GetEnv("SMTP_PORT", "587"),
+			Username: config.GetEnv("SMTP_USERNAME", ""),
+			Password: config.GetEnv("SMTP_PASSWORD", ""),
+			From:     config.GetEnv("SMTP_FROM", ""),
+		})
+		if err != nil {
+			return err
+		}
+		email = smtp
+	case "file":
+		email = FileSender{Dir: config.GetEnv("NOTIFICATION_MAIL_DIR", "mail")}
+	case "log":
+		email = LogSender{}
+	default:
+		return fmt.Errorf("unknown email driver %q", driver)
+	}
+
+	Register(ChannelWebhook, webhook, splitList(config.GetEnv("NOTIFICATION_WEBHOOK_URLS", ""))...)
+	Register(ChannelEmail, email, splitList(config.GetEnv("NOTIFICATION_EMAILS", ""))...)
+	return nil
+}
+
+// Register sets the sender and recipients of a channel, replacing the
+// configured ones
+func Register(name string, sender Sender, recipients ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	channels[name] = channel{sender: sender, recipients: recipients}
+}
+
+// Notify records a pending notification of message for every recipient of
+// every channel within tx. Without recipients nothing is recorded.
+func Notify(tx *gorm.DB, message Message) error {
+	payload, err := json.Marshal(message.Payload)
+	if err != nil {
+		return err
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	now := time.Now()
+	for name, channel := range channels {
+		for _, recipient := range channel.recipients {
+			notification := models.Notification{
+				Uuid:          uuid.New(),
+				Event:         message.Event,
+				Reference:     message.Reference,
+				Channel:       name,
+				Recipient:     recipient,
+				Subject:       message.Subject,
+				Body:          message.Body,
+				Payload:       string(payload),
+				Status:        models.NotificationPending,
+				NextAttemptAt: now,
+			}
+			if err := tx.Create(&notification).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Dispatch sends the pending notifications that are due. Failed attempts
+// are retried with a growing delay until MaxAttempts. It returns the number
+// of notifications sent.
+func Dispatch(ctx context.Context, db *gorm.DB) (int, error) {
+	var pending []models.Notification
+	if err := db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", models.NotificationPending, time.Now()).
+		Order("id ASC").Limit(100).
+		Find(&pending).Error; err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, notification := range pending {
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+
+		// Claim the attempt with a conditional update, so a notification
+		// is never sent twice by concurrent dispatchers
+		attempt := notification.Attempts + 1
+		result := db.Model(&models.Notification{}).
+			Where("id = ? AND status = ? AND attempts = ?", notification.ID, models.NotificationPending, notification.Attempts).
+			Updates(map[string]interface{}{"attempts": attempt, "next_attempt_at": time.Now().Add(retryDelay(attempt))})
+		if result.Error != nil {
+			return sent, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		err := send(ctx, notification)
+		updates := map[string]interface{}{"last_error": ""}
+		switch {
+		case err == nil:
+			now := time.Now()
+			updates["status"], updates["sent_at"] = models.NotificationSent, &now
+			sent++
+		case attempt >= MaxAttempts:
+			updates["status"], updates["last_error"] = models.NotificationFailed, err.Error()
+		default:
+			updates["last_error"] = err.Error()
+		}
+		if err := db.Model(&notification).Updates(updates).Error; err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// send delivers a notification with the sender of its channel
+func send(ctx context.Context, notification models.Notification) error {
+	mu.RLock()
+	channel, ok := channels[notification.Channel]
+	mu.RUnlock()
+	if !ok || channel.sender == nil {
+		return fmt.Errorf("no sender for channel %q", notification.Channel)
+	}
+	return channel.sender.Send(ctx, Delivery{
+		Event:     notification.Event,
+		Recipient: notification.Recipient,
+		Subject:   notification.Subject,
+		Body:      notification.Body,
+		Payload:   json.RawMessage(notification.Payload),
+	})
+}
+
+// retryDelay is the wait before the attempt after attempt: 1, 2, 4, 8
+// minutes and so on
+func retryDelay(attempt int) time.Duration {
+	return time.Minute << uint(attempt-1)
+}
+
+// splitList splits a comma separated setting, dropping blank entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,52 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WebhookSender posts the payload of a notification as JSON to the
+// recipient URL. With a secret, the body is signed with HMAC-SHA256 in the
+// X-Signature-256 header as "sha256=<hex>".
+type WebhookSender struct {
+	Client *http.Client
+	Secret string
+}
+
+// NewWebhookSender returns a webhook sender with a 10 second timeout
+func NewWebhookSender(secret string) *WebhookSender {
+	return &WebhookSender{Client: &http.Client{Timeout: 10 * time.Second}, Secret: secret}
+}
+
+func (s *WebhookSender) Send(ctx context.Context, delivery Delivery) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Recipient, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Event", delivery.Event)
+	if s.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(s.Secret))
+		mac.Write(delivery.Payload)
+		request.Header.Set("X-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	response, err := s.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 1<<16))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with %s", response.Status)
+	}
+	return nil
+}
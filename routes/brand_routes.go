@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"backend/controllers"
+	"backend/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func BrandRoutes(r *gin.Engine) {
+	// Public routes (no authentication required)
+	public := r.Group("/brands")
+	{
+		public.GET("/", controllers.GetBrands)     // Get all brands
+		public.GET("/:slug", controllers.GetBrand) // Get brand by slug or ID
+	}
+
+	// Public routes that show logged-in users the products they created
+	// before they are active
+	optional := r.Group("/brands")
+	optional.Use(middlewares.OptionalAuthMiddleware())
+	{
+		optional.GET("/:slug/products", controllers.GetBrandProducts) // Get products of a brand
+	}
+
+	// Protected routes (authentication required)
+	protected := r.Group("/brands")
+	protected.Use(middlewares.AuthMiddleware())
+	{
+		protected.POST("/", controllers.CreateBrand)               // Create brand
+		protected.PUT("/:slug", controllers.UpdateBrand)           // Update brand
+		protected.DELETE("/:slug", controllers.DeleteBrand)        // Delete brand
+		protected.POST("/:slug/logo", controllers.UploadBrandLogo) // Upload brand logo
+	}
+}
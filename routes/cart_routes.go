@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"backend/controllers"
+	"backend/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CartRoutes(r *gin.Engine) {
+	// Public routes; logged-in users get their own cart, guests the cart of
+	// the X-Cart-Token header
+	cart := r.Group("/cart")
+	cart.Use(middlewares.OptionalAuthMiddleware())
+	{
+		cart.GET("", controllers.GetCart)                     // View cart
+		cart.POST("/items", controllers.AddCartItem)          // Add product or variant
+		cart.PUT("/items/:id", controllers.UpdateCartItem)    // Change quantity
+		cart.DELETE("/items/:id", controllers.RemoveCartItem) // Remove item
+	}
+}
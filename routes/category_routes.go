@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"backend/controllers"
+	"backend/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CategoryRoutes(r *gin.Engine) {
+	// Public routes (no authentication required)
+	public := r.Group("/categories")
+	{
+		public.GET("/", controllers.GetCategories)       // Get all categories (flat)
+		public.GET("/tree", controllers.GetCategoryTree) // Get nested category tree
+		public.GET("/:id", controllers.GetCategoryByID)  // Get category by ID or slug
+	}
+
+	// Protected routes (authentication required)
+	protected := r.Group("/categories")
+	protected.Use(middlewares.AuthMiddleware())
+	{
+		protected.POST("/", controllers.CreateCategory)      // Create category
+		protected.PUT("/:id", controllers.UpdateCategory)    // Update category
+		protected.DELETE("/:id", controllers.DeleteCategory) // Delete category
+	}
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"backend/controllers"
+	"backend/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ExchangeRateRoutes(r *gin.Engine) {
+	// Public routes (no authentication required)
+	public := r.Group("/exchange-rates")
+	{
+		public.GET("/", controllers.GetExchangeRates) // Get rates in effect or their history
+	}
+
+	// Protected routes (authentication required)
+	protected := r.Group("/exchange-rates")
+	protected.Use(middlewares.AuthMiddleware())
+	{
+		protected.POST("/", controllers.CreateExchangeRate)        // Save a rate
+		protected.POST("/import", controllers.ImportExchangeRates) // Load rates from a CSV file
+		protected.DELETE("/:id", controllers.DeleteExchangeRate)   // Delete a rate
+	}
+}
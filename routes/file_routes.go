@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"backend/controllers"
+	"backend/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func FileRoutes(r *gin.Engine) {
+	// Public product images and brand logos are safe to cache by browsers
+	// and CDNs
+	public := r.Group("/uploads")
+	public.Use(middlewares.CacheControl("public, max-age=86400"))
+	{
+		public.Static("/products", "./uploads/products")
+		public.Static("/brands", "./uploads/brands")
+	}
+
+	// Private uploads are only reachable through signed, expiring URLs
+	private := r.Group("/files")
+	private.Use(middlewares.OptionalAuthMiddleware())
+	{
+		private.GET("/*filepath", controllers.ServePrivateFile)
+	}
+
+	// Target of presigned uploads when using the local storage backend; the
+	// signature in the URL is the authorization
+	r.PUT("/storage/upload/*key", controllers.ReceiveDirectUpload)
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"backend/controllers"
+	"backend/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func InventoryRoutes(r *gin.Engine) {
+	// Protected routes (authentication required)
+	protected := r.Group("/inventory")
+	protected.Use(middlewares.AuthMiddleware())
+	{
+		protected.GET("/low-stock", controllers.GetLowStockProducts)                     // Products at or below their reorder threshold
+		protected.GET("/alerts", controllers.GetStockAlerts)                             // Low-stock alerts and their notifications
+		protected.GET("/transfers", controllers.GetStockTransfers)                       // List stock transfers
+		protected.POST("/transfers", controllers.CreateStockTransfer)                    // Move stock between warehouses
+		protected.POST("/reservations", controllers.CreateStockReservation)              // Hold stock for a limited time
+		protected.GET("/reservations/:id", controllers.GetStockReservation)              // Get reservation
+		protected.POST("/reservations/:id/confirm", controllers.ConfirmStockReservation) // Sell the held stock
+		protected.POST("/reservations/:id/release", controllers.ReleaseStockReservation) // Give the held stock back
+	}
+}
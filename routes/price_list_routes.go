@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"backend/controllers"
+	"backend/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func PriceListRoutes(r *gin.Engine) {
+	// Protected routes (authentication required)
+	protected := r.Group("/price-lists")
+	protected.Use(middlewares.AuthMiddleware())
+	{
+		protected.GET("/", controllers.GetPriceLists)                                 // Get all price lists
+		protected.POST("/", controllers.CreatePriceList)                              // Create price list
+		protected.GET("/:id", controllers.GetPriceList)                               // Get price list with prices
+		protected.PUT("/:id", controllers.UpdatePriceList)                            // Update price list
+		protected.DELETE("/:id", controllers.DeletePriceList)                         // Delete price list and its prices
+		protected.PUT("/:id/prices", controllers.SetPriceListPrices)                  // Add or replace product prices
+		protected.DELETE("/:id/prices/:product_id", controllers.DeletePriceListPrice) // Remove a product price
+	}
+}
@@ -11,21 +11,51 @@ func ProductRoutes(r *gin.Engine) {
 	// Public routes (no authentication required)
 	public := r.Group("/products")
 	{
-		public.GET("/", controllers.GetAllProducts)           // Get all products with pagination and filtering
-		public.GET("/:id", controllers.GetProductByID)       // Get single product by ID
 		public.GET("/categories", controllers.GetProductCategories) // Get all categories
 	}
 
+	// Public routes that show logged-in users the products they created
+	// before they are active, and their customer group prices
+	optional := r.Group("/products")
+	optional.Use(middlewares.OptionalAuthMiddleware())
+	{
+		optional.GET("/", controllers.GetAllProducts)                            // Get all products with pagination and filtering
+		optional.GET("/:id", controllers.GetProductByID)                         // Get single product by ID
+		optional.GET("/:id/variants", controllers.GetProductVariants)            // Get variants and option types
+		optional.GET("/:id/variants/:variant_id", controllers.GetProductVariant) // Get single variant
+		optional.GET("/:id/prices", controllers.GetProductPrices)                // Get pricing, price history and lowest price
+	}
+
 	// Protected routes (authentication required)
 	protected := r.Group("/products")
 	protected.Use(middlewares.AuthMiddleware())
 	{
-		protected.POST("/", controllers.CreateProduct)              // Create new product
-		protected.PUT("/:id", controllers.UpdateProduct)           // Update product
-		protected.DELETE("/:id", controllers.DeleteProduct)        // Delete product
-		protected.POST("/:id/image", controllers.UploadProductImage) // Upload product image
+		protected.POST("/", controllers.CreateProduct)                                           // Create new product
+		protected.PUT("/:id", controllers.UpdateProduct)                                         // Update product
+		protected.DELETE("/:id", controllers.DeleteProduct)                                      // Delete product
+		protected.POST("/:id/image", controllers.UploadProductImage)                             // Upload product image
+		protected.POST("/:id/image/upload-url", controllers.RequestProductImageUploadURL)        // Get presigned direct upload target
+		protected.POST("/:id/image/complete", controllers.CompleteProductImageUpload)            // Verify and attach direct upload
+		protected.GET("/:id/image/signed-url", controllers.GetProductImageSignedURL)             // Get temporary URL for a private image
+		protected.POST("/:id/variants", controllers.CreateProductVariant)                        // Create variant
+		protected.PUT("/:id/variants/:variant_id", controllers.UpdateProductVariant)             // Update variant
+		protected.DELETE("/:id/variants/:variant_id", controllers.DeleteProductVariant)          // Delete variant
+		protected.POST("/:id/variants/:variant_id/image", controllers.UploadProductVariantImage) // Upload variant image
+		protected.GET("/trash", controllers.GetProductTrash)                                     // List deleted products
+		protected.POST("/:id/restore", controllers.RestoreProduct)                               // Take a product out of the trash
+		protected.DELETE("/:id/purge", controllers.PurgeProduct)                                 // Permanently delete a trashed product
+		protected.POST("/:id/stock/adjust", controllers.AdjustProductStock)                      // Record a stock movement
+		protected.GET("/:id/stock/movements", controllers.GetStockMovements)                     // List the stock ledger
+		protected.GET("/:id/stock", controllers.GetProductStock)                                 // Stock levels per warehouse
+		protected.GET("/:id/history", controllers.GetProductHistory)                             // List revisions
+		protected.POST("/:id/history/:revision_id/restore", controllers.RestoreProductRevision)  // Restore a revision
+		protected.POST("/import", controllers.ImportProducts)                                    // Queue a CSV/XLSX import
+		protected.GET("/import/:id", controllers.GetProductImport)                               // Get import job status
+		protected.GET("/import/:id/report", controllers.GetProductImportReport)                  // Download rejected rows report
+		protected.GET("/export", controllers.ExportProducts)                                     // Stream or queue a CSV/XLSX/NDJSON export
+		protected.GET("/export/:id", controllers.GetProductExport)                               // Get export job status
+		protected.GET("/export/:id/download", controllers.DownloadProductExport)                 // Download a finished export
 	}
 
-	// Serve static files for uploaded images
-	r.Static("/uploads", "./uploads")
-}
\ No newline at end of file
+	// Uploaded files are served by FileRoutes
+}
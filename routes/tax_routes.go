@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"backend/controllers"
+	"backend/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TaxRoutes(r *gin.Engine) {
+	// Public routes (no authentication required)
+	public := r.Group("/tax-classes")
+	{
+		public.GET("/", controllers.GetTaxClasses) // Get tax classes with their rates
+	}
+	r.POST("/tax/calculate", controllers.CalculateTax) // Calculate net, tax and gross amounts of products
+
+	// Protected routes (authentication required)
+	protected := r.Group("/tax-classes")
+	protected.Use(middlewares.AuthMiddleware())
+	{
+		protected.POST("/", controllers.CreateTaxClass)                    // Create tax class
+		protected.PUT("/:id", controllers.UpdateTaxClass)                  // Update tax class
+		protected.DELETE("/:id", controllers.DeleteTaxClass)               // Delete unused tax class
+		protected.POST("/:id/rates", controllers.CreateTaxRate)            // Add or replace a regional rate
+		protected.DELETE("/:id/rates/:rate_id", controllers.DeleteTaxRate) // Delete a rate
+	}
+}
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"backend/controllers"
+	"backend/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UploadRoutes exposes the tus resumable upload protocol for product images
+func UploadRoutes(r *gin.Engine) {
+	r.OPTIONS("/tus/uploads", middlewares.TusResumable(), controllers.ResumableUploadOptions)
+
+	tus := r.Group("/tus/uploads")
+	tus.Use(middlewares.AuthMiddleware(), middlewares.TusResumable())
+	{
+		tus.POST("", controllers.CreateResumableUpload)               // Start upload session
+		tus.HEAD("/:upload_id", controllers.GetResumableUploadOffset) // Get current offset
+		tus.PATCH("/:upload_id", controllers.PatchResumableUpload)    // Append chunk
+		tus.DELETE("/:upload_id", controllers.DeleteResumableUpload)  // Cancel upload
+	}
+
+	// Plain JSON status, outside the tus protocol
+	status := r.Group("/tus/uploads")
+	status.Use(middlewares.AuthMiddleware())
+	{
+		status.GET("/:upload_id", controllers.GetResumableUpload)
+	}
+}
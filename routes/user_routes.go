@@ -14,5 +14,6 @@ func UserRoutes(r *gin.Engine) {
 	{
 		protected.GET("/:user_id", controllers.GetProfile) // gunakan parameter user_id
 		protected.GET("/all", controllers.GetAllUsers)
+		protected.PUT("/:user_id/customer-group", controllers.SetCustomerGroup) // atur customer group untuk price list
 	}
 }
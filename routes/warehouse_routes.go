@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"backend/controllers"
+	"backend/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func WarehouseRoutes(r *gin.Engine) {
+	// Protected routes (authentication required)
+	protected := r.Group("/warehouses")
+	protected.Use(middlewares.AuthMiddleware())
+	{
+		protected.GET("/", controllers.GetWarehouses)         // Get all warehouses
+		protected.POST("/", controllers.CreateWarehouse)      // Create warehouse
+		protected.PUT("/:id", controllers.UpdateWarehouse)    // Update warehouse
+		protected.DELETE("/:id", controllers.DeleteWarehouse) // Delete empty warehouse
+	}
+}
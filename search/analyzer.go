@@ -0,0 +1,164 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// fragmentSize is the approximate length of a highlighted fragment of a long
+// field such as the description
+const fragmentSize = 160
+
+// token is a normalised word and its byte range in the original text
+type token struct {
+	term       string
+	start, end int
+}
+
+// tokenize splits text into lowercase words of letters and digits
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWord && start < 0 {
+			start = i
+		}
+		if !isWord && start >= 0 {
+			tokens = append(tokens, token{term: strings.ToLower(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{term: strings.ToLower(text[start:]), start: start, end: len(text)})
+	}
+	return tokens
+}
+
+// terms returns the normalised words of text
+func terms(text string) []string {
+	tokens := tokenize(text)
+	result := make([]string, len(tokens))
+	for i, t := range tokens {
+		result[i] = t.term
+	}
+	return result
+}
+
+// maxEdits is the number of typos tolerated in a query term of the given
+// length: none for short words, one up to seven characters, two above
+func maxEdits(term string) int {
+	switch n := utf8.RuneCountInString(term); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance returns the Levenshtein distance between a and b, or max+1
+// as soon as it is known to exceed max
+func editDistance(a, b string, max int) int {
+	ra, rb := []rune(a), []rune(b)
+	if diff := len(ra) - len(rb); diff > max || -diff > max {
+		return max + 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// highlight wraps the words of text accepted by match in <mark> tags. Text
+// longer than fragmentSize is cut to a window around the first match. The
+// result is HTML-escaped; ok is false when nothing matched.
+func highlight(text string, match func(term string) bool) (fragment string, ok bool) {
+	var matches []token
+	for _, t := range tokenize(text) {
+		if match(t.term) {
+			matches = append(matches, t)
+		}
+	}
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	// Choose a window around the first match, aligned to word boundaries
+	from, to := 0, len(text)
+	if len(text) > fragmentSize {
+		from = max(0, matches[0].start-fragmentSize/4)
+		to = min(len(text), from+fragmentSize)
+		for from > 0 && !utf8.RuneStart(text[from]) {
+			from--
+		}
+		for to < len(text) && !utf8.RuneStart(text[to]) {
+			to++
+		}
+		if from > 0 {
+			if i := strings.IndexByte(text[from:], ' '); i >= 0 && from+i < matches[0].start {
+				from += i + 1
+			}
+		}
+		if to < len(text) {
+			if i := strings.LastIndexByte(text[from:to], ' '); i > 0 && from+i >= matches[0].end {
+				to = from + i
+			}
+		}
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	pos := from
+	for _, m := range matches {
+		if m.start < from || m.end > to {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:m.start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[m.start:m.end]))
+		b.WriteString("</mark>")
+		pos = m.end
+	}
+	b.WriteString(html.EscapeString(text[pos:to]))
+	if to < len(text) {
+		b.WriteString("…")
+	}
+	return b.String(), true
+}
+
+// highlightDocument highlights every field of doc that contains a word
+// accepted by match
+func highlightDocument(doc Document, match func(term string) bool) map[string]string {
+	highlights := make(map[string]string)
+	for field, text := range doc.fields() {
+		if fragment, ok := highlight(text, match); ok {
+			highlights[field] = fragment
+		}
+	}
+	return highlights
+}
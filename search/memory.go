@@ -0,0 +1,255 @@
+package search
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BM25 parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Weights of approximate matches relative to an exact term match
+const (
+	prefixMatchWeight = 0.8
+	typoMatchWeight   = 0.6 // per tolerated edit
+)
+
+// memoryFields lists the indexed fields and their boost
+var memoryFields = []struct {
+	name  string
+	boost float64
+}{
+	{FieldName, 3},
+	{FieldSKU, 3},
+	{FieldBrand, 2},
+	{FieldCategory, 1.5},
+	{FieldDescription, 1},
+}
+
+type memoryDoc struct {
+	doc     Document
+	lengths []int    // number of terms per field, in memoryFields order
+	terms   []string // distinct terms, to clean up postings on removal
+}
+
+// MemoryIndex is an embedded inverted index ranking documents with BM25F
+// over the product fields. Fuzzy queries also match word prefixes and words
+// within maxEdits typos, found by scanning the vocabulary.
+type MemoryIndex struct {
+	mu       sync.RWMutex
+	docs     map[uint]*memoryDoc
+	postings map[string]map[uint][]int // term -> document -> frequency per field
+	totalLen []int                     // summed field lengths, for averages
+}
+
+// NewMemoryIndex returns an empty index
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		docs:     make(map[uint]*memoryDoc),
+		postings: make(map[string]map[uint][]int),
+		totalLen: make([]int, len(memoryFields)),
+	}
+}
+
+func (m *MemoryIndex) Index(ctx context.Context, docs ...Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, doc := range docs {
+		m.remove(doc.ID)
+		m.add(doc)
+	}
+	return nil
+}
+
+func (m *MemoryIndex) Delete(ctx context.Context, ids ...uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range ids {
+		m.remove(id)
+	}
+	return nil
+}
+
+func (m *MemoryIndex) Rebuild(ctx context.Context, docs []Document) error {
+	fresh := NewMemoryIndex()
+	for _, doc := range docs {
+		fresh.add(doc)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.docs, m.postings, m.totalLen = fresh.docs, fresh.postings, fresh.totalLen
+	return nil
+}
+
+func (m *MemoryIndex) Search(ctx context.Context, q Query) ([]Hit, error) {
+	queryTerms := uniqueTerms(terms(q.Text))
+	if len(queryTerms) == 0 {
+		return nil, nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.docs) == 0 {
+		return nil, nil
+	}
+
+	avgLen := make([]float64, len(memoryFields))
+	for f := range memoryFields {
+		avgLen[f] = math.Max(1, float64(m.totalLen[f])/float64(len(m.docs)))
+	}
+
+	scores := make(map[uint]float64)
+	matchedTerms := make(map[uint]int)
+	highlighted := make(map[string]bool)
+
+	for _, queryTerm := range queryTerms {
+		best := make(map[uint]float64)
+		for term, weight := range m.expand(queryTerm, q.Fuzzy) {
+			highlighted[term] = true
+			postings := m.postings[term]
+			idf := math.Log(1 + (float64(len(m.docs))-float64(len(postings))+0.5)/(float64(len(postings))+0.5))
+
+			for id, freqs := range postings {
+				doc := m.docs[id]
+				var tf float64
+				for f, field := range memoryFields {
+					if freqs[f] == 0 {
+						continue
+					}
+					norm := 1 - bm25B + bm25B*float64(doc.lengths[f])/avgLen[f]
+					tf += field.boost * float64(freqs[f]) / norm
+				}
+				score := weight * idf * tf * (bm25K1 + 1) / (tf + bm25K1)
+				if score > best[id] {
+					best[id] = score
+				}
+			}
+		}
+
+		for id, score := range best {
+			scores[id] += score
+			matchedTerms[id]++
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		// Documents matching more of the query terms rank higher
+		coordination := float64(matchedTerms[id]) / float64(len(queryTerms))
+		hits = append(hits, Hit{ID: id, Score: score * coordination})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID > hits[j].ID
+	})
+
+	if limit := clampLimit(q.Limit); len(hits) > limit {
+		hits = hits[:limit]
+	}
+	for i := range hits {
+		hits[i].Highlights = highlightDocument(m.docs[hits[i].ID].doc, func(term string) bool {
+			return highlighted[term]
+		})
+	}
+	return hits, nil
+}
+
+// expand returns the indexed terms matching a query term with their weight
+func (m *MemoryIndex) expand(queryTerm string, fuzzy bool) map[string]float64 {
+	expansions := make(map[string]float64)
+	if _, ok := m.postings[queryTerm]; ok {
+		expansions[queryTerm] = 1
+	}
+	if !fuzzy {
+		return expansions
+	}
+
+	edits := maxEdits(queryTerm)
+	for term := range m.postings {
+		if term == queryTerm {
+			continue
+		}
+		if len(queryTerm) >= 2 && strings.HasPrefix(term, queryTerm) {
+			expansions[term] = prefixMatchWeight
+			continue
+		}
+		if edits > 0 {
+			if d := editDistance(queryTerm, term, edits); d <= edits {
+				expansions[term] = 1 - typoMatchWeight*float64(d)/float64(edits+1)
+			}
+		}
+	}
+	return expansions
+}
+
+// add indexes doc; the caller holds the write lock
+func (m *MemoryIndex) add(doc Document) {
+	fields := doc.fields()
+	entry := &memoryDoc{doc: doc, lengths: make([]int, len(memoryFields))}
+
+	for f, field := range memoryFields {
+		fieldTerms := terms(fields[field.name])
+		entry.lengths[f] = len(fieldTerms)
+		m.totalLen[f] += len(fieldTerms)
+
+		for _, term := range fieldTerms {
+			postings, ok := m.postings[term]
+			if !ok {
+				postings = make(map[uint][]int)
+				m.postings[term] = postings
+			}
+			freqs, ok := postings[doc.ID]
+			if !ok {
+				freqs = make([]int, len(memoryFields))
+				postings[doc.ID] = freqs
+				entry.terms = append(entry.terms, term)
+			}
+			freqs[f]++
+		}
+	}
+
+	m.docs[doc.ID] = entry
+}
+
+// remove drops a document; the caller holds the write lock
+func (m *MemoryIndex) remove(id uint) {
+	entry, ok := m.docs[id]
+	if !ok {
+		return
+	}
+
+	for f := range memoryFields {
+		m.totalLen[f] -= entry.lengths[f]
+	}
+	for _, term := range entry.terms {
+		delete(m.postings[term], id)
+		if len(m.postings[term]) == 0 {
+			delete(m.postings, term)
+		}
+	}
+	delete(m.docs, id)
+}
+
+func uniqueTerms(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := values[:0]
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
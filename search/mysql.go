@@ -0,0 +1,154 @@
+package search
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// mysqlIndexName is the FULLTEXT index over the searchable product columns
+const mysqlIndexName = "idx_products_fulltext"
+
+const mysqlMatch = "MATCH(name, description, brand, sku, category)"
+
+// MySQLIndex searches the products table through a FULLTEXT index, so it
+// never needs to be fed: Index, Delete and Rebuild are no-ops. Fuzzy queries
+// use boolean mode prefix matching, which forgives typos at the end of a
+// word only. Words shorter than innodb_ft_min_token_size (3 by default) are
+// not indexed by MySQL; exact SKUs are always matched separately.
+type MySQLIndex struct {
+	DB *gorm.DB
+}
+
+// EnsureIndex creates the FULLTEXT index when it does not exist yet
+func (m *MySQLIndex) EnsureIndex() error {
+	var count int64
+	if err := m.DB.Raw(`SELECT COUNT(*) FROM information_schema.statistics
+		WHERE table_schema = DATABASE() AND table_name = 'products' AND index_name = ?`, mysqlIndexName).
+		Scan(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	return m.DB.Exec("ALTER TABLE products ADD FULLTEXT INDEX " + mysqlIndexName + " (name, description, brand, sku, category)").Error
+}
+
+func (m *MySQLIndex) Index(ctx context.Context, docs ...Document) error { return nil }
+
+func (m *MySQLIndex) Delete(ctx context.Context, ids ...uint) error { return nil }
+
+func (m *MySQLIndex) Rebuild(ctx context.Context, docs []Document) error { return nil }
+
+func (m *MySQLIndex) Search(ctx context.Context, q Query) ([]Hit, error) {
+	queryTerms := uniqueTerms(terms(q.Text))
+	if len(queryTerms) == 0 {
+		return nil, nil
+	}
+	limit := clampLimit(q.Limit)
+	db := m.DB.WithContext(ctx)
+
+	against, mode := strings.Join(queryTerms, " "), "IN NATURAL LANGUAGE MODE"
+	if q.Fuzzy {
+		against, mode = booleanPrefixQuery(queryTerms), "IN BOOLEAN MODE"
+	}
+
+	var rows []struct {
+		ID    uint
+		Score float64
+	}
+	if err := db.Table("products").
+		Select("id, "+mysqlMatch+" AGAINST (? "+mode+") AS score", against).
+		Where("deleted_at IS NULL AND "+mysqlMatch+" AGAINST (? "+mode+")", against).
+		Order("score DESC, id DESC").
+		Limit(limit).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	// Exact SKU matches of the product or one of its variants rank first
+	sku := strings.TrimSpace(q.Text)
+	var skuMatches []uint
+	if err := db.Table("products").
+		Where("deleted_at IS NULL").
+		Where("sku = ? OR id IN (SELECT product_id FROM product_variants WHERE sku = ?)", sku, sku).
+		Pluck("id", &skuMatches).Error; err != nil {
+		return nil, err
+	}
+
+	topScore := 0.0
+	hits := make([]Hit, 0, len(rows)+len(skuMatches))
+	seen := make(map[uint]bool)
+	for _, row := range rows {
+		topScore = max(topScore, row.Score)
+	}
+	for _, id := range skuMatches {
+		hits = append(hits, Hit{ID: id, Score: topScore + 1})
+		seen[id] = true
+	}
+	for _, row := range rows {
+		if !seen[row.ID] {
+			hits = append(hits, Hit{ID: row.ID, Score: row.Score})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	if len(hits) == 0 {
+		return hits, nil
+	}
+
+	// Highlight the hits
+	ids := make([]uint, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	docs, err := LoadDocuments(db.Where("products.id IN ?", ids))
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]Document, len(docs))
+	for _, doc := range docs {
+		byID[doc.ID] = doc
+	}
+
+	match := func(term string) bool {
+		for _, queryTerm := range queryTerms {
+			if term == queryTerm || (q.Fuzzy && strings.HasPrefix(term, prefixStem(queryTerm))) {
+				return true
+			}
+		}
+		return false
+	}
+	for i := range hits {
+		hits[i].Highlights = highlightDocument(byID[hits[i].ID], match)
+	}
+	return hits, nil
+}
+
+// booleanPrefixQuery turns terms into a boolean mode query matching words
+// that start with each term or with its stem
+func booleanPrefixQuery(queryTerms []string) string {
+	parts := make([]string, 0, len(queryTerms))
+	for _, term := range queryTerms {
+		if stem := prefixStem(term); stem != term {
+			parts = append(parts, "("+term+"* "+stem+"*)")
+		} else {
+			parts = append(parts, term+"*")
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// prefixStem drops the last character of longer words so that a typo in the
+// final letter still matches as a prefix
+func prefixStem(term string) string {
+	runes := []rune(term)
+	if len(runes) < 5 {
+		return term
+	}
+	return string(runes[:len(runes)-1])
+}
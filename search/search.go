@@ -0,0 +1,211 @@
+// Package search provides ranked full-text product search. The backend is
+// chosen with SEARCH_DRIVER: "mysql" uses a FULLTEXT index on the products
+// table, "memory" keeps an embedded inverted index in the process.
+package search
+
+import (
+	"backend/config"
+	"backend/models"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// MaxCandidates caps the number of ranked hits a single search returns. The
+// product listing filters and paginates within these candidates.
+const MaxCandidates = 1000
+
+// Searchable product fields, also used as keys of Hit.Highlights
+const (
+	FieldName        = "name"
+	FieldDescription = "description"
+	FieldBrand       = "brand"
+	FieldSKU         = "sku"
+	FieldCategory    = "category"
+)
+
+// Document is the searchable view of a product
+type Document struct {
+	ID          uint
+	Name        string
+	Description string
+	Brand       string
+	SKU         string   // product SKU
+	VariantSKUs []string // SKUs of the product's variants
+	Category    string
+}
+
+// fields returns the document text per searchable field
+func (d Document) fields() map[string]string {
+	return map[string]string{
+		FieldName:        d.Name,
+		FieldDescription: d.Description,
+		FieldBrand:       d.Brand,
+		FieldSKU:         strings.TrimSpace(d.SKU + " " + strings.Join(d.VariantSKUs, " ")),
+		FieldCategory:    d.Category,
+	}
+}
+
+// Query describes a search request
+type Query struct {
+	Text  string
+	Fuzzy bool // tolerate typos and match word prefixes
+	Limit int  // maximum number of hits, capped at MaxCandidates
+}
+
+// Hit is a matching product ordered by descending Score. Highlights holds an
+// HTML-escaped fragment per matching field with matches wrapped in <mark>.
+type Hit struct {
+	ID         uint
+	Score      float64
+	Highlights map[string]string
+}
+
+// Index is a product search backend
+type Index interface {
+	// Index adds or replaces documents
+	Index(ctx context.Context, docs ...Document) error
+	// Delete removes documents by product ID
+	Delete(ctx context.Context, ids ...uint) error
+	// Rebuild replaces the whole index with docs
+	Rebuild(ctx context.Context, docs []Document) error
+	// Search returns ranked hits for q
+	Search(ctx context.Context, q Query) ([]Hit, error)
+}
+
+// Default is the configured search backend
+var Default Index
+
+// Init configures Default from the environment. The memory backend is
+// filled with the current catalogue before Init returns.
+func Init() error {
+	driver := config.GetEnv("SEARCH_DRIVER", "mysql")
+
+	switch driver {
+	case "mysql":
+		index := &MySQLIndex{DB: config.DB}
+		if err := index.EnsureIndex(); err != nil {
+			return err
+		}
+		Default = index
+		return nil
+	case "memory":
+		Default = NewMemoryIndex()
+		return RebuildAll(context.Background())
+	default:
+		return fmt.Errorf("unknown SEARCH_DRIVER %q", driver)
+	}
+}
+
+// RebuildAll reloads every live product into Default
+func RebuildAll(ctx context.Context) error {
+	docs, err := LoadDocuments(config.DB)
+	if err != nil {
+		return err
+	}
+	return Default.Rebuild(ctx, docs)
+}
+
+// Sync re-reads the given products from the database and updates Default.
+// Products that no longer exist are removed. Failures are logged, as the
+// database write that triggered the sync has already succeeded.
+func Sync(ids ...uint) {
+	if Default == nil || len(ids) == 0 {
+		return
+	}
+
+	ctx := context.Background()
+	docs, err := LoadDocuments(config.DB.Where("products.id IN ?", ids))
+	if err != nil {
+		log.Printf("search: failed to load products %v: %v", ids, err)
+		return
+	}
+
+	found := make(map[uint]bool, len(docs))
+	for _, doc := range docs {
+		found[doc.ID] = true
+	}
+	var missing []uint
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+
+	if err := Default.Index(ctx, docs...); err != nil {
+		log.Printf("search: failed to index products %v: %v", ids, err)
+	}
+	if len(missing) > 0 {
+		if err := Default.Delete(ctx, missing...); err != nil {
+			log.Printf("search: failed to remove products %v: %v", missing, err)
+		}
+	}
+}
+
+// SyncWhere calls Sync for the live products matching the condition, e.g.
+// after renaming the category they belong to
+func SyncWhere(query interface{}, args ...interface{}) {
+	if Default == nil {
+		return
+	}
+
+	var ids []uint
+	if err := config.DB.Model(&models.Product{}).Where(query, args...).Pluck("id", &ids).Error; err != nil {
+		log.Printf("search: failed to find products to sync: %v", err)
+		return
+	}
+	Sync(ids...)
+}
+
+// LoadDocuments builds documents for the live products matched by db
+func LoadDocuments(db *gorm.DB) ([]Document, error) {
+	var products []models.Product
+	if err := db.Model(&models.Product{}).
+		Select("id", "name", "description", "brand", "sku", "category").
+		Find(&products).Error; err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uint, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+
+	var variants []models.ProductVariant
+	if err := db.Session(&gorm.Session{NewDB: true}).Select("product_id", "sku").
+		Where("product_id IN ? AND sku <> ''", ids).
+		Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	variantSKUs := make(map[uint][]string)
+	for _, variant := range variants {
+		variantSKUs[variant.ProductID] = append(variantSKUs[variant.ProductID], variant.SKU)
+	}
+
+	docs := make([]Document, len(products))
+	for i, product := range products {
+		docs[i] = Document{
+			ID:          product.ID,
+			Name:        product.Name,
+			Description: product.Description,
+			Brand:       product.Brand,
+			SKU:         product.SKU,
+			VariantSKUs: variantSKUs[product.ID],
+			Category:    product.Category,
+		}
+	}
+	return docs, nil
+}
+
+func clampLimit(limit int) int {
+	if limit <= 0 || limit > MaxCandidates {
+		return MaxCandidates
+	}
+	return limit
+}
@@ -0,0 +1,76 @@
+// Package spreadsheet reads tabular files (CSV and XLSX) row by row without
+// third-party dependencies.
+package spreadsheet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+// Supported formats
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// ErrUnsupportedFormat is returned for files that are neither CSV nor XLSX
+var ErrUnsupportedFormat = errors.New("unsupported file format, use .csv or .xlsx")
+
+// RowReader returns the rows of a sheet in order. Read returns io.EOF after
+// the last row. Empty rows are returned as empty slices so that the row
+// number of every record matches the file.
+type RowReader interface {
+	Read() ([]string, error)
+}
+
+// FormatFromFilename returns the format for a file name by its extension
+func FormatFromFilename(filename string) (string, error) {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".xlsx":
+		return FormatXLSX, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// NewReader returns a RowReader for data in the given format. Only the first
+// worksheet of an XLSX workbook is read.
+func NewReader(data []byte, format string) (RowReader, error) {
+	switch format {
+	case FormatCSV:
+		return NewCSVReader(bytes.NewReader(data)), nil
+	case FormatXLSX:
+		return NewXLSXReader(bytes.NewReader(data), int64(len(data)))
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+type csvReader struct {
+	reader *csv.Reader
+}
+
+// NewCSVReader reads comma-separated rows, skipping a UTF-8 byte order mark
+// and allowing rows of different lengths
+func NewCSVReader(r io.Reader) RowReader {
+	buffered := bufio.NewReader(r)
+	if bom, err := buffered.Peek(3); err == nil && bytes.Equal(bom, []byte("\xef\xbb\xbf")) {
+		buffered.Discard(3)
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	return &csvReader{reader: reader}
+}
+
+func (r *csvReader) Read() ([]string, error) {
+	return r.reader.Read()
+}
@@ -0,0 +1,93 @@
+package spreadsheet
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RowWriter writes rows of cells. Cells may be strings, numbers, booleans,
+// times or nil; XLSX keeps numbers numeric. Strings that spreadsheet apps
+// would run as formulas are escaped, see escapeFormula. Close flushes
+// buffered output but does not close the underlying writer.
+type RowWriter interface {
+	WriteRow(cells []interface{}) error
+	Close() error
+}
+
+// NewWriter returns a RowWriter for the given format
+func NewWriter(w io.Writer, format string) (RowWriter, error) {
+	switch format {
+	case FormatCSV:
+		return NewCSVWriter(w), nil
+	case FormatXLSX:
+		return NewXLSXWriter(w)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+	record []string
+}
+
+// NewCSVWriter writes comma-separated rows
+func NewCSVWriter(w io.Writer) RowWriter {
+	return &csvWriter{writer: csv.NewWriter(w)}
+}
+
+func (w *csvWriter) WriteRow(cells []interface{}) error {
+	w.record = w.record[:0]
+	for _, cell := range cells {
+		text := formatCell(cell)
+		if _, ok := cell.(string); ok {
+			text = escapeFormula(text)
+		}
+		w.record = append(w.record, text)
+	}
+	return w.writer.Write(w.record)
+}
+
+func (w *csvWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// formatCell renders a cell value as text
+func formatCell(cell interface{}) string {
+	switch v := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// escapeFormula prefixes text that starts like a formula (=, +, -, @, tab or
+// carriage return) with a single quote, so spreadsheet apps show it as text
+// instead of running it. Plain numbers such as "-0.50" are left alone.
+func escapeFormula(text string) string {
+	if text == "" || !strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return text
+	}
+	if _, err := strconv.ParseFloat(text, 64); err == nil {
+		return text
+	}
+	return "'" + text
+}
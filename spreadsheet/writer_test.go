@@ -0,0 +1,83 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+var formulaCells = []interface{}{"=HYPERLINK(\"http://example.com\")", "+1+2", "-2+3", "@SUM(A1)", "\tcmd", "\rcmd", "-0.50", "+5", "plain", "a=b", 42, -5, nil}
+
+var escapedCells = []string{"'=HYPERLINK(\"http://example.com\")", "'+1+2", "'-2+3", "'@SUM(A1)", "'\tcmd", "'\rcmd", "-0.50", "+5", "plain", "a=b", "42", "-5", ""}
+
+func TestEscapeFormula(t *testing.T) {
+	tests := map[string]string{
+		"":        "",
+		"=1+1":    "'=1+1",
+		"+1+1":    "'+1+1",
+		"-1+1":    "'-1+1",
+		"@cmd":    "'@cmd",
+		"\t=1":    "'\t=1",
+		"\r=1":    "'\r=1",
+		"-19.99":  "-19.99",
+		"+19.99":  "+19.99",
+		"19.99":   "19.99",
+		"T-Shirt": "T-Shirt",
+		" =1":     " =1",
+	}
+	for text, want := range tests {
+		if got := escapeFormula(text); got != want {
+			t.Errorf("escapeFormula(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestCSVWriterEscapesFormulas(t *testing.T) {
+	var buffer bytes.Buffer
+	writer := NewCSVWriter(&buffer)
+	if err := writer.WriteRow(formulaCells); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	row, err := NewCSVReader(&buffer).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(row, escapedCells) {
+		t.Errorf("CSV row = %q, want %q", row, escapedCells)
+	}
+}
+
+func TestXLSXWriterEscapesFormulas(t *testing.T) {
+	var buffer bytes.Buffer
+	writer, err := NewXLSXWriter(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.WriteRow(formulaCells); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := NewXLSXReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	row, err := reader.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Trailing empty cells are not written
+	if want := escapedCells[:len(escapedCells)-1]; !reflect.DeepEqual(row, want) {
+		t.Errorf("XLSX row = %q, want %q", row, want)
+	}
+	if _, err := reader.Read(); err != io.EOF {
+		t.Errorf("second Read error = %v, want io.EOF", err)
+	}
+}
@@ -0,0 +1,289 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// maxSharedStrings bounds the shared string table of an XLSX file
+const maxSharedStrings = 1 << 20
+
+var errInvalidXLSX = errors.New("invalid xlsx file")
+
+type xlsxReader struct {
+	sheet   io.ReadCloser
+	decoder *xml.Decoder
+	strings []string
+	next    int // row number expected next, starting at 1
+	pending []string
+	ahead   int // row number of pending, 0 when none
+}
+
+// NewXLSXReader reads the first worksheet of an XLSX workbook. Cell values
+// are returned as stored: numbers in their text form, dates as serial
+// numbers and booleans as 0 or 1.
+func NewXLSXReader(r io.ReaderAt, size int64) (RowReader, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, errInvalidXLSX
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+	sheetFile, ok := files[sheetPath]
+	if !ok {
+		return nil, errInvalidXLSX
+	}
+
+	var sharedStrings []string
+	if file, ok := files["xl/sharedStrings.xml"]; ok {
+		if sharedStrings, err = readSharedStrings(file); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := sheetFile.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &xlsxReader{sheet: sheet, decoder: xml.NewDecoder(sheet), strings: sharedStrings, next: 1}, nil
+}
+
+// firstSheetPath resolves the part name of the first worksheet through the
+// workbook relationships
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	var workbook struct {
+		Sheets []struct {
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+
+	if err := decodeXMLFile(files["xl/workbook.xml"], &workbook); err != nil || len(workbook.Sheets) == 0 {
+		return "", errInvalidXLSX
+	}
+	if err := decodeXMLFile(files["xl/_rels/workbook.xml.rels"], &rels); err != nil {
+		return "", errInvalidXLSX
+	}
+
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].ID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return "", errInvalidXLSX
+}
+
+func decodeXMLFile(file *zip.File, v interface{}) error {
+	if file == nil {
+		return errInvalidXLSX
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return xml.NewDecoder(reader).Decode(v)
+}
+
+// readSharedStrings loads the shared string table. Rich text runs of a
+// string are concatenated; phonetic hints are skipped.
+func readSharedStrings(file *zip.File) ([]string, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var result []string
+	var current strings.Builder
+	inString, inText, inPhonetic := false, false, false
+
+	decoder := xml.NewDecoder(reader)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, errInvalidXLSX
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				inString = true
+				current.Reset()
+			case "rPh":
+				inPhonetic = true
+			case "t":
+				inText = inString && !inPhonetic
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				if len(result) >= maxSharedStrings {
+					return nil, fmt.Errorf("%w: too many shared strings", errInvalidXLSX)
+				}
+				result = append(result, current.String())
+				inString = false
+			case "rPh":
+				inPhonetic = false
+			case "t":
+				inText = false
+			}
+		case xml.CharData:
+			if inText {
+				current.Write(t)
+			}
+		}
+	}
+}
+
+func (r *xlsxReader) Read() ([]string, error) {
+	if r.ahead == 0 {
+		row, number, err := r.readRow()
+		if err != nil {
+			r.sheet.Close()
+			return nil, err
+		}
+		r.pending, r.ahead = row, number
+	}
+
+	// Fill gaps left by rows the file omits because they are empty
+	if r.next < r.ahead {
+		r.next++
+		return []string{}, nil
+	}
+
+	row := r.pending
+	r.pending, r.ahead = nil, 0
+	r.next++
+	return row, nil
+}
+
+// readRow decodes the next <row> element and its 1-based row number
+func (r *xlsxReader) readRow() ([]string, int, error) {
+	for {
+		token, err := r.decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil, 0, io.EOF
+			}
+			return nil, 0, errInvalidXLSX
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		number := r.next
+		if value := attr(start, "r"); value != "" {
+			if n, err := strconv.Atoi(value); err == nil && n >= r.next {
+				number = n
+			}
+		}
+
+		var row struct {
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline struct {
+					Text string `xml:"t"`
+					Runs []struct {
+						Text string `xml:"t"`
+					} `xml:"r"`
+				} `xml:"is"`
+			} `xml:"c"`
+		}
+		if err := r.decoder.DecodeElement(&row, &start); err != nil {
+			return nil, 0, errInvalidXLSX
+		}
+
+		var values []string
+		for i, cell := range row.Cells {
+			column := i
+			if cell.Ref != "" {
+				if index, ok := columnIndex(cell.Ref); ok {
+					column = index
+				}
+			}
+			for len(values) < column {
+				values = append(values, "")
+			}
+
+			var value string
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(cell.Value)
+				if err != nil || index < 0 || index >= len(r.strings) {
+					return nil, 0, fmt.Errorf("%w: bad shared string reference", errInvalidXLSX)
+				}
+				value = r.strings[index]
+			case "inlineStr":
+				value = cell.Inline.Text
+				for _, run := range cell.Inline.Runs {
+					value += run.Text
+				}
+			default:
+				value = cell.Value
+			}
+
+			if column < len(values) {
+				values[column] = value
+			} else {
+				values = append(values, value)
+			}
+		}
+		return values, number, nil
+	}
+}
+
+// columnIndex converts the letters of a cell reference such as "AB12" into a
+// 0-based column index
+func columnIndex(ref string) (int, bool) {
+	index := 0
+	letters := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		index = index*26 + int(r-'A'+1)
+		letters++
+	}
+	return index - 1, letters > 0
+}
+
+func attr(element xml.StartElement, name string) string {
+	for _, a := range element.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"io"
+)
+
+// concatReader reads several objects back to back, opening each one only
+// when the previous one is exhausted
+type concatReader struct {
+	ctx     context.Context
+	backend Storage
+	keys    []string
+	current io.ReadCloser
+}
+
+// NewConcatReader returns a reader over the concatenation of keys in order
+func NewConcatReader(ctx context.Context, backend Storage, keys []string) io.ReadCloser {
+	return &concatReader{ctx: ctx, backend: backend, keys: keys}
+}
+
+func (r *concatReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.keys) == 0 {
+				return 0, io.EOF
+			}
+			reader, err := r.backend.Open(r.ctx, r.keys[0])
+			if err != nil {
+				return 0, err
+			}
+			r.current = reader
+			r.keys = r.keys[1:]
+		}
+
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.current.Close()
+			r.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *concatReader) Close() error {
+	if r.current != nil {
+		return r.current.Close()
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"backend/config"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LocalStorage keeps objects on the local filesystem under Root. Direct
+// uploads are accepted by the API itself at /storage/upload/<key>.
+type LocalStorage struct {
+	Root    string
+	BaseURL string
+
+	mu          sync.Mutex
+	usedUploads map[string]int64 // signature of received uploads to their expiry
+}
+
+// NewLocalStorage creates the root directory if needed
+func NewLocalStorage(root, baseURL string) (*LocalStorage, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{Root: root, BaseURL: baseURL}, nil
+}
+
+func (s *LocalStorage) fullPath(key string) (string, error) {
+	if !ValidKey(key) {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return filepath.Join(s.Root, filepath.FromSlash(key)), nil
+}
+
+// Put writes body to key, creating parent directories as needed
+func (s *LocalStorage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	fullPath, err := s.fullPath(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	dst, err := os.Create(fullPath)
+	if err != nil {
+		return fmt.Errorf("failed to create destination file: %v", err)
+	}
+
+	if _, err := io.Copy(dst, body); err != nil {
+		dst.Close()
+		os.Remove(fullPath)
+		return fmt.Errorf("failed to save file: %v", err)
+	}
+
+	return dst.Close()
+}
+
+// Open returns a reader for key
+func (s *LocalStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	fullPath, err := s.fullPath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(fullPath)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+// Stat returns size and modification time of key. The content type is
+// guessed from the extension.
+func (s *LocalStorage) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	fullPath, err := s.fullPath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(fullPath)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &ObjectInfo{
+		Key:          key,
+		Size:         info.Size(),
+		ContentType:  mime.TypeByExtension(path.Ext(key)),
+		LastModified: info.ModTime(),
+	}, nil
+}
+
+// Delete removes key. Deleting a missing object is not an error.
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	fullPath, err := s.fullPath(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// List returns every object whose key starts with prefix
+func (s *LocalStorage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+
+	err := filepath.WalkDir(s.Root, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return ctx.Err()
+		}
+
+		rel, err := filepath.Rel(s.Root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, ObjectInfo{
+			Key:          key,
+			Size:         info.Size(),
+			ContentType:  mime.TypeByExtension(path.Ext(key)),
+			LastModified: info.ModTime(),
+		})
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	return objects, err
+}
+
+// PresignPut returns a signed URL served by the API's local upload handler
+func (s *LocalStorage) PresignPut(key, contentType string, ttl time.Duration) (*PresignedUpload, error) {
+	if !ValidKey(key) {
+		return nil, fmt.Errorf("invalid storage key %q", key)
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expiresAt.Unix(), 10))
+	query.Set("signature", SignLocalUpload(key, contentType, expiresAt.Unix()))
+
+	return &PresignedUpload{
+		URL:       fmt.Sprintf("%s/storage/upload/%s?%s", s.BaseURL, key, query.Encode()),
+		Method:    "PUT",
+		Headers:   map[string]string{"Content-Type": contentType},
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// ConsumeUpload marks the presigned upload with signature as used, so each
+// URL uploads once. Call ReleaseUpload when the upload then fails. Signatures are kept in memory until their URL expires,
+// so URLs still valid when the API restarts can be used once more.
+func (s *LocalStorage) ConsumeUpload(signature string, expiresAt int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().Unix()
+	for used, expiry := range s.usedUploads {
+		if expiry < now {
+			delete(s.usedUploads, used)
+		}
+	}
+	if _, ok := s.usedUploads[signature]; ok {
+		return fmt.Errorf("upload URL has already been used")
+	}
+	if s.usedUploads == nil {
+		s.usedUploads = make(map[string]int64)
+	}
+	s.usedUploads[signature] = expiresAt
+	return nil
+}
+
+// ReleaseUpload forgets that the presigned upload with signature was used,
+// so that an upload that failed can be retried with the same URL
+func (s *LocalStorage) ReleaseUpload(signature string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.usedUploads, signature)
+}
+
+// PublicURL returns the statically served URL for key
+func (s *LocalStorage) PublicURL(key string) string {
+	return fmt.Sprintf("%s/uploads/%s", s.BaseURL, key)
+}
+
+// SignLocalUpload signs a direct upload to the local backend. The method is
+// part of the payload so a signed download URL can never be used to upload.
+func SignLocalUpload(key, contentType string, expiresAt int64) string {
+	mac := hmac.New(sha256.New, config.UploadSigningKey)
+	fmt.Fprintf(mac, "PUT\n%s\n%s\n%d", key, contentType, expiresAt)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyLocalUpload checks a signature produced by SignLocalUpload
+func VerifyLocalUpload(key, contentType string, expiresAt int64, signature string) error {
+	if time.Now().Unix() > expiresAt {
+		return fmt.Errorf("upload URL has expired")
+	}
+	if !hmac.Equal([]byte(SignLocalUpload(key, contentType, expiresAt)), []byte(signature)) {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
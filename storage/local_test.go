@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConsumeUpload(t *testing.T) {
+	local := &LocalStorage{}
+	expiresAt := time.Now().Add(time.Minute).Unix()
+
+	if err := local.ConsumeUpload("first", expiresAt); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	if err := local.ConsumeUpload("first", expiresAt); err == nil {
+		t.Error("second use of the same URL was accepted")
+	}
+	if err := local.ConsumeUpload("second", expiresAt); err != nil {
+		t.Errorf("other URL: %v", err)
+	}
+
+	// A released URL can be used again
+	local.ReleaseUpload("second")
+	if err := local.ConsumeUpload("second", expiresAt); err != nil {
+		t.Errorf("released URL: %v", err)
+	}
+
+	// Expired signatures are forgotten
+	if err := local.ConsumeUpload("expired", time.Now().Add(-time.Minute).Unix()); err != nil {
+		t.Fatal(err)
+	}
+	local.ConsumeUpload("third", expiresAt)
+	if _, ok := local.usedUploads["expired"]; ok {
+		t.Error("expired signature was kept")
+	}
+}
@@ -0,0 +1,341 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Config holds the settings of an S3-compatible bucket (AWS S3, MinIO, R2)
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PublicURL is the base URL public objects are served from, e.g. a CDN.
+	// Defaults to the path-style bucket URL.
+	PublicURL string
+}
+
+// S3Storage talks to an S3-compatible API. Every request, including the
+// ones made by the API itself, is authenticated with a SigV4 presigned URL
+// so no SDK is required.
+type S3Storage struct {
+	cfg    S3Config
+	host   string
+	scheme string
+	client *http.Client
+}
+
+// NewS3Storage validates the configuration
+func NewS3Storage(cfg S3Config) (*S3Storage, error) {
+	if cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, fmt.Errorf("S3 storage requires S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY")
+	}
+
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", cfg.Endpoint)
+	}
+
+	if cfg.PublicURL == "" {
+		cfg.PublicURL = fmt.Sprintf("%s://%s/%s", endpoint.Scheme, endpoint.Host, cfg.Bucket)
+	}
+
+	return &S3Storage{
+		cfg:    cfg,
+		host:   endpoint.Host,
+		scheme: endpoint.Scheme,
+		client: &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// Put uploads body to key
+func (s *S3Storage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	signed := s.presign(http.MethodPut, key, nil, map[string]string{"content-type": contentType}, 15*time.Minute)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, signed, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload object: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to upload object: %s", resp.Status)
+	}
+	return nil
+}
+
+// Open downloads key
+func (s *S3Storage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrNotFound
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to open object: %s", resp.Status)
+	}
+}
+
+// Stat issues a HEAD request for key
+func (s *S3Storage) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	resp, err := s.do(ctx, http.MethodHead, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	default:
+		return nil, fmt.Errorf("failed to stat object: %s", resp.Status)
+	}
+
+	lastModified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return &ObjectInfo{
+		Key:          key,
+		Size:         resp.ContentLength,
+		ContentType:  resp.Header.Get("Content-Type"),
+		LastModified: lastModified,
+	}, nil
+}
+
+// Delete removes key. S3 reports success for missing keys as well.
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to delete object: %s", resp.Status)
+	}
+	return nil
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List pages through ListObjectsV2 for prefix
+func (s *S3Storage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	token := ""
+
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := s.do(ctx, http.MethodGet, "", query)
+		if err != nil {
+			return nil, err
+		}
+
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to list objects: %s", resp.Status)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode object listing: %v", err)
+		}
+
+		for _, item := range result.Contents {
+			objects = append(objects, ObjectInfo{
+				Key:          item.Key,
+				Size:         item.Size,
+				LastModified: item.LastModified,
+			})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// PresignPut returns a URL the client can PUT the object to directly. The
+// content type is part of the signature, so the client must send the same
+// Content-Type header.
+func (s *S3Storage) PresignPut(key, contentType string, ttl time.Duration) (*PresignedUpload, error) {
+	if !ValidKey(key) {
+		return nil, fmt.Errorf("invalid storage key %q", key)
+	}
+
+	return &PresignedUpload{
+		URL:       s.presign(http.MethodPut, key, nil, map[string]string{"content-type": contentType}, ttl),
+		Method:    http.MethodPut,
+		Headers:   map[string]string{"Content-Type": contentType},
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
+
+// PublicURL returns the URL public objects are served from
+func (s *S3Storage) PublicURL(key string) string {
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(s.cfg.PublicURL, "/"), key)
+}
+
+func (s *S3Storage) do(ctx context.Context, method, key string, query url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.presign(method, key, query, nil, 5*time.Minute), nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(req)
+}
+
+// presign builds an AWS Signature Version 4 query-string signed URL for a
+// path-style request to the bucket. headers lists additional headers (lower
+// case) the caller must send with the request.
+func (s *S3Storage) presign(method, key string, query url.Values, headers map[string]string, ttl time.Duration) string {
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, s.cfg.Region)
+
+	signedHeaders := map[string]string{"host": s.host}
+	for name, value := range headers {
+		signedHeaders[strings.ToLower(name)] = value
+	}
+	headerNames := make([]string, 0, len(signedHeaders))
+	for name := range signedHeaders {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+
+	params := url.Values{}
+	for name, values := range query {
+		params[name] = values
+	}
+	params.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	params.Set("X-Amz-Credential", s.cfg.AccessKey+"/"+scope)
+	params.Set("X-Amz-Date", amzDate)
+	params.Set("X-Amz-Expires", strconv.Itoa(int(ttl.Seconds())))
+	params.Set("X-Amz-SignedHeaders", strings.Join(headerNames, ";"))
+
+	canonicalURI := "/" + awsEscapePath(s.cfg.Bucket)
+	if key != "" {
+		canonicalURI += "/" + awsEscapePath(key)
+	} else {
+		canonicalURI += "/"
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, name := range headerNames {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(signedHeaders[name]) + "\n")
+	}
+
+	canonicalQuery := awsCanonicalQuery(params)
+	canonicalRequest := strings.Join([]string{
+		method,
+		canonicalURI,
+		canonicalQuery,
+		canonicalHeaders.String(),
+		strings.Join(headerNames, ";"),
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	hashedRequest := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(hashedRequest[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), date)
+	signingKey = hmacSHA256(signingKey, s.cfg.Region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	return fmt.Sprintf("%s://%s%s?%s&X-Amz-Signature=%s", s.scheme, s.host, canonicalURI, canonicalQuery, signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// awsEscape percent-encodes everything except the unreserved characters, as
+// required by SigV4
+func awsEscape(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		ch := value[i]
+		if (ch >= 'A' && ch <= 'Z') || (ch >= 'a' && ch <= 'z') || (ch >= '0' && ch <= '9') ||
+			ch == '-' || ch == '_' || ch == '.' || ch == '~' {
+			b.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+func awsEscapePath(key string) string {
+	parts := strings.Split(key, "/")
+	for i, part := range parts {
+		parts[i] = awsEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+func awsCanonicalQuery(params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, key := range keys {
+		values := append([]string(nil), params[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, awsEscape(key)+"="+awsEscape(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"backend/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when an object does not exist in the backend
+var ErrNotFound = errors.New("storage: object not found")
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+}
+
+// PresignedUpload is a time-limited request a client can use to upload an
+// object directly to the backend without going through the API process
+type PresignedUpload struct {
+	URL       string            `json:"upload_url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// Storage is implemented by every file storage backend. Keys are slash
+// separated paths such as "products/<file>.jpg".
+type Storage interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	Delete(ctx context.Context, key string) error
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	PresignPut(key, contentType string, ttl time.Duration) (*PresignedUpload, error)
+	PublicURL(key string) string
+}
+
+// Default is the backend used by the application, set up by Init
+var Default Storage
+
+// Init configures Default from STORAGE_DRIVER ("local" or "s3")
+func Init() error {
+	switch driver := config.GetEnv("STORAGE_DRIVER", "local"); driver {
+	case "local":
+		local, err := NewLocalStorage(config.GetEnv("STORAGE_LOCAL_ROOT", "uploads"), config.AppBaseURL)
+		if err != nil {
+			return err
+		}
+		Default = local
+	case "s3":
+		s3, err := NewS3Storage(S3Config{
+			Endpoint:  config.GetEnv("S3_ENDPOINT", "https://s3.amazonaws.com"),
+			Region:    config.GetEnv("S3_REGION", "us-east-1"),
+			Bucket:    config.GetEnv("S3_BUCKET", ""),
+			AccessKey: config.GetEnv("S3_ACCESS_KEY", ""),
+			SecretKey: config.GetEnv("S3_SECRET_KEY", ""),
+			PublicURL: config.GetEnv("S3_PUBLIC_URL", ""),
+		})
+		if err != nil {
+			return err
+		}
+		Default = s3
+	default:
+		return fmt.Errorf("unknown storage driver %q", driver)
+	}
+	return nil
+}
+
+// KeyFromPath converts a stored image path into a storage key. Older rows
+// store local paths such as "uploads/products/<file>", newer rows store the
+// key itself.
+func KeyFromPath(imagePath string) string {
+	return strings.TrimPrefix(filepath.ToSlash(imagePath), "uploads/")
+}
+
+// ValidKey reports whether key is a clean relative path that stays inside
+// the storage root
+func ValidKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,231 @@
+// Package tax calculates the tax of product prices with the rates of the
+// tax_rates table.
+package tax
+
+import (
+	"backend/config"
+	"backend/models"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RateScale is the number of decimal places of percentage rates
+const RateScale = 4
+
+var (
+	ErrInvalidRate   = errors.New("rate must be a percentage between 0 and 100")
+	ErrInvalidRegion = errors.New("region must be an ISO 3166 country or subdivision code, e.g. ID or ID-JK")
+)
+
+var regionPattern = regexp.MustCompile(`^[A-Z]{2}(-[A-Z0-9]{1,3})?$`)
+
+// PriceMode returns whether product prices include tax, set with
+// TAX_PRICE_MODE
+func PriceMode() string {
+	if config.GetEnv("TAX_PRICE_MODE", models.TaxExclusive) == models.TaxInclusive {
+		return models.TaxInclusive
+	}
+	return models.TaxExclusive
+}
+
+// DefaultRegion returns the region taxes are calculated for when none is
+// given, set with DEFAULT_TAX_REGION
+func DefaultRegion() string {
+	return config.GetEnv("DEFAULT_TAX_REGION", "ID")
+}
+
+// DefaultClass returns the code of the tax class of products without one,
+// set with DEFAULT_TAX_CLASS
+func DefaultClass() string {
+	return config.GetEnv("DEFAULT_TAX_CLASS", "standard")
+}
+
+// NormalizeRegion upper-cases a region code and checks its format
+func NormalizeRegion(region string) (string, error) {
+	region = strings.ToUpper(strings.TrimSpace(region))
+	if !regionPattern.MatchString(region) {
+		return "", ErrInvalidRegion
+	}
+	return region, nil
+}
+
+// ParseRate parses a percentage between 0 and 100, rounded to RateScale
+// places
+func ParseRate(value string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok || rate.Sign() < 0 || rate.Cmp(big.NewRat(100, 1)) > 0 {
+		return nil, ErrInvalidRate
+	}
+	rate, _ = new(big.Rat).SetString(rate.FloatString(RateScale))
+	return rate, nil
+}
+
+// FormatRate formats a rate without trailing zeros
+func FormatRate(rate *big.Rat) string {
+	formatted := strings.TrimRight(rate.FloatString(RateScale), "0")
+	return strings.TrimSuffix(formatted, ".")
+}
+
+// Split splits an amount into its net amount and tax at rate percent.
+// Inclusive amounts already contain the tax, exclusive ones get it added.
+// Tax is rounded half up to minor units, and net + tax of an inclusive
+// amount is always the amount. Negative amounts, e.g. refunds, split like
+// their positive counterparts.
+func Split(amount int64, rate *big.Rat, inclusive bool) (net, tax int64) {
+	hundred := big.NewRat(100, 1)
+	if inclusive {
+		// net = amount * 100 / (100 + rate)
+		divisor := new(big.Rat).Add(hundred, rate)
+		net = roundHalfUp(new(big.Rat).Quo(new(big.Rat).Mul(new(big.Rat).SetInt64(amount), hundred), divisor))
+		return net, amount - net
+	}
+	tax = roundHalfUp(new(big.Rat).Quo(new(big.Rat).Mul(new(big.Rat).SetInt64(amount), rate), hundred))
+	return amount, tax
+}
+
+// Calculator calculates taxes for a region at a point in time. It caches
+// the classes and rates it looks up, so use one per request.
+type Calculator struct {
+	db        *gorm.DB
+	region    string
+	inclusive bool
+	at        time.Time
+	classes   map[uint]*models.TaxClass
+	rates     map[uint]*models.TaxRate
+	defaultID *uint
+}
+
+// NewCalculator returns a calculator for prices in the configured
+// PriceMode
+func NewCalculator(db *gorm.DB, region string, at time.Time) *Calculator {
+	return &Calculator{
+		db:        db,
+		region:    region,
+		inclusive: PriceMode() == models.TaxInclusive,
+		at:        at,
+		classes:   make(map[uint]*models.TaxClass),
+		rates:     make(map[uint]*models.TaxRate),
+	}
+}
+
+// Calculate returns the tax breakdown of a price of a product in the given
+// tax class, or the default class when taxClassID is nil. Prices of
+// classes without a rate for the region are untaxed.
+func (c *Calculator) Calculate(price models.Money, taxClassID *uint) (models.TaxBreakdown, error) {
+	breakdown := models.TaxBreakdown{Rate: "0", Region: c.region, Inclusive: c.inclusive}
+
+	classID, err := c.classID(taxClassID)
+	if err != nil {
+		return breakdown, err
+	}
+	rate := big.NewRat(0, 1)
+	if classID != nil {
+		class, taxRate, err := c.lookup(*classID)
+		if err != nil {
+			return breakdown, err
+		}
+		breakdown.TaxClass = class.Code
+		if taxRate != nil {
+			if rate, err = ParseRate(taxRate.Rate); err != nil {
+				return breakdown, fmt.Errorf("tax rate %s: %w", taxRate.Uuid, err)
+			}
+			breakdown.Rate = FormatRate(rate)
+			breakdown.TaxName = taxRate.Name
+		}
+	}
+
+	net, tax := Split(price.Amount, rate, c.inclusive)
+	breakdown.Net = models.Money{Amount: net, Currency: price.Currency}
+	breakdown.Tax = models.Money{Amount: tax, Currency: price.Currency}
+	breakdown.Gross = models.Money{Amount: net + tax, Currency: price.Currency}
+	return breakdown, nil
+}
+
+// classID returns the class of a product, or the default class if it has
+// none. It is nil when there is no default class either.
+func (c *Calculator) classID(taxClassID *uint) (*uint, error) {
+	if taxClassID != nil {
+		return taxClassID, nil
+	}
+	if c.defaultID == nil {
+		var class models.TaxClass
+		err := c.db.Where("code = ?", DefaultClass()).First(&class).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.defaultID = new(uint) // remembered as 0: no default class
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		c.classes[class.ID] = &class
+		c.defaultID = &class.ID
+	}
+	if *c.defaultID == 0 {
+		return nil, nil
+	}
+	return c.defaultID, nil
+}
+
+// lookup returns a class with its rate for the region, which is nil when
+// the class is not taxed there
+func (c *Calculator) lookup(classID uint) (*models.TaxClass, *models.TaxRate, error) {
+	class, ok := c.classes[classID]
+	if !ok {
+		class = &models.TaxClass{}
+		if err := c.db.First(class, classID).Error; err != nil {
+			return nil, nil, err
+		}
+		c.classes[classID] = class
+	}
+
+	rate, ok := c.rates[classID]
+	if !ok {
+		var err error
+		if rate, err = EffectiveRate(c.db, classID, c.region, c.at); err != nil {
+			return nil, nil, err
+		}
+		c.rates[classID] = rate
+	}
+	return class, rate, nil
+}
+
+// EffectiveRate returns the rate of a class in effect in a region at a
+// time. Subdivisions without their own rate use the rate of their country.
+// It returns nil when the class is not taxed in the region.
+func EffectiveRate(db *gorm.DB, classID uint, region string, at time.Time) (*models.TaxRate, error) {
+	regions := []string{region}
+	if country, _, ok := strings.Cut(region, "-"); ok {
+		regions = append(regions, country)
+	}
+
+	for _, region := range regions {
+		var rate models.TaxRate
+		err := db.Where("tax_class_id = ? AND region = ? AND effective_from <= ?", classID, region, at).
+			Order("effective_from DESC").First(&rate).Error
+		if err == nil {
+			return &rate, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// roundHalfUp rounds a value to an integer, halves away from zero like
+// currency.RoundHalfUp
+func roundHalfUp(value *big.Rat) int64 {
+	// (2 * |num| + denom) / (2 * denom), with the sign of value
+	numerator := new(big.Int).Add(new(big.Int).Mul(new(big.Int).Abs(value.Num()), big.NewInt(2)), value.Denom())
+	rounded := new(big.Int).Quo(numerator, new(big.Int).Mul(value.Denom(), big.NewInt(2)))
+	if value.Sign() < 0 {
+		rounded.Neg(rounded)
+	}
+	return rounded.Int64()
+}
@@ -0,0 +1,97 @@
+package tax
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestRoundHalfUp(t *testing.T) {
+	tests := []struct {
+		num, denom int64
+		want       int64
+	}{
+		{5, 2, 3},   // 2.5
+		{7, 2, 4},   // 3.5
+		{-5, 2, -3}, // -2.5 rounds away from zero
+		{-7, 2, -4},
+		{12, 5, 2}, // 2.4
+		{13, 5, 3}, // 2.6
+		{-12, 5, -2},
+		{-13, 5, -3},
+		{4, 1, 4},
+		{0, 1, 0},
+	}
+	for _, test := range tests {
+		if got := roundHalfUp(big.NewRat(test.num, test.denom)); got != test.want {
+			t.Errorf("roundHalfUp(%d/%d) = %d, want %d", test.num, test.denom, got, test.want)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name      string
+		amount    int64
+		rate      string
+		inclusive bool
+		net, tax  int64
+	}{
+		{"exclusive USD", 1999, "11", false, 1999, 220},              // 219.89
+		{"exclusive half", 50, "5", false, 50, 3},                    // 2.5
+		{"exclusive zero rate", 1999, "0", false, 1999, 0},           //
+		{"exclusive fractional rate", 1000, "7.25", false, 1000, 73}, // 72.5
+		{"exclusive negative", -1999, "11", false, -1999, -220},
+		{"exclusive negative half", -50, "5", false, -50, -3},
+		{"inclusive USD", 11100, "11", true, 10000, 1100},
+		{"inclusive rounded", 1999, "11", true, 1801, 198}, // net 1800.9
+		{"inclusive negative", -1999, "11", true, -1801, -198},
+		{"inclusive zero rate", 1999, "0", true, 1999, 0},
+		{"exclusive JPY", 1000, "10", false, 1000, 100}, // exponent 0
+		{"inclusive JPY", 1000, "10", true, 909, 91},    // net 909.09
+		{"exclusive KWD", 1234, "5", false, 1234, 62},   // exponent 3, 61.7
+		{"inclusive KWD", 1234, "5", true, 1175, 59},    // net 1175.24
+		{"inclusive full rate", 200, "100", true, 100, 100},
+	}
+	for _, test := range tests {
+		rate, err := ParseRate(test.rate)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		net, tax := Split(test.amount, rate, test.inclusive)
+		if net != test.net || tax != test.tax {
+			t.Errorf("%s: Split(%d, %s, %v) = %d, %d, want %d, %d", test.name, test.amount, test.rate, test.inclusive, net, tax, test.net, test.tax)
+		}
+	}
+}
+
+func TestSplitInclusiveAddsUp(t *testing.T) {
+	for _, rateValue := range []string{"0", "5", "7.25", "11", "12.5", "19", "100"} {
+		rate, _ := ParseRate(rateValue)
+		for amount := int64(-1000); amount <= 1000; amount++ {
+			net, tax := Split(amount, rate, true)
+			if net+tax != amount {
+				t.Fatalf("Split(%d, %s, true) = %d + %d, want a sum of %d", amount, rateValue, net, tax, amount)
+			}
+			// The tax of the net amount is the split tax, give or take the
+			// rounding of the net amount
+			if _, exclusive := Split(net, rate, false); exclusive-tax > 1 || tax-exclusive > 1 {
+				t.Fatalf("Split(%d, %s, true) tax %d, but %d on its net amount", amount, rateValue, tax, exclusive)
+			}
+		}
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	for _, value := range []string{"-1", "100.01", "abc", ""} {
+		if _, err := ParseRate(value); err == nil {
+			t.Errorf("ParseRate(%q) accepted", value)
+		}
+	}
+	rate, err := ParseRate("7.123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := FormatRate(rate); got != "7.1235" {
+		t.Errorf("FormatRate = %s, want 7.1235", got)
+	}
+}
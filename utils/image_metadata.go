@@ -0,0 +1,270 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// StripImageMetadata removes EXIF, XMP, IPTC, comments and similar metadata
+// (which can include GPS coordinates and device serials) from an image
+// without re-encoding it, so image quality is unchanged. Colour profiles are
+// kept. contentType must be one of the sniffed image types.
+func StripImageMetadata(data []byte, contentType string) ([]byte, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEGMetadata(data)
+	case "image/png":
+		return stripPNGMetadata(data)
+	case "image/webp":
+		return stripWebPMetadata(data)
+	case "image/gif":
+		return stripGIFMetadata(data)
+	default:
+		return nil, fmt.Errorf("unsupported image type %s", contentType)
+	}
+}
+
+func stripJPEGMetadata(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, fmt.Errorf("invalid JPEG file")
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xFF, 0xD8)
+
+	i := 2
+	for i < len(data) {
+		if data[i] != 0xFF {
+			return nil, fmt.Errorf("invalid JPEG marker at offset %d", i)
+		}
+		// Markers may be preceded by any number of fill bytes
+		for i+1 < len(data) && data[i+1] == 0xFF {
+			i++
+		}
+		if i+1 >= len(data) {
+			return nil, fmt.Errorf("truncated JPEG file")
+		}
+
+		marker := data[i+1]
+		switch {
+		case marker == 0xD9: // EOI
+			return append(out, 0xFF, 0xD9), nil
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7): // standalone markers
+			out = append(out, 0xFF, marker)
+			i += 2
+			continue
+		}
+
+		if i+4 > len(data) {
+			return nil, fmt.Errorf("truncated JPEG file")
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, fmt.Errorf("invalid JPEG segment length at offset %d", i)
+		}
+		segment := data[i:end]
+
+		if marker == 0xDA { // SOS: entropy-coded data follows, copy the rest as is
+			return append(out, data[i:]...), nil
+		}
+
+		if keepJPEGSegment(marker, segment[4:]) {
+			out = append(out, segment...)
+		}
+		i = end
+	}
+
+	return nil, fmt.Errorf("truncated JPEG file")
+}
+
+// keepJPEGSegment drops APPn and comment segments except JFIF (APP0), the ICC
+// colour profile (APP2) and the Adobe colour transform (APP14)
+func keepJPEGSegment(marker byte, payload []byte) bool {
+	switch {
+	case marker == 0xFE: // COM
+		return false
+	case marker == 0xE0:
+		return true
+	case marker == 0xE2:
+		return bytes.HasPrefix(payload, []byte("ICC_PROFILE\x00"))
+	case marker == 0xEE:
+		return bytes.HasPrefix(payload, []byte("Adobe"))
+	case marker >= 0xE1 && marker <= 0xEF:
+		return false
+	default:
+		return true
+	}
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngMetadataChunks are ancillary chunks that only carry metadata
+var pngMetadataChunks = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"tIME": true,
+}
+
+func stripPNGMetadata(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, fmt.Errorf("invalid PNG file")
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+
+	i := len(pngSignature)
+	for i < len(data) {
+		if i+8 > len(data) {
+			return nil, fmt.Errorf("truncated PNG file")
+		}
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		chunkType := string(data[i+4 : i+8])
+		end := i + 12 + length // length + type + data + crc
+		if length < 0 || end > len(data) {
+			return nil, fmt.Errorf("invalid PNG chunk length at offset %d", i)
+		}
+
+		if !pngMetadataChunks[chunkType] {
+			out = append(out, data[i:end]...)
+		}
+		i = end
+
+		if chunkType == "IEND" {
+			return out, nil
+		}
+	}
+
+	return nil, fmt.Errorf("truncated PNG file")
+}
+
+func stripWebPMetadata(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, fmt.Errorf("invalid WebP file")
+	}
+
+	out := make([]byte, 12, len(data))
+	copy(out, data[:12])
+
+	i := 12
+	for i+8 <= len(data) {
+		fourCC := string(data[i : i+4])
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + 8 + size + size%2 // chunks are padded to an even size
+		if end > len(data) {
+			if end-1 == len(data) && size%2 == 1 {
+				end = len(data) // tolerate a missing final pad byte
+			} else {
+				return nil, fmt.Errorf("invalid WebP chunk length at offset %d", i)
+			}
+		}
+
+		switch fourCC {
+		case "EXIF", "XMP ":
+			// dropped
+		case "VP8X":
+			chunk := append([]byte(nil), data[i:end]...)
+			if len(chunk) > 8 {
+				chunk[8] &^= 0x08 | 0x04 // clear the EXIF and XMP flags
+			}
+			out = append(out, chunk...)
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, nil
+}
+
+func stripGIFMetadata(data []byte) ([]byte, error) {
+	if len(data) < 13 || (string(data[0:6]) != "GIF87a" && string(data[0:6]) != "GIF89a") {
+		return nil, fmt.Errorf("invalid GIF file")
+	}
+
+	// Header, logical screen descriptor and global colour table
+	i := 13
+	if data[10]&0x80 != 0 {
+		i += 3 << (int(data[10]&0x07) + 1)
+	}
+	if i > len(data) {
+		return nil, fmt.Errorf("truncated GIF file")
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:i]...)
+
+	for i < len(data) {
+		start := i
+		switch data[i] {
+		case 0x3B: // trailer
+			return append(out, 0x3B), nil
+		case 0x2C: // image descriptor, optional local colour table, image data
+			if i+10 > len(data) {
+				return nil, fmt.Errorf("truncated GIF file")
+			}
+			flags := data[i+9]
+			i += 10
+			if flags&0x80 != 0 {
+				i += 3 << (int(flags&0x07) + 1)
+			}
+			i++ // LZW minimum code size
+			end, err := skipGIFSubBlocks(data, i)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, data[start:end]...)
+			i = end
+		case 0x21: // extension
+			if i+2 > len(data) {
+				return nil, fmt.Errorf("truncated GIF file")
+			}
+			label := data[i+1]
+			end, err := skipGIFSubBlocks(data, i+2)
+			if err != nil {
+				return nil, err
+			}
+			if keepGIFExtension(label, data[i+2:end]) {
+				out = append(out, data[start:end]...)
+			}
+			i = end
+		default:
+			return nil, fmt.Errorf("invalid GIF block at offset %d", i)
+		}
+	}
+
+	return nil, fmt.Errorf("truncated GIF file")
+}
+
+// keepGIFExtension drops comments and application extensions (such as XMP)
+// except the NETSCAPE looping extension animations depend on
+func keepGIFExtension(label byte, blocks []byte) bool {
+	switch label {
+	case 0xFE: // comment
+		return false
+	case 0xFF: // application
+		return len(blocks) > 11 && (string(blocks[1:12]) == "NETSCAPE2.0" || string(blocks[1:12]) == "ANIMEXTS1.0")
+	default:
+		return true
+	}
+}
+
+// skipGIFSubBlocks returns the offset just past the block terminator
+func skipGIFSubBlocks(data []byte, i int) (int, error) {
+	for {
+		if i >= len(data) {
+			return 0, fmt.Errorf("truncated GIF file")
+		}
+		size := int(data[i])
+		i++
+		if size == 0 {
+			return i, nil
+		}
+		i += size
+	}
+}
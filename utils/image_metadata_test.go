@@ -0,0 +1,266 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// secret marks metadata that must not survive stripping
+const secret = "GPS 52.5200N 13.4050E serial 1234"
+
+// jpegSegment builds a JPEG marker segment with its length
+func jpegSegment(marker byte, payload string) []byte {
+	segment := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// pngChunk builds a PNG chunk; the CRC is not checked by the stripper
+func pngChunk(chunkType, payload string) []byte {
+	chunk := make([]byte, 4, 12+len(payload))
+	binary.BigEndian.PutUint32(chunk, uint32(len(payload)))
+	chunk = append(chunk, chunkType...)
+	chunk = append(chunk, payload...)
+	return append(chunk, 0xDE, 0xAD, 0xBE, 0xEF)
+}
+
+// webpChunk builds a RIFF chunk padded to an even size
+func webpChunk(fourCC, payload string) []byte {
+	chunk := make([]byte, 8, 9+len(payload))
+	copy(chunk, fourCC)
+	binary.LittleEndian.PutUint32(chunk[4:], uint32(len(payload)))
+	chunk = append(chunk, payload...)
+	if len(payload)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// webpFile wraps chunks in a RIFF WEBP header
+func webpFile(chunks ...[]byte) []byte {
+	file := append([]byte("RIFF\x00\x00\x00\x00WEBP"), bytes.Join(chunks, nil)...)
+	binary.LittleEndian.PutUint32(file[4:], uint32(len(file)-8))
+	return file
+}
+
+// gifExtension builds a GIF extension, splitting payload into data
+// sub-blocks
+func gifExtension(label byte, payload string) []byte {
+	extension := []byte{0x21, label}
+	for len(payload) > 0 {
+		size := min(len(payload), 255)
+		extension = append(extension, byte(size))
+		extension = append(extension, payload[:size]...)
+		payload = payload[size:]
+	}
+	return append(extension, 0)
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+var (
+	jpegJFIF = jpegSegment(0xE0, "JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00")
+	jpegICC  = jpegSegment(0xE2, "ICC_PROFILE\x00\x01\x01sRGB profile")
+	jpegDQT  = jpegSegment(0xDB, "\x00"+string(bytes.Repeat([]byte{1}, 64)))
+	jpegSOF  = jpegSegment(0xC0, "\x08\x00\x01\x00\x01\x01\x01\x11\x00")
+	// Start of scan, entropy-coded data with a stuffed 0xFF and a restart
+	// marker, end of image
+	jpegScan = concat(jpegSegment(0xDA, "\x01\x01\x00\x00\x3F\x00"), []byte{0x12, 0xFF, 0x00, 0x34, 0xFF, 0xD0, 0x56, 0xFF, 0xD9})
+
+	jpegExif      = jpegSegment(0xE1, "Exif\x00\x00MM\x00*"+secret)
+	jpegXMP       = jpegSegment(0xE1, "http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta>"+secret+"</x:xmpmeta>")
+	jpegIPTC      = jpegSegment(0xED, "Photoshop 3.0\x008BIM"+secret)
+	jpegComment   = jpegSegment(0xFE, secret)
+	jpegFakeICC   = jpegSegment(0xE2, "FPXR\x00"+secret)
+	jpegAdobe     = jpegSegment(0xEE, "Adobe\x00\x64\x00\x00\x00\x00\x01")
+	jpegFakeAdobe = jpegSegment(0xEE, "Other"+secret)
+)
+
+func TestStripJPEGMetadata(t *testing.T) {
+	tests := []struct {
+		name        string
+		input, want []byte
+	}{
+		{
+			"EXIF, XMP, IPTC and comment",
+			concat([]byte{0xFF, 0xD8}, jpegJFIF, jpegExif, jpegXMP, jpegICC, jpegIPTC, jpegComment, jpegDQT, jpegSOF, jpegScan),
+			concat([]byte{0xFF, 0xD8}, jpegJFIF, jpegICC, jpegDQT, jpegSOF, jpegScan),
+		},
+		{
+			"Adobe colour transform is kept",
+			concat([]byte{0xFF, 0xD8}, jpegAdobe, jpegFakeAdobe, jpegFakeICC, jpegDQT, jpegScan),
+			concat([]byte{0xFF, 0xD8}, jpegAdobe, jpegDQT, jpegScan),
+		},
+		{
+			"fill bytes before markers",
+			concat([]byte{0xFF, 0xD8, 0xFF, 0xFF}, jpegExif, []byte{0xFF}, jpegDQT, jpegScan),
+			concat([]byte{0xFF, 0xD8}, jpegDQT, jpegScan),
+		},
+		{
+			"no metadata",
+			concat([]byte{0xFF, 0xD8}, jpegJFIF, jpegDQT, jpegSOF, jpegScan),
+			concat([]byte{0xFF, 0xD8}, jpegJFIF, jpegDQT, jpegSOF, jpegScan),
+		},
+		{
+			"image without scan",
+			concat([]byte{0xFF, 0xD8}, jpegComment, []byte{0xFF, 0xD9}),
+			[]byte{0xFF, 0xD8, 0xFF, 0xD9},
+		},
+	}
+	for _, test := range tests {
+		got, err := StripImageMetadata(test.input, "image/jpeg")
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !bytes.Equal(got, test.want) {
+			t.Errorf("%s:\n got % x\nwant % x", test.name, got, test.want)
+		}
+	}
+}
+
+func TestStripPNGMetadata(t *testing.T) {
+	ihdr := pngChunk("IHDR", "\x00\x00\x00\x01\x00\x00\x00\x01\x08\x02\x00\x00\x00")
+	iccp := pngChunk("iCCP", "sRGB\x00\x00compressed profile")
+	idat := pngChunk("IDAT", "\x78\x9c\x63\x60\x00\x00")
+	iend := pngChunk("IEND", "")
+
+	input := concat(pngSignature, ihdr, iccp, pngChunk("eXIf", "MM\x00*"+secret), pngChunk("tEXt", "Comment\x00"+secret),
+		pngChunk("zTXt", "Author\x00\x00"+secret), pngChunk("iTXt", "XML:com.adobe.xmp\x00\x00\x00\x00\x00"+secret),
+		pngChunk("tIME", "\x07\xe8\x01\x01\x00\x00\x00"), idat, pngChunk("tEXt", "Late\x00"+secret), iend)
+	want := concat(pngSignature, ihdr, iccp, idat, iend)
+
+	got, err := StripImageMetadata(input, "image/png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("\n got % x\nwant % x", got, want)
+	}
+
+	// Anything after IEND is dropped
+	got, err = StripImageMetadata(append(want, secret...), "image/png")
+	if err != nil || !bytes.Equal(got, want) {
+		t.Errorf("trailing data: got % x, %v", got, err)
+	}
+}
+
+func TestStripWebPMetadata(t *testing.T) {
+	// VP8X flags: ICC (0x20), EXIF (0x08) and XMP (0x04)
+	vp8x := webpChunk("VP8X", "\x2c\x00\x00\x00\x00\x00\x00\x00\x00\x00")
+	vp8xStripped := webpChunk("VP8X", "\x20\x00\x00\x00\x00\x00\x00\x00\x00\x00")
+	iccp := webpChunk("ICCP", "sRGB profile")
+	vp8 := webpChunk("VP8 ", "odd sized bitstream") // padded
+
+	tests := []struct {
+		name        string
+		input, want []byte
+	}{
+		{
+			"extended file",
+			webpFile(vp8x, iccp, vp8, webpChunk("EXIF", "MM\x00*"+secret), webpChunk("XMP ", "<x:xmpmeta>"+secret+"</x:xmpmeta>")),
+			webpFile(vp8xStripped, iccp, vp8),
+		},
+		{
+			"metadata before the bitstream",
+			webpFile(vp8x, webpChunk("EXIF", secret), vp8),
+			webpFile(vp8xStripped, vp8),
+		},
+		{
+			"simple file",
+			webpFile(vp8),
+			webpFile(vp8),
+		},
+	}
+	for _, test := range tests {
+		got, err := StripImageMetadata(test.input, "image/webp")
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !bytes.Equal(got, test.want) {
+			t.Errorf("%s:\n got % x\nwant % x", test.name, got, test.want)
+		}
+	}
+
+	// A missing pad byte after the last chunk is tolerated
+	unpadded := webpFile(vp8x, webpChunk("EXIF", secret), vp8)
+	unpadded = unpadded[:len(unpadded)-1]
+	got, err := StripImageMetadata(unpadded, "image/webp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(got, []byte(secret)) || !bytes.Contains(got, []byte("odd sized bitstream")) {
+		t.Errorf("unpadded file: got % x", got)
+	}
+	if size := binary.LittleEndian.Uint32(got[4:]); int(size) != len(got)-8 {
+		t.Errorf("unpadded file: RIFF size %d, want %d", size, len(got)-8)
+	}
+}
+
+func TestStripGIFMetadata(t *testing.T) {
+	// Header and logical screen descriptor with a 2-colour global table
+	header := concat([]byte("GIF89a\x01\x00\x01\x00\x80\x00\x00"), []byte{0, 0, 0, 0xFF, 0xFF, 0xFF})
+	loop := []byte("\x21\xff\x0bNETSCAPE2.0\x03\x01\x00\x00\x00")
+	control := gifExtension(0xF9, "\x00\x00\x00\x00")
+	image := concat([]byte{0x2C, 0, 0, 0, 0, 1, 0, 1, 0, 0}, []byte{0x02, 0x02, 0x44, 0x01, 0x00})
+
+	input := concat(header, loop, gifExtension(0xFF, "XMP DataXMP<x:xmpmeta>"+secret+"</x:xmpmeta>"),
+		gifExtension(0xFE, secret+string(bytes.Repeat([]byte("x"), 300))), control, image, []byte{0x3B})
+	want := concat(header, loop, control, image, []byte{0x3B})
+
+	got, err := StripImageMetadata(input, "image/gif")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("\n got % x\nwant % x", got, want)
+	}
+}
+
+// TestStripImageMetadataMalformed feeds every truncation of each fixture, and
+// a few broken files, to the stripper: it must fail or return a file without
+// the metadata, and never panic
+func TestStripImageMetadataMalformed(t *testing.T) {
+	fixtures := map[string][]byte{
+		"image/jpeg": concat([]byte{0xFF, 0xD8}, jpegJFIF, jpegExif, jpegICC, jpegComment, jpegDQT, jpegScan),
+		"image/png":  concat(pngSignature, pngChunk("IHDR", "header"), pngChunk("eXIf", secret), pngChunk("IDAT", "data"), pngChunk("IEND", "")),
+		"image/webp": webpFile(webpChunk("VP8X", "\x0c\x00\x00\x00\x00\x00\x00\x00\x00\x00"), webpChunk("EXIF", secret), webpChunk("VP8 ", "data"), webpChunk("XMP ", secret)),
+		"image/gif":  concat([]byte("GIF89a\x01\x00\x01\x00\x00\x00\x00"), gifExtension(0xFE, secret), []byte{0x2C, 0, 0, 0, 0, 1, 0, 1, 0, 0, 0x02, 0x01, 0x00, 0x00, 0x3B}),
+	}
+	for contentType, fixture := range fixtures {
+		for size := 0; size < len(fixture); size++ {
+			got, err := StripImageMetadata(fixture[:size], contentType)
+			if err == nil && bytes.Contains(got, []byte(secret)) {
+				t.Errorf("%s truncated to %d bytes kept the metadata", contentType, size)
+			}
+		}
+	}
+
+	broken := []struct {
+		contentType string
+		data        []byte
+	}{
+		{"image/jpeg", []byte("not a jpeg")},
+		{"image/jpeg", concat([]byte{0xFF, 0xD8, 0x00}, jpegDQT)},                    // no marker
+		{"image/jpeg", concat([]byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x01}, jpegScan)}, // length below 2
+		{"image/jpeg", concat([]byte{0xFF, 0xD8, 0xFF, 0xE1, 0xFF, 0xFF}, []byte(secret))},
+		{"image/png", []byte("\x89PNG\r\n\x1a\x0a\xff\xff\xff\xffeXIf" + secret)},
+		{"image/png", concat(pngSignature, pngChunk("IHDR", "header"))}, // no IEND
+		{"image/webp", []byte("RIFF\x00\x00\x00\x00WEBX")},
+		{"image/webp", []byte("RIFF\x00\x00\x00\x00WEBPEXIF\xff\xff\xff\x7f" + secret)},
+		{"image/gif", []byte("GIF90a\x01\x00\x01\x00\x00\x00\x00")},
+		{"image/gif", []byte("GIF89a\x01\x00\x01\x00\x87\x00\x00")},                          // colour table past the end
+		{"image/gif", []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00\x21\xfe\x05" + secret[:5])}, // unterminated comment
+		{"image/gif", []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00\x99")},
+		{"image/bmp", []byte("BM")},
+	}
+	for _, test := range broken {
+		if got, err := StripImageMetadata(test.data, test.contentType); err == nil {
+			t.Errorf("%s % x: got % x, want an error", test.contentType, test.data, got)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"backend/config"
+	"backend/models"
+	"backend/storage"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// imageExtensions maps sniffed content types to the extension used in keys
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// StoreImage reads an image, strips its metadata and stores it under a key
+// derived from the SHA-256 of the cleaned bytes. Uploading bytes that are
+// already stored reuses the existing blob and only bumps its reference count.
+// Every successful call must eventually be balanced by ReleaseImage.
+func StoreImage(ctx context.Context, src io.Reader, maxSize int64, private bool) (*ImageUploadResponse, error) {
+	prefix := ProductImagePrefix
+	if private {
+		prefix = PrivateProductImagePrefix
+	}
+	return StoreImageUnder(ctx, src, maxSize, prefix, private)
+}
+
+// StoreImageUnder is StoreImage for images that live under another storage
+// prefix, such as brand logos
+func StoreImageUnder(ctx context.Context, src io.Reader, maxSize int64, prefix string, private bool) (*ImageUploadResponse, error) {
+	data, err := io.ReadAll(io.LimitReader(src, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read uploaded file: %v", err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("file size exceeds maximum limit of %dMB", maxSize>>20)
+	}
+
+	// Trust the bytes, not the filename
+	contentType := http.DetectContentType(data)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, fmt.Errorf("invalid file type. Only JPG, JPEG, PNG, GIF, and WEBP are allowed")
+	}
+
+	cleaned, err := StripImageMetadata(data, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(cleaned)
+	hash := hex.EncodeToString(sum[:])
+
+	key := path.Join(prefix, hash+ext)
+
+	err = acquireImageBlob(key, hash, int64(len(cleaned)), contentType, func() error {
+		return storage.Default.Put(ctx, key, bytes.NewReader(cleaned), int64(len(cleaned)), contentType)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store image: %v", err)
+	}
+
+	response := &ImageUploadResponse{
+		ImagePath: key,
+		ImageHash: hash,
+	}
+	if !private {
+		response.ImageURL = storage.Default.PublicURL(key)
+	}
+
+	return response, nil
+}
+
+// acquireImageBlob increments the reference count of the blob at key,
+// creating it if needed. The first reference calls write to store the file
+// before its row is committed: concurrent uploads of the same bytes wait
+// for the row, so they never refer to a file that isn't written yet, and a
+// failed write rolls the reference back.
+func acquireImageBlob(key, hash string, size int64, contentType string, write func() error) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		blob := models.ImageBlob{
+			Hash:        hash,
+			StorageKey:  key,
+			Size:        size,
+			ContentType: contentType,
+			RefCount:    1,
+		}
+
+		// The upsert keeps the row locked until commit, so the count read
+		// below cannot be changed by a concurrent upload or release
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "storage_key"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"ref_count": gorm.Expr("ref_count + 1")}),
+		}).Create(&blob).Error; err != nil {
+			return err
+		}
+
+		var refCount int
+		if err := tx.Model(&models.ImageBlob{}).
+			Where("storage_key = ?", key).
+			Select("ref_count").
+			Scan(&refCount).Error; err != nil {
+			return err
+		}
+
+		// Only the first reference writes the file; duplicates reuse it
+		if refCount == 1 {
+			return write()
+		}
+		return nil
+	})
+}
+
+// ReleaseImage drops one reference to a stored image and deletes the file
+// once nothing refers to it anymore. Images stored before content addressing
+// have no blob row and are deleted directly.
+func ReleaseImage(imagePath string) error {
+	if imagePath == "" {
+		return nil
+	}
+
+	key := storage.KeyFromPath(imagePath)
+
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var blob models.ImageBlob
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("storage_key = ?", key).
+			First(&blob).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return storage.Default.Delete(context.Background(), key)
+		}
+		if err != nil {
+			return err
+		}
+
+		if blob.RefCount > 1 {
+			return tx.Model(&blob).Update("ref_count", gorm.Expr("ref_count - 1")).Error
+		}
+
+		if err := tx.Delete(&blob).Error; err != nil {
+			return err
+		}
+
+		// Delete while still holding the row lock: a concurrent upload of the
+		// same bytes waits for this transaction and then writes the file again
+		return storage.Default.Delete(context.Background(), key)
+	})
+}
@@ -1,10 +1,14 @@
 package utils
 
 import (
+	"backend/storage"
+	"context"
 	"fmt"
 	"io"
+	"log"
 	"mime/multipart"
-	"os"
+	"net/http"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
@@ -15,20 +19,45 @@ import (
 
 const (
 	MaxFileSize = 10 << 20 // 10MB
-	UploadDir   = "uploads/products"
+
+	// MaxDirectUploadSize limits presigned uploads, which never pass through
+	// the API process and can therefore be larger
+	MaxDirectUploadSize = 50 << 20 // 50MB
+
+	// DirectUploadTTL is how long a presigned upload URL stays valid
+	DirectUploadTTL = 15 * time.Minute
+
+	// UploadTokenTTL is how long a client has to complete a direct upload
+	UploadTokenTTL = time.Hour
+
+	// ProductImagePrefix holds public product images, served statically
+	ProductImagePrefix = "products"
+
+	// PrivateProductImagePrefix holds files that are never served statically
+	// and can only be fetched through a signed URL (see SignedFileURL)
+	PrivateProductImagePrefix = "private/products"
+
+	// BrandLogoPrefix holds brand logos, served statically
+	BrandLogoPrefix = "brands"
+
+	// IncomingPrefix receives presigned uploads until they are verified and
+	// moved into content-addressed storage
+	IncomingPrefix = "incoming"
 )
 
+// allowedImageTypes maps accepted extensions to their MIME type
+var allowedImageTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+}
+
 type ImageUploadResponse struct {
 	ImagePath string `json:"image_path"`
 	ImageURL  string `json:"image_url"`
-}
-
-// InitUploadDir creates upload directory if it doesn't exist
-func InitUploadDir() error {
-	if _, err := os.Stat(UploadDir); os.IsNotExist(err) {
-		return os.MkdirAll(UploadDir, 0755)
-	}
-	return nil
+	ImageHash string `json:"image_hash"`
 }
 
 // ValidateImageFile validates uploaded image file
@@ -39,33 +68,78 @@ func ValidateImageFile(fileHeader *multipart.FileHeader) error {
 	}
 
 	// Check file extension
-	ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
-	allowedExts := map[string]bool{
-		".jpg":  true,
-		".jpeg": true,
-		".png":  true,
-		".gif":  true,
-		".webp": true,
+	return ValidateImageFilename(fileHeader.Filename)
+}
+
+// ValidateImageFilename checks that the file extension is an allowed image type
+func ValidateImageFilename(filename string) error {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if _, ok := allowedImageTypes[ext]; !ok {
+		return fmt.Errorf("invalid file type. Only JPG, JPEG, PNG, GIF, and WEBP are allowed")
 	}
 
-	if !allowedExts[ext] {
+	return nil
+}
+
+// ValidateDirectUpload validates the metadata a client announces before
+// uploading directly to storage. The object itself is checked again by
+// VerifyStoredImage once uploaded.
+func ValidateDirectUpload(filename, contentType string, size int64) error {
+	if size > MaxDirectUploadSize {
+		return fmt.Errorf("file size exceeds maximum limit of 50MB")
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	expected, ok := allowedImageTypes[ext]
+	if !ok {
 		return fmt.Errorf("invalid file type. Only JPG, JPEG, PNG, GIF, and WEBP are allowed")
 	}
+	if contentType != expected {
+		return fmt.Errorf("content type %q does not match file extension %s", contentType, ext)
+	}
 
 	return nil
 }
 
+// NewIncomingKey generates a unique staging key for a presigned upload
+func NewIncomingKey(filename string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	return path.Join(IncomingPrefix, fmt.Sprintf("%s_%d%s", uuid.New().String(), time.Now().Unix(), ext))
+}
+
 // SaveUploadedImage saves the uploaded image and returns file path and URL
 func SaveUploadedImage(c *gin.Context, fileHeader *multipart.FileHeader) (*ImageUploadResponse, error) {
-	// Validate file
+	return saveImage(c.Request.Context(), fileHeader, false)
+}
+
+// SavePrivateUploadedImage saves the uploaded image outside the public upload
+// prefix. The returned ImageURL is empty; use SignedFileURL to hand out
+// temporary access.
+func SavePrivateUploadedImage(c *gin.Context, fileHeader *multipart.FileHeader) (*ImageUploadResponse, error) {
+	return saveImage(c.Request.Context(), fileHeader, true)
+}
+
+// SaveUploadedBrandLogo saves an uploaded brand logo
+func SaveUploadedBrandLogo(c *gin.Context, fileHeader *multipart.FileHeader) (*ImageUploadResponse, error) {
 	if err := ValidateImageFile(fileHeader); err != nil {
 		return nil, err
 	}
 
-	// Generate unique filename
-	ext := filepath.Ext(fileHeader.Filename)
-	filename := fmt.Sprintf("%s_%d%s", uuid.New().String(), time.Now().Unix(), ext)
-	filePath := filepath.Join(UploadDir, filename)
+	src, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uploaded file: %v", err)
+	}
+	defer src.Close()
+
+	return StoreImageUnder(c.Request.Context(), src, MaxFileSize, BrandLogoPrefix, false)
+}
+
+// saveImage validates the uploaded image and hands it to StoreImage
+func saveImage(ctx context.Context, fileHeader *multipart.FileHeader, private bool) (*ImageUploadResponse, error) {
+	// Validate file
+	if err := ValidateImageFile(fileHeader); err != nil {
+		return nil, err
+	}
 
 	// Open uploaded file
 	src, err := fileHeader.Open()
@@ -74,38 +148,79 @@ func SaveUploadedImage(c *gin.Context, fileHeader *multipart.FileHeader) (*Image
 	}
 	defer src.Close()
 
-	// Create destination file
-	dst, err := os.Create(filePath)
+	return StoreImage(ctx, src, MaxFileSize, private)
+}
+
+// ImportIncomingUpload moves a verified presigned upload from the staging
+// prefix into content-addressed storage, stripping its metadata on the way
+func ImportIncomingUpload(ctx context.Context, key string, private bool) (*ImageUploadResponse, error) {
+	reader, err := storage.Default.Open(ctx, key)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create destination file: %v", err)
+		return nil, fmt.Errorf("failed to read uploaded file: %v", err)
 	}
-	defer dst.Close()
+	defer reader.Close()
 
-	// Copy file content
-	if _, err := io.Copy(dst, src); err != nil {
-		return nil, fmt.Errorf("failed to save file: %v", err)
+	imageResponse, err := StoreImage(ctx, reader, MaxDirectUploadSize, private)
+	if err != nil {
+		return nil, err
 	}
 
-	// Generate URL (assuming server runs on localhost:8081)
-	imageURL := fmt.Sprintf("http://localhost:8081/uploads/products/%s", filename)
+	// The staging copy still carries the original metadata
+	if err := storage.Default.Delete(ctx, key); err != nil {
+		log.Printf("failed to delete staged upload %s: %v", key, err)
+	}
 
-	return &ImageUploadResponse{
-		ImagePath: filePath,
-		ImageURL:  imageURL,
-	}, nil
+	return imageResponse, nil
 }
 
-// DeleteImage deletes an image file from the filesystem
+// VerifyStoredImage checks an object uploaded directly to storage: it must
+// exist, fit within maxSize and actually contain an allowed image type
+func VerifyStoredImage(ctx context.Context, key string, maxSize int64) (*storage.ObjectInfo, error) {
+	info, err := storage.Default.Stat(ctx, key)
+	if err == storage.ErrNotFound {
+		return nil, fmt.Errorf("uploaded file not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect uploaded file: %v", err)
+	}
+
+	if info.Size == 0 {
+		return nil, fmt.Errorf("uploaded file is empty")
+	}
+	if info.Size > maxSize {
+		return nil, fmt.Errorf("file size exceeds maximum limit of %dMB", maxSize>>20)
+	}
+
+	// Sniff the content instead of trusting the extension or header
+	reader, err := storage.Default.Open(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read uploaded file: %v", err)
+	}
+	defer reader.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(reader, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("failed to read uploaded file: %v", err)
+	}
+
+	detected := http.DetectContentType(head[:n])
+	if detected != allowedImageTypes[strings.ToLower(path.Ext(key))] {
+		return nil, fmt.Errorf("uploaded file is not a valid %s image", strings.TrimPrefix(path.Ext(key), "."))
+	}
+	info.ContentType = detected
+
+	return info, nil
+}
+
+// DeleteImage deletes a file from storage regardless of its references. Use
+// ReleaseImage for images attached to records.
 func DeleteImage(imagePath string) error {
 	if imagePath == "" {
 		return nil
 	}
 
-	if _, err := os.Stat(imagePath); os.IsNotExist(err) {
-		return nil // File doesn't exist, nothing to delete
-	}
-
-	return os.Remove(imagePath)
+	return storage.Default.Delete(context.Background(), storage.KeyFromPath(imagePath))
 }
 
 // UpdateImage handles image update - deletes old image and saves new one
@@ -116,10 +231,10 @@ func UpdateImage(c *gin.Context, fileHeader *multipart.FileHeader, oldImagePath
 		return nil, err
 	}
 
-	// Delete old image if exists
+	// Release old image if exists
 	if oldImagePath != "" {
-		DeleteImage(oldImagePath)
+		ReleaseImage(oldImagePath)
 	}
 
 	return imageResponse, nil
-}
\ No newline at end of file
+}
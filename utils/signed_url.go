@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"backend/config"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DefaultSignedURLTTL is how long a signed file URL stays valid when the
+// caller does not ask for a specific lifetime.
+const DefaultSignedURLTTL = 15 * time.Minute
+
+// MaxSignedURLTTL caps the lifetime a client may request for a signed URL.
+const MaxSignedURLTTL = 24 * time.Hour
+
+// SignFilePath returns the HMAC signature for a private file path. A non-zero
+// userID binds the signature to that user so the URL only works together with
+// their token.
+func SignFilePath(filePath string, expiresAt int64, userID uint) string {
+	mac := hmac.New(sha256.New, config.UploadSigningKey)
+	fmt.Fprintf(mac, "%s\n%d\n%d", filePath, expiresAt, userID)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignedFileURL builds an absolute URL to a private file that expires after ttl
+func SignedFileURL(filePath string, ttl time.Duration, userID uint) string {
+	if ttl <= 0 {
+		ttl = DefaultSignedURLTTL
+	}
+	if ttl > MaxSignedURLTTL {
+		ttl = MaxSignedURLTTL
+	}
+
+	expiresAt := time.Now().Add(ttl).Unix()
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expiresAt, 10))
+	if userID != 0 {
+		query.Set("uid", strconv.FormatUint(uint64(userID), 10))
+	}
+	query.Set("signature", SignFilePath(filePath, expiresAt, userID))
+
+	return fmt.Sprintf("%s/files/%s?%s", config.AppBaseURL, filePath, query.Encode())
+}
+
+// VerifyFileSignature checks that signature matches the file path, expiry and
+// bound user, and that the URL has not expired yet
+func VerifyFileSignature(filePath string, expiresAt int64, userID uint, signature string) error {
+	if time.Now().Unix() > expiresAt {
+		return fmt.Errorf("signed URL has expired")
+	}
+
+	expected := SignFilePath(filePath, expiresAt, userID)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("invalid signature")
+	}
+
+	return nil
+}
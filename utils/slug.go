@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Slugify turns a name into a URL friendly slug, e.g. "Home & Garden" into
+// "home-garden"
+func Slugify(name string) string {
+	var b strings.Builder
+	dash := false
+
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+
+	return strings.TrimSuffix(b.String(), "-")
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"backend/models"
+	"backend/storage"
+	"context"
+	"fmt"
+	"io"
+	"log"
+)
+
+// ResumableUploadPrefix holds the chunks of unfinished resumable uploads.
+// They are cleaned up by the upload session expirer, not the orphan sweeper.
+const ResumableUploadPrefix = "resumable"
+
+// UploadChunkKey returns the storage key of chunk index of a session
+func UploadChunkKey(session *models.UploadSession, index int) string {
+	return fmt.Sprintf("%s/%06d", session.ChunkPrefix, index)
+}
+
+// OpenUploadChunks returns a reader over all received chunks in order
+func OpenUploadChunks(ctx context.Context, session *models.UploadSession) io.ReadCloser {
+	keys := make([]string, session.ChunkCount)
+	for i := range keys {
+		keys[i] = UploadChunkKey(session, i)
+	}
+	return storage.NewConcatReader(ctx, storage.Default, keys)
+}
+
+// DeleteUploadChunks removes every chunk of a session from storage
+func DeleteUploadChunks(ctx context.Context, session *models.UploadSession) {
+	for i := 0; i < session.ChunkCount; i++ {
+		key := UploadChunkKey(session, i)
+		if err := storage.Default.Delete(ctx, key); err != nil {
+			log.Printf("failed to delete upload chunk %s: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"backend/config"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UploadToken ties a presigned upload to the product it was issued for, so
+// the completion request cannot attach an arbitrary storage key
+type UploadToken struct {
+	Key       string    `json:"key"`
+	ProductID uuid.UUID `json:"product_id"`
+	Private   bool      `json:"private"`
+	ExpiresAt int64     `json:"exp"`
+}
+
+// SignUploadToken encodes and signs the token
+func SignUploadToken(token UploadToken) (string, error) {
+	payload, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + signUploadPayload(encoded), nil
+}
+
+// ParseUploadToken verifies the signature and expiry of a token created by
+// SignUploadToken
+func ParseUploadToken(value string) (*UploadToken, error) {
+	encoded, signature, found := strings.Cut(value, ".")
+	if !found || !hmac.Equal([]byte(signUploadPayload(encoded)), []byte(signature)) {
+		return nil, fmt.Errorf("invalid upload token")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upload token")
+	}
+
+	var token UploadToken
+	if err := json.Unmarshal(payload, &token); err != nil {
+		return nil, fmt.Errorf("invalid upload token")
+	}
+
+	if time.Now().Unix() > token.ExpiresAt {
+		return nil, fmt.Errorf("upload token has expired")
+	}
+
+	return &token, nil
+}
+
+func signUploadPayload(encoded string) string {
+	mac := hmac.New(sha256.New, config.UploadSigningKey)
+	mac.Write([]byte("upload-token\n" + encoded))
+	return hex.EncodeToString(mac.Sum(nil))
+}